@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"keystone/internal/agent"
 	"keystone/internal/tickets"
@@ -12,8 +11,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// newAgentCmd creates the "agent" command and subcommands
-func newAgentCmd(managerProvider func() *agent.AgentManager) *cobra.Command {
+// newAgentCmd creates the "agent" command and subcommands.
+// dirProvider returns the agents directory once flags and config are resolved.
+func newAgentCmd(managerProvider func() *agent.AgentManager, dirProvider func() string) *cobra.Command {
 	var (
 		providerFlag      string
 		modelFlag         string
@@ -21,6 +21,8 @@ func newAgentCmd(managerProvider func() *agent.AgentManager) *cobra.Command {
 		cliParametersJSON string
 		ticketFlag        string
 		verboseFlag       bool
+		inputFile         string
+		outputFile        string
 	)
 
 	agentCmd := &cobra.Command{
@@ -28,33 +30,25 @@ func newAgentCmd(managerProvider func() *agent.AgentManager) *cobra.Command {
 		Short: "Manage and run Keystone agents",
 	}
 
-	// -------- list command --------
-	agentCmd.AddCommand(&cobra.Command{
-		Use:   "list",
-		Short: "List available agents",
-		Run: func(cmd *cobra.Command, args []string) {
-			manager := managerProvider()
-			agents := manager.List()
-			out := make([]map[string]string, 0, len(agents))
-			for _, a := range agents {
-				out = append(out, map[string]string{
-					"id":          a.ID(),
-					"name":        a.Name(),
-					"description": a.Description(),
-				})
-			}
-			printOrJSON(out, fmt.Sprintf("Available agents listed (%d)", len(out)), cmd)
-		},
-	})
-
 	// -------- run command --------
 	runCmd := &cobra.Command{
 		Use:   "run [agentName] [input]",
 		Short: "Run an agent with an input string (optionally bound to a ticket)",
-		Args:  cobra.MinimumNArgs(2),
+		Long: `Run an agent once and print its response.
+
+The input is the remaining arguments joined by spaces, or read verbatim from
+--input-file, from stdin when the input is "-", or from stdin when it is piped
+and no input arguments are given:
+
+  cat doc.md | keystone agent run summarizer
+  keystone agent run summarizer --input-file doc.md --output-file summary.md`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			agentName := args[0]
-			input := strings.Join(args[1:], " ")
+			input, err := readAgentInput(cmd, args[1:], inputFile)
+			if err != nil {
+				return err
+			}
 
 			mgr := managerProvider()
 			a, err := mgr.Get(agentName)
@@ -68,14 +62,18 @@ func newAgentCmd(managerProvider func() *agent.AgentManager) *cobra.Command {
 				return fmt.Errorf("ticket error: %w", err)
 			}
 
-			finalParams, err := mergeCLIParams(a.Parameters(), cliParametersJSON, cmd)
+			finalParams, err := mergeCLIParams(a, cliParametersJSON, cmd)
 			if err != nil {
 				return err
 			}
 
-			finalInput, usedTemplate := applyPromptTemplate(a.PromptTemplate(), cliPromptTemplate, finalParams, input)
+			vars := agent.PromptVars{Params: finalParams, Template: cliPromptTemplate}
+			ctx, finalInput, err := agent.PrepareInput(context.Background(), a, input, vars, ticket)
+			if err != nil {
+				PrintError("agent run", fmt.Sprintf("Error rendering prompt template: %v", err), cmd)
+				return err
+			}
 
-			ctx := context.Background()
 			resp, err := a.Handle(ctx, finalInput, ticket)
 			if err != nil {
 				PrintError("agent run", fmt.Sprintf("Error running agent: %v", err), cmd)
@@ -86,10 +84,6 @@ func newAgentCmd(managerProvider func() *agent.AgentManager) *cobra.Command {
 				updateTicket(ticket, a, store, verboseFlag)
 			}
 
-			if usedTemplate != "" {
-				resp = fmt.Sprintf("%s\n%s", usedTemplate, resp)
-			}
-
 			out := map[string]interface{}{
 				"agentID":    a.ID(),
 				"name":       a.Name(),
@@ -100,7 +94,15 @@ func newAgentCmd(managerProvider func() *agent.AgentManager) *cobra.Command {
 				"ticketID":   ticketFlag,
 				"status":     "ok",
 			}
-			Print(out, "", cmd)
+			msg := resp
+			if outputFile != "" {
+				if err := writeAgentOutput(outputFile, resp); err != nil {
+					return err
+				}
+				out["outputFile"] = outputFile
+				msg = fmt.Sprintf("Response written to %s", outputFile)
+			}
+			Print(out, msg, cmd)
 			return nil
 		},
 	}
@@ -112,8 +114,24 @@ func newAgentCmd(managerProvider func() *agent.AgentManager) *cobra.Command {
 	runCmd.Flags().StringVar(&cliParametersJSON, "parameters", "", "Override parameters JSON")
 	runCmd.Flags().StringVar(&ticketFlag, "ticket", "", "Attach an existing workflow ticket ID")
 	runCmd.Flags().BoolVar(&verboseFlag, "verbose", false, "Enable verbose ticket step logging")
-
-	agentCmd.AddCommand(runCmd)
+	runCmd.Flags().StringVar(&inputFile, "input-file", "", `Read the input from a file ("-" for stdin)`)
+	runCmd.Flags().StringVar(&outputFile, "output-file", "", "Write the response to a file instead of stdout")
+
+	agentCmd.AddCommand(
+		newAgentListCmd(managerProvider),
+		runCmd,
+		newAgentChatCmd(managerProvider),
+		newAgentBatchCmd(managerProvider),
+		newAgentCreateCmd(dirProvider),
+		newAgentShowCmd(dirProvider),
+		newAgentEditCmd(dirProvider),
+		newAgentDeleteCmd(dirProvider),
+		newAgentExportCmd(dirProvider),
+		newAgentImportCmd(dirProvider),
+		newAgentValidateCmd(dirProvider),
+		newAgentWatchCmd(managerProvider, dirProvider),
+		newAgentTestCmd(managerProvider, dirProvider),
+	)
 	agentCmd.PersistentFlags().Bool("json", false, "Output results in JSON format")
 
 	return agentCmd
@@ -140,7 +158,7 @@ func loadOrCreateTicket(ticketID string) (*tickets.Ticket, *tickets.Store, error
 	tkt, err := store.Load("default", ticketID)
 	if err != nil {
 		// Create new ticket if missing
-		tkt = tickets.NewTicket("default", ticketID, nil)
+		tkt = tickets.NewTicket(ticketID, "default", nil)
 		if saveErr := store.Save(tkt); saveErr != nil {
 			return nil, nil, saveErr
 		}
@@ -150,34 +168,33 @@ func loadOrCreateTicket(ticketID string) (*tickets.Ticket, *tickets.Store, error
 	return tkt, store, nil
 }
 
-func mergeCLIParams(base map[string]string, cliJSON string, cmd *cobra.Command) (map[string]string, error) {
-	if cliJSON == "" {
-		return base, nil
-	}
+// mergeCLIParams layers the --parameters JSON over the agent's parameters and
+// validates the result against the agent's parameter schema.
+func mergeCLIParams(a agent.Agent, cliJSON string, cmd *cobra.Command) (map[string]string, error) {
 	var cliParams map[string]string
-	if err := json.Unmarshal([]byte(cliJSON), &cliParams); err != nil {
-		PrintError("agent run", fmt.Sprintf("Invalid JSON parameters: %v", err), cmd)
-		return nil, err
+	if cliJSON != "" {
+		var err error
+		if cliParams, err = decodeParamsJSON(cliJSON); err != nil {
+			PrintError("agent run", fmt.Sprintf("Invalid JSON parameters: %v", err), cmd)
+			return nil, err
+		}
 	}
-	for k, v := range cliParams {
-		base[k] = v
+	params, err := agent.MergeParams(a, cliParams)
+	if err != nil {
+		PrintError("agent run", err.Error(), cmd)
+		return nil, err
 	}
-	return base, nil
+	return params, nil
 }
 
-func applyPromptTemplate(agentTemplate, cliTemplate string, params map[string]string, input string) (string, string) {
-	usedTemplate := agentTemplate
-	if cliTemplate != "" {
-		usedTemplate = cliTemplate
-	}
-	finalInput := input
-	if usedTemplate != "" {
-		for k, v := range params {
-			usedTemplate = strings.ReplaceAll(usedTemplate, fmt.Sprintf("{{%s}}", k), v)
-		}
-		finalInput = fmt.Sprintf("%s\n%s", usedTemplate, input)
+// decodeParamsJSON parses a JSON object of parameters. Numbers, booleans and
+// arrays are accepted and converted to the string form agents store them in.
+func decodeParamsJSON(data string) (map[string]string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, err
 	}
-	return finalInput, usedTemplate
+	return agent.ParamsFromJSON(raw)
 }
 
 func updateTicket(ticket *tickets.Ticket, a agent.Agent, store *tickets.Store, verbose bool) {
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"keystone/internal/agent"
+	"keystone/internal/batch"
+
+	"github.com/spf13/cobra"
+)
+
+// newAgentBatchCmd creates "agent batch", which runs an agent over every record in
+// a JSONL or CSV file and writes one JSONL result per record.
+func newAgentBatchCmd(managerProvider func() *agent.AgentManager) *cobra.Command {
+	var (
+		inputPath   string
+		outputPath  string
+		concurrency int
+		paramsJSON  string
+		resume      bool
+		retryErrors bool
+		overwrite   bool
+	)
+
+	batchCmd := &cobra.Command{
+		Use:   "batch <id>",
+		Short: "Run an agent over every record in a JSONL or CSV file",
+		Long: `Run an agent over every record in --input and write one JSONL result per record
+({"index", "id", "input", "output", "error", "duration_ms"}) to --output.
+
+JSONL records are a JSON string or {"id", "input", "params"}; CSV files need an
+"input" column, may have an "id" column, and pass other columns as parameters.
+A failing record is captured in its result and the batch carries on. After an
+interruption, rerun with --resume to skip records that already have results.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if inputPath == "" || outputPath == "" {
+				return fmt.Errorf("--input and --output are required")
+			}
+			cmd.SilenceUsage = true
+
+			a, err := managerProvider().Get(args[0])
+			if err != nil {
+				return err
+			}
+			var base map[string]string
+			if paramsJSON != "" {
+				if base, err = decodeParamsJSON(paramsJSON); err != nil {
+					return fmt.Errorf("invalid --parameters: %w", err)
+				}
+			}
+			records, err := batch.ReadRecords(inputPath)
+			if err != nil {
+				return err
+			}
+			done := map[int]bool{}
+			if resume {
+				if done, err = batch.Completed(outputPath, retryErrors); err != nil {
+					return err
+				}
+			}
+			out, err := batch.OpenResults(outputPath, resume, overwrite)
+			if err != nil {
+				return err
+			}
+			defer out.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			var lastReport time.Time
+			runner := &batch.Runner{
+				Agent:       a,
+				Concurrency: concurrency,
+				Params:      base,
+				OnProgress: func(p batch.Progress) {
+					if time.Since(lastReport) < time.Second && p.Done() < p.Total {
+						return
+					}
+					lastReport = time.Now()
+					fmt.Fprintf(cmd.ErrOrStderr(), "Progress: %d/%d (%d ok, %d failed, %d skipped)\n",
+						p.Done(), p.Total, p.Succeeded, p.Failed, p.Skipped)
+				},
+			}
+			progress, err := runner.Run(ctx, records, done, out)
+			if err != nil {
+				return fmt.Errorf("%w; rerun with --resume to continue", err)
+			}
+
+			Print(progress, fmt.Sprintf("Batch complete: %d record(s), %d ok, %d failed, %d skipped; results in %s",
+				progress.Total, progress.Succeeded, progress.Failed, progress.Skipped, outputPath), cmd)
+			return nil
+		},
+	}
+
+	f := batchCmd.Flags()
+	f.StringVar(&inputPath, "input", "", "JSONL or CSV file of records")
+	f.StringVar(&outputPath, "output", "", "JSONL file to write results to")
+	f.IntVar(&concurrency, "concurrency", 1, "number of records to run at once")
+	f.StringVar(&paramsJSON, "parameters", "", "parameter overrides (JSON) applied before each record's own")
+	f.BoolVar(&resume, "resume", false, "skip records that already have results in --output and append the rest")
+	f.BoolVar(&retryErrors, "retry-errors", false, "with --resume, run failed records again")
+	f.BoolVar(&overwrite, "overwrite", false, "replace an existing --output instead of refusing")
+	return batchCmd
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"keystone/internal/agent"
+)
+
+func TestAgentBatchWritesAndResumes(t *testing.T) {
+	manager := agent.NewManager()
+	_ = manager.Register(agent.NewAgent("echo", "Echo", "", &agent.MockProvider{}, "m", "none",
+		agent.WithPromptTemplate("{{input}} in {{lang}}")))
+
+	run := func(args ...string) (string, error) {
+		return runCLI(t, cliOptions{manager: manager, stderr: io.Discard}, append([]string{"agent", "batch", "echo"}, args...)...)
+	}
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	output := filepath.Join(dir, "out.jsonl")
+	if err := os.WriteFile(input, []byte("id,input,lang\na,hello,fr\nb,bye,\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := run("--input", input, "--output", output, "--concurrency", "2", "--parameters", `{"lang": "en"}`)
+	if err != nil || !strings.Contains(out, "Batch complete: 2 record(s), 2 ok, 0 failed, 0 skipped") {
+		t.Fatalf("batch failed: %v\n%s", err, out)
+	}
+	data, _ := os.ReadFile(output)
+	if !strings.Contains(string(data), `"id":"a"`) || !strings.Contains(string(data), "mock response: hello in fr") || !strings.Contains(string(data), "mock response: bye in en") {
+		t.Errorf("unexpected results:\n%s", data)
+	}
+
+	if _, err := run("--input", input, "--output", output); err == nil || !strings.Contains(err.Error(), "use --resume") {
+		t.Errorf("expected refusal to overwrite, got %v", err)
+	}
+	out, err = run("--input", input, "--output", output, "--resume")
+	if err != nil || !strings.Contains(out, "0 ok, 0 failed, 2 skipped") {
+		t.Errorf("resume should skip finished records: %v\n%s", err, out)
+	}
+}
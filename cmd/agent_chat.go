@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"keystone/internal/agent"
+	"keystone/internal/memory"
+	"keystone/internal/tickets"
+
+	"github.com/spf13/cobra"
+)
+
+const chatHelp = `Commands:
+  /reset            clear the conversation and start a new ticket
+  /ticket [id]      show the current ticket or switch to another one
+  /params [json]    show parameters or merge a JSON object / key=value pair
+  /model [name]     show or override the model for this session
+  /save <path>      write the transcript to a file
+  /help             show this help
+  /exit             end the session`
+
+// chatSession holds the state of an interactive agent chat.
+type chatSession struct {
+	agent      agent.Agent
+	ticket     *tickets.Ticket
+	store      *tickets.Store
+	params     map[string]string
+	model      string
+	history    *memory.Conversation // used when the agent has no memory of its own
+	transcript []string
+	out        io.Writer
+}
+
+// newAgentChatCmd creates "agent chat", an interactive REPL bound to one agent.
+func newAgentChatCmd(managerProvider func() *agent.AgentManager) *cobra.Command {
+	var ticketFlag string
+
+	chatCmd := &cobra.Command{
+		Use:   "chat [agentID]",
+		Short: "Chat with an agent interactively",
+		Long:  "Start a REPL that keeps a ticket and conversation memory across turns.\n\n" + chatHelp,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			a, err := managerProvider().Get(args[0])
+			if err != nil {
+				PrintError("agent chat", fmt.Sprintf("Agent '%s' not found", args[0]), cmd)
+				return nil
+			}
+
+			s := &chatSession{
+				agent:   a,
+				params:  copyParams(a.Parameters()),
+				history: &memory.Conversation{},
+				out:     cmd.OutOrStdout(),
+			}
+			if err := s.openTicket(ticketFlag); err != nil {
+				return fmt.Errorf("ticket error: %w", err)
+			}
+
+			fmt.Fprintf(s.out, "Chatting with %s (%s) on ticket %s. Type /help for commands.\n", a.Name(), a.ID(), s.ticket.ID)
+			err = s.loop(cmd.Context(), cmd.InOrStdin())
+			if saveErr := s.finish(); saveErr != nil && err == nil {
+				err = saveErr
+			}
+			return err
+		},
+	}
+
+	chatCmd.Flags().StringVar(&ticketFlag, "ticket", "", "Continue an existing ticket ID")
+	return chatCmd
+}
+
+// loop reads lines until EOF or /exit, dispatching slash commands and agent turns.
+func (s *chatSession) loop(ctx context.Context, in io.Reader) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for {
+		fmt.Fprint(s.out, "you> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(s.out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "/") {
+			done, err := s.command(line)
+			if err != nil {
+				fmt.Fprintf(s.out, "Error: %v\n", err)
+			}
+			if done {
+				return nil
+			}
+			continue
+		}
+		if err := s.turn(ctx, line); err != nil {
+			fmt.Fprintf(s.out, "Error: %v\n", err)
+		}
+	}
+}
+
+// turn sends one user message to the agent and streams the reply.
+func (s *chatSession) turn(ctx context.Context, line string) error {
+	input := line
+	if !s.agentHasMemory() {
+		input = s.history.Prepend(line)
+	}
+	ctx, input, err := agent.PrepareInput(ctx, s.agent, input, agent.PromptVars{Params: s.params}, s.ticket)
+	if err != nil {
+		return err
+	}
+
+	ctx = agent.WithModelOverride(ctx, s.model)
+	fmt.Fprintf(s.out, "%s> ", s.agent.ID())
+	var resp string
+	if sa, ok := s.agent.(agent.StreamingAgent); ok {
+		resp, err = sa.HandleStream(ctx, input, s.ticket, func(chunk string) { fmt.Fprint(s.out, chunk) })
+	} else {
+		resp, err = s.agent.Handle(ctx, input, s.ticket)
+		if err == nil {
+			fmt.Fprint(s.out, resp)
+		}
+	}
+	fmt.Fprintln(s.out)
+	if err != nil {
+		return err
+	}
+
+	s.history.Append(memory.RoleUser, line)
+	s.history.Append(memory.RoleAssistant, resp)
+	s.transcript = append(s.transcript, "User: "+line, s.agent.Name()+": "+resp)
+	updateTicket(s.ticket, s.agent, s.store, false)
+	return nil
+}
+
+// command handles a slash command and reports whether the session should end.
+func (s *chatSession) command(line string) (bool, error) {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "/exit", "/quit":
+		return true, nil
+	case "/help":
+		fmt.Fprintln(s.out, chatHelp)
+	case "/reset":
+		if err := s.resetMemory(); err != nil {
+			return false, err
+		}
+		if err := s.openTicket(""); err != nil {
+			return false, err
+		}
+		s.history = &memory.Conversation{}
+		s.transcript = nil
+		fmt.Fprintf(s.out, "Conversation reset. New ticket %s\n", s.ticket.ID)
+	case "/ticket":
+		if arg == "" {
+			fmt.Fprintf(s.out, "Ticket %s (Step: %d, Hops: %d)\n", s.ticket.ID, s.ticket.Step, s.ticket.Hops)
+			return false, nil
+		}
+		if err := s.saveTranscript(); err != nil {
+			return false, err
+		}
+		if err := s.openTicket(arg); err != nil {
+			return false, err
+		}
+		s.history = &memory.Conversation{}
+		s.transcript = nil
+		fmt.Fprintf(s.out, "Switched to ticket %s\n", s.ticket.ID)
+	case "/params":
+		if arg != "" {
+			if err := s.setParams(arg); err != nil {
+				return false, err
+			}
+		}
+		data, _ := json.MarshalIndent(s.params, "", "  ")
+		fmt.Fprintln(s.out, string(data))
+	case "/model":
+		if arg != "" {
+			s.model = arg
+		}
+		model := s.model
+		if model == "" {
+			model = s.agent.DefaultModel()
+		}
+		fmt.Fprintf(s.out, "Model: %s\n", model)
+	case "/save":
+		if arg == "" {
+			return false, fmt.Errorf("usage: /save <path>")
+		}
+		if err := os.WriteFile(arg, []byte(s.transcriptText()), 0o644); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(s.out, "Transcript saved to %s\n", arg)
+	default:
+		return false, fmt.Errorf("unknown command %s (try /help)", name)
+	}
+	return false, nil
+}
+
+// setParams merges a JSON object or a single key=value pair into the session parameters.
+func (s *chatSession) setParams(arg string) error {
+	if strings.HasPrefix(arg, "{") {
+		p, err := decodeParamsJSON(arg)
+		if err != nil {
+			return fmt.Errorf("invalid JSON parameters: %w", err)
+		}
+		for k, v := range p {
+			s.params[k] = v
+		}
+		return nil
+	}
+	k, v, ok := strings.Cut(arg, "=")
+	if !ok || strings.TrimSpace(k) == "" {
+		return fmt.Errorf("usage: /params {\"key\":\"value\"} or /params key=value")
+	}
+	s.params[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	return nil
+}
+
+// openTicket loads or creates the ticket with id, or a fresh chat ticket when id is empty.
+func (s *chatSession) openTicket(id string) error {
+	if id != "" {
+		t, store, err := loadOrCreateTicket(id)
+		if err != nil {
+			return err
+		}
+		s.ticket, s.store = t, store
+		return nil
+	}
+	s.store = tickets.NewStore(tickets.TicketDir)
+	s.ticket = tickets.NewTicket(tickets.NewID("cli", "chat", s.agent.ID()), "default", nil)
+	return s.store.Save(s.ticket)
+}
+
+func (s *chatSession) agentHasMemory() bool {
+	m, ok := s.agent.(interface{ MemoryEnabled() bool })
+	return ok && m.MemoryEnabled()
+}
+
+func (s *chatSession) resetMemory() error {
+	if r, ok := s.agent.(interface {
+		ResetMemory(*tickets.Ticket) error
+	}); ok {
+		return r.ResetMemory(s.ticket)
+	}
+	return nil
+}
+
+func (s *chatSession) transcriptText() string {
+	if len(s.transcript) == 0 {
+		return ""
+	}
+	return strings.Join(s.transcript, "\n") + "\n"
+}
+
+// saveTranscript writes the transcript into the agent's namespace on the ticket.
+func (s *chatSession) saveTranscript() error {
+	if len(s.transcript) == 0 {
+		return nil
+	}
+	s.ticket.SetNamespaced(s.agent.ID(), "transcript", s.transcriptText())
+	return s.store.Save(s.ticket)
+}
+
+// finish stores the transcript on the ticket when the session ends.
+func (s *chatSession) finish() error {
+	if err := s.saveTranscript(); err != nil {
+		return fmt.Errorf("saving transcript: %w", err)
+	}
+	if len(s.transcript) > 0 {
+		fmt.Fprintf(s.out, "Transcript saved to ticket %s\n", s.ticket.ID)
+	}
+	return nil
+}
+
+// copyParams returns a copy of params so session changes don't leak into the agent.
+func copyParams(params map[string]string) map[string]string {
+	out := make(map[string]string, len(params))
+	for k, v := range params {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"keystone/internal/agent"
+	"keystone/internal/tickets"
+)
+
+// runChatCLI runs "agent chat" with the given stdin and returns its output.
+func runChatCLI(t *testing.T, stdin string, args ...string) string {
+	t.Helper()
+	manager := agent.NewManager()
+	_ = manager.Register(agent.NewAgent("chatty", "Chatty", "chat test agent", &agent.MockProvider{}, "base-model", "none"))
+
+	opts := cliOptions{manager: manager, store: tickets.NewStore(t.TempDir()), stdin: stdin}
+	out, err := runCLI(t, opts, append([]string{"agent", "chat"}, args...)...)
+	if err != nil {
+		t.Fatalf("agent chat failed: %v\n%s", err, out)
+	}
+	return out
+}
+
+func TestAgentChatREPL(t *testing.T) {
+	tickets.TicketDir = t.TempDir()
+	transcriptPath := filepath.Join(t.TempDir(), "chat.txt")
+
+	input := strings.Join([]string{
+		"hello",
+		"/params tone=dry",
+		"/model tiny",
+		"again",
+		"/save " + transcriptPath,
+		"/ticket",
+		"/bogus",
+		"/exit",
+	}, "\n")
+	out := runChatCLI(t, input, "chatty", "--ticket", "chat-t1")
+
+	if !strings.Contains(out, "chatty> mock response: hello") {
+		t.Errorf("expected first reply, got:\n%s", out)
+	}
+	// The second turn carries the earlier conversation.
+	if !strings.Contains(out, "User: hello") || !strings.Contains(out, "Assistant: mock response: hello") {
+		t.Errorf("expected history in second turn, got:\n%s", out)
+	}
+	if !strings.Contains(out, `"tone": "dry"`) || !strings.Contains(out, "Model: tiny") {
+		t.Errorf("expected params and model updates, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Ticket chat-t1 (Step: 2, Hops: 2)") {
+		t.Errorf("expected ticket status, got:\n%s", out)
+	}
+	if !strings.Contains(out, "unknown command /bogus") {
+		t.Errorf("expected unknown command error, got:\n%s", out)
+	}
+
+	saved, err := os.ReadFile(transcriptPath)
+	if err != nil {
+		t.Fatalf("transcript not saved: %v", err)
+	}
+	if !strings.HasPrefix(string(saved), "User: hello\nChatty: mock response: hello\nUser: again\n") {
+		t.Errorf("unexpected transcript:\n%s", saved)
+	}
+
+	tkt, err := tickets.NewStore(tickets.TicketDir).Load("default", "chat-t1")
+	if err != nil {
+		t.Fatalf("ticket not saved: %v", err)
+	}
+	if v, _ := tkt.GetNamespaced("chatty", "transcript"); v != string(saved) {
+		t.Errorf("expected transcript on ticket, got %q", v)
+	}
+}
+
+func TestAgentChatReset(t *testing.T) {
+	tickets.TicketDir = t.TempDir()
+
+	out := runChatCLI(t, "hello\n/reset\nagain\n", "chatty")
+	if !strings.Contains(out, "Conversation reset. New ticket") {
+		t.Fatalf("expected reset message, got:\n%s", out)
+	}
+	if strings.Contains(out, "User: hello") {
+		t.Errorf("history should be cleared after /reset, got:\n%s", out)
+	}
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"keystone/internal/agent"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// newAgentCreateCmd creates "agent create", which writes a new agent config.
+// Fields not given as flags are prompted for interactively.
+func newAgentCreateCmd(dirProvider func() string) *cobra.Command {
+	var (
+		cfg         agent.AgentConfig
+		params      []string
+		force       bool
+		interactive bool
+	)
+
+	createCmd := &cobra.Command{
+		Use:   "create [agentID]",
+		Short: "Create a new agent config",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			dir := dirProvider()
+			cfg.ID = args[0]
+
+			// An agent being overwritten keeps its file, so no second file defines the ID.
+			path := filepath.Join(dir, cfg.ID+".yaml")
+			index, err := agent.IndexConfigDir(dir)
+			existing, exists := index[cfg.ID]
+			if err == nil && exists {
+				if !force {
+					return fmt.Errorf("agent %s already exists in %s (use --force to overwrite)", cfg.ID, existing.Path)
+				}
+				path = existing.Path
+			}
+
+			p, err := parseParamFlags(params)
+			if err != nil {
+				return err
+			}
+			cfg.Parameters = p
+
+			if interactive || (cfg.Name == "" && cfg.Extends == "") {
+				if err := promptAgentConfig(bufio.NewReader(cmd.InOrStdin()), cmd, &cfg); err != nil {
+					return err
+				}
+			}
+
+			if err := checkAgentConfig(cmd, dir, path, cfg); err != nil {
+				return err
+			}
+			if exists {
+				err = agent.WriteConfigFile(path, cfg)
+			} else {
+				err = agent.NewLifecycleManager(dir, nil).SaveOrMergeConfig(cfg)
+			}
+			if err != nil {
+				return err
+			}
+			Print(map[string]string{"status": "created", "agentID": cfg.ID, "path": path},
+				fmt.Sprintf("Created agent '%s' at %s", cfg.ID, path), cmd)
+			return nil
+		},
+	}
+
+	f := createCmd.Flags()
+	f.StringVar(&cfg.Name, "name", "", "display name")
+	f.StringVar(&cfg.Description, "description", "", "description")
+	f.StringVar(&cfg.Extends, "extends", "", "agent ID to inherit from")
+	f.StringVar(&cfg.Provider, "provider", "", "provider name")
+	f.StringVar(&cfg.Model, "model", "", "model name")
+	f.StringVar(&cfg.Memory, "memory", "", "memory identifier")
+	f.StringVar(&cfg.PromptTemplate, "prompt-template", "", "inline prompt template")
+	f.StringVar(&cfg.PromptRef, "prompt-ref", "", "prompt library reference (name@vN)")
+	f.StringVar(&cfg.SystemPrompt, "system-prompt", "", "instructions sent ahead of every input")
+	f.StringArrayVar(&params, "param", nil, "parameter as key=value (repeatable)")
+	f.StringSliceVar(&cfg.Tags, "tag", nil, "tag for discovery (repeatable or comma-separated)")
+	f.StringSliceVar(&cfg.Capabilities, "capability", nil, "capability the agent offers (repeatable or comma-separated)")
+	f.StringSliceVar(&cfg.Tools, "tool", nil, "agent ID this agent may call as a tool (repeatable or comma-separated)")
+	f.StringVar(&cfg.Timeout, "timeout", "", "longest a call to the agent may take, e.g. 30s")
+	f.IntVar(&cfg.MaxConcurrency, "max-concurrency", 0, "most calls to the agent that may run at once")
+	f.BoolVar(&cfg.Logging, "logging", false, "enable per-agent logging")
+	f.BoolVar(&force, "force", false, "overwrite an existing agent")
+	f.BoolVarP(&interactive, "interactive", "i", false, "prompt for every field")
+	return createCmd
+}
+
+// promptAgentConfig asks for the main fields, using current values as defaults.
+func promptAgentConfig(in *bufio.Reader, cmd *cobra.Command, cfg *agent.AgentConfig) error {
+	out := cmd.OutOrStdout()
+	defaults := func(v, def string) string {
+		if v != "" {
+			return v
+		}
+		return def
+	}
+
+	fields := []struct {
+		label string
+		dst   *string
+		def   string
+	}{
+		{"Name", &cfg.Name, defaults(cfg.Name, cfg.ID)},
+		{"Description", &cfg.Description, cfg.Description},
+		{"Provider", &cfg.Provider, defaults(cfg.Provider, "venice")},
+		{"Model", &cfg.Model, defaults(cfg.Model, "default")},
+		{"Prompt template", &cfg.PromptTemplate, defaults(cfg.PromptTemplate, "{{input}}")},
+	}
+	for _, f := range fields {
+		v, err := ask(in, out, f.label, f.def)
+		if err != nil {
+			return err
+		}
+		*f.dst = v
+	}
+	return nil
+}
+
+// checkAgentConfig validates cfg as it would be written to path, printing every
+// issue and failing when any is an error.
+func checkAgentConfig(cmd *cobra.Command, dir, path string, cfg agent.AgentConfig) error {
+	data, err := yaml.Marshal(&cfg)
+	if err != nil {
+		return err
+	}
+	return checkAgentYAML(cmd, dir, path, data)
+}
+
+// checkAgentYAML validates a YAML document destined for path.
+func checkAgentYAML(cmd *cobra.Command, dir, path string, data []byte) error {
+	v, err := newValidator(dir)
+	if err != nil {
+		return err
+	}
+	errCount := 0
+	for _, i := range v.Validate(path, data) {
+		fmt.Fprintln(cmd.ErrOrStderr(), i.String())
+		if i.Severity == agent.SeverityError {
+			errCount++
+		}
+	}
+	if errCount > 0 {
+		return fmt.Errorf("agent config has %d error(s)", errCount)
+	}
+	return nil
+}
+
+// parseParamFlags turns key=value flags into a parameter map.
+func parseParamFlags(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	params := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid --param %q (want key=value)", p)
+		}
+		params[strings.TrimSpace(k)] = v
+	}
+	return params, nil
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"keystone/internal/agent"
+)
+
+// runAgentCLI runs an agent subcommand against dir with the given stdin.
+func runAgentCLI(t *testing.T, dir, stdin string, args ...string) (string, error) {
+	t.Helper()
+	return runCLI(t, cliOptions{stdin: stdin}, append([]string{"--agents-dir", dir, "agent"}, args...)...)
+}
+
+func TestAgentCreateAndDelete(t *testing.T) {
+	dir := t.TempDir()
+
+	out, err := runAgentCLI(t, dir, "", "create", "base", "--name", "Base", "--provider", "mock", "--param", "tone=calm", "--system-prompt", "Be brief.", "--timeout", "30s", "--max-concurrency", "2")
+	if err != nil {
+		t.Fatalf("create failed: %v\n%s", err, out)
+	}
+	cfg, err := agent.ReadConfigFile(filepath.Join(dir, "base.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "Base" || cfg.Provider != "mock" || cfg.Parameters["tone"] != "calm" || cfg.SystemPrompt != "Be brief." || cfg.Timeout != "30s" || cfg.MaxConcurrency != 2 {
+		t.Errorf("unexpected config written: %+v", cfg)
+	}
+
+	if out, err := runAgentCLI(t, dir, "", "create", "base", "--name", "Again", "--provider", "mock"); err == nil {
+		t.Errorf("expected error creating an existing agent:\n%s", out)
+	}
+	if out, err := runAgentCLI(t, dir, "", "create", "bad", "--name", "Bad", "--provider", "nope"); err == nil {
+		t.Errorf("expected validation error for unknown provider:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bad.yaml")); !os.IsNotExist(err) {
+		t.Error("invalid agent should not be written")
+	}
+
+	// Interactive mode reads each field from stdin, falling back to defaults on empty lines.
+	out, err = runAgentCLI(t, dir, "Kid\nextends base\n\n\n\n\n\n", "create", "kid", "-i", "--extends", "base")
+	if err != nil {
+		t.Fatalf("interactive create failed: %v\n%s", err, out)
+	}
+	kid, err := agent.ReadConfigFile(filepath.Join(dir, "kid.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid.Name != "Kid" || kid.Extends != "base" {
+		t.Errorf("unexpected interactive config: %+v", kid)
+	}
+
+	if out, err := runAgentCLI(t, dir, "", "delete", "base", "-y"); err == nil || !strings.Contains(err.Error(), "extended by kid") {
+		t.Errorf("expected delete to refuse a parent agent, got %v\n%s", err, out)
+	}
+	out, _ = runAgentCLI(t, dir, "n\n", "delete", "kid")
+	if !strings.Contains(out, "Aborted") {
+		t.Errorf("expected delete to abort without confirmation:\n%s", out)
+	}
+	if out, err := runAgentCLI(t, dir, "y\n", "delete", "kid"); err != nil {
+		t.Fatalf("delete failed: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "kid.yaml")); !os.IsNotExist(err) {
+		t.Error("kid.yaml should have been removed")
+	}
+}
+
+func TestAgentCreateForceKeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "team", "helper.yaml")
+	if err := os.MkdirAll(filepath.Dir(nested), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(nested, []byte("id: helper\nname: Old\nprovider: mock\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runAgentCLI(t, dir, "", "create", "helper", "--name", "New", "--provider", "mock", "--force")
+	if err != nil {
+		t.Fatalf("create --force failed: %v\n%s", err, out)
+	}
+	cfg, err := agent.ReadConfigFile(nested)
+	if err != nil || cfg.Name != "New" {
+		t.Errorf("expected the existing file to be overwritten, got %+v, %v", cfg, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "helper.yaml")); !os.IsNotExist(err) {
+		t.Error("overwriting should not write a second file for the agent")
+	}
+}
+
+func TestAgentExportImport(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"base.yaml":  "id: base\nname: Base\nprovider: mock\nparameters:\n  tone: calm\n",
+		"child.yaml": "id: child\nname: Child\nextends: base\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bundle := filepath.Join(t.TempDir(), "bundle.yaml")
+	if out, err := runAgentCLI(t, src, "", "export", "-o", bundle); err != nil {
+		t.Fatalf("export failed: %v\n%s", err, out)
+	}
+
+	dst := t.TempDir()
+	out, err := runAgentCLI(t, dst, "", "import", bundle)
+	if err != nil {
+		t.Fatalf("import failed: %v\n%s", err, out)
+	}
+	index, err := agent.IndexConfigDir(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index["child"].Config.Extends != "base" || index["base"].Config.Parameters["tone"] != "calm" {
+		t.Errorf("round trip lost data: %+v", index)
+	}
+
+	out, err = runAgentCLI(t, dst, "", "import", bundle)
+	if err != nil || !strings.Contains(out, "skipped existing: base, child") {
+		t.Errorf("expected existing agents to be skipped, got %v\n%s", err, out)
+	}
+
+	// One bad document rejects the whole bundle, with lines relative to the bundle.
+	bad := "id: ok\nname: OK\nprovider: mock\n---\nid: broken\nname: Broken\nprovider: nope\n"
+	empty := t.TempDir()
+	out, err = runAgentCLI(t, empty, bad, "import", "-")
+	if err == nil {
+		t.Fatalf("expected import to fail:\n%s", out)
+	}
+	if !strings.Contains(out, "<stdin>:7: error") {
+		t.Errorf("expected issue on bundle line 7:\n%s", out)
+	}
+	if entries, _ := os.ReadDir(empty); len(entries) != 0 {
+		t.Errorf("failed import should write nothing, found %d file(s)", len(entries))
+	}
+}
+
+func TestAgentEdit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("editor script requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "helper.yaml")
+	if err := os.WriteFile(path, []byte("id: helper\nname: Helper\nprovider: mock\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	script := "#!/bin/sh\nsed -i.bak 's/name: Helper/name: Edited/' \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+
+	if out, err := runAgentCLI(t, dir, "", "edit", "helper"); err != nil {
+		t.Fatalf("edit failed: %v\n%s", err, out)
+	}
+	cfg, err := agent.ReadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "Edited" {
+		t.Errorf("expected edited name, got %q", cfg.Name)
+	}
+
+	// An edit that breaks validation is discarded when the user declines to retry.
+	script = "#!/bin/sh\nsed -i.bak 's/provider: mock/provider: nope/' \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runAgentCLI(t, dir, "n\n", "edit", "helper"); err == nil {
+		t.Errorf("expected invalid edit to fail:\n%s", out)
+	}
+	if cfg, _ := agent.ReadConfigFile(path); cfg.Provider != "mock" {
+		t.Errorf("invalid edit should leave the file unchanged, provider is %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"keystone/internal/agent"
+
+	"github.com/spf13/cobra"
+)
+
+// newAgentDeleteCmd creates "agent delete", which removes an agent's config file.
+func newAgentDeleteCmd(dirProvider func() string) *cobra.Command {
+	var yes, force bool
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [agentID]",
+		Short: "Delete an agent config",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			id := args[0]
+			dir := dirProvider()
+			index, err := agent.IndexConfigDir(dir)
+			if err != nil {
+				return err
+			}
+			file, ok := index[id]
+			if !ok {
+				return fmt.Errorf("agent %s not found in %s", id, dir)
+			}
+
+			var children []string
+			for childID, f := range index {
+				if f.Config.Extends == id {
+					children = append(children, childID)
+				}
+			}
+			sort.Strings(children)
+			if len(children) > 0 && !force {
+				return fmt.Errorf("agent %s is extended by %s (use --force to delete anyway)", id, strings.Join(children, ", "))
+			}
+
+			if !yes {
+				ok, err := confirm(bufio.NewReader(cmd.InOrStdin()), cmd.OutOrStdout(), fmt.Sprintf("Delete agent '%s' (%s)?", id, file.Path), false)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					Print(map[string]string{"status": "aborted", "agentID": id}, "Aborted.", cmd)
+					return nil
+				}
+			}
+
+			if err := os.Remove(file.Path); err != nil {
+				return fmt.Errorf("deleting %s: %w", file.Path, err)
+			}
+			Print(map[string]string{"status": "deleted", "agentID": id, "path": file.Path},
+				fmt.Sprintf("Deleted agent '%s' (%s)", id, file.Path), cmd)
+			return nil
+		},
+	}
+	deleteCmd.Flags().BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation")
+	deleteCmd.Flags().BoolVar(&force, "force", false, "delete even if other agents extend this one")
+	return deleteCmd
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"keystone/internal/agent"
+
+	"github.com/spf13/cobra"
+)
+
+// newAgentEditCmd creates "agent edit", which opens an agent's YAML in $EDITOR and
+// only writes it back once it passes validation.
+func newAgentEditCmd(dirProvider func() string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit [agentID]",
+		Short: "Edit an agent config in $EDITOR with validation on save",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			dir := dirProvider()
+			index, err := agent.IndexConfigDir(dir)
+			if err != nil {
+				return err
+			}
+			file, ok := index[args[0]]
+			if !ok {
+				return fmt.Errorf("agent %s not found in %s", args[0], dir)
+			}
+
+			original, err := os.ReadFile(file.Path)
+			if err != nil {
+				return err
+			}
+			tmp, err := os.CreateTemp("", "keystone-agent-*.yaml")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(tmp.Name())
+			if _, err := tmp.Write(original); err != nil {
+				tmp.Close()
+				return err
+			}
+			tmp.Close()
+
+			in := bufio.NewReader(cmd.InOrStdin())
+			for {
+				if err := runEditor(cmd, tmp.Name()); err != nil {
+					return err
+				}
+				edited, err := os.ReadFile(tmp.Name())
+				if err != nil {
+					return err
+				}
+				if bytes.Equal(edited, original) {
+					Print(map[string]string{"status": "unchanged", "agentID": args[0]}, fmt.Sprintf("No changes made to agent '%s'", args[0]), cmd)
+					return nil
+				}
+
+				checkErr := checkAgentYAML(cmd, dir, file.Path, edited)
+				if checkErr == nil {
+					if err := os.WriteFile(file.Path, edited, 0o644); err != nil {
+						return err
+					}
+					Print(map[string]string{"status": "updated", "agentID": args[0], "path": file.Path},
+						fmt.Sprintf("Updated agent '%s' at %s", args[0], file.Path), cmd)
+					return nil
+				}
+
+				again, err := confirm(in, cmd.OutOrStdout(), "Re-open the editor to fix the errors?", true)
+				if err != nil || !again {
+					return fmt.Errorf("edit aborted, %s left unchanged: %w", file.Path, checkErr)
+				}
+			}
+		},
+	}
+}
+
+// editorCommand returns the user's editor from $VISUAL or $EDITOR, defaulting to vi.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// runEditor opens path in the user's editor and waits for it to exit.
+func runEditor(cmd *cobra.Command, path string) error {
+	editor := editorCommand()
+	c := exec.Command(editor[0], append(editor[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = cmd.OutOrStdout()
+	c.Stderr = cmd.ErrOrStderr()
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("running editor %s: %w", editor[0], err)
+	}
+	return nil
+}
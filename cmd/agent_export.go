@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"keystone/internal/agent"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// newAgentExportCmd creates "agent export", which writes agent configs as a multi-document YAML bundle.
+func newAgentExportCmd(dirProvider func() string) *cobra.Command {
+	var (
+		output   string
+		resolved bool
+	)
+
+	exportCmd := &cobra.Command{
+		Use:   "export [agentID...]",
+		Short: "Export agent configs as a YAML bundle (all agents by default)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			dir := dirProvider()
+			index, err := agent.IndexConfigDir(dir)
+			if err != nil {
+				return err
+			}
+
+			ids := args
+			if len(ids) == 0 {
+				for id := range index {
+					ids = append(ids, id)
+				}
+				sort.Strings(ids)
+			}
+
+			configs := make([]agent.AgentConfig, 0, len(ids))
+			for _, id := range ids {
+				file, ok := index[id]
+				if !ok {
+					return fmt.Errorf("agent %s not found in %s", id, dir)
+				}
+				cfg := file.Config
+				if resolved {
+					if cfg, err = resolveConfig(index, cfg, dir, nil); err != nil {
+						return err
+					}
+				}
+				configs = append(configs, cfg)
+			}
+
+			bundle, err := encodeBundle(configs)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				Print(configs, strings.TrimRight(string(bundle), "\n"), cmd)
+				return nil
+			}
+			if err := os.WriteFile(output, bundle, 0o644); err != nil {
+				return err
+			}
+			Print(map[string]interface{}{"status": "ok", "path": output, "agents": ids},
+				fmt.Sprintf("Exported %d agent(s) to %s", len(configs), output), cmd)
+			return nil
+		},
+	}
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "write the bundle to a file instead of stdout")
+	exportCmd.Flags().BoolVar(&resolved, "resolved", false, "export fully resolved configs instead of the files as written")
+	return exportCmd
+}
+
+// newAgentImportCmd creates "agent import", which validates every agent in a bundle
+// and writes them into the agents dir only if all of them pass.
+func newAgentImportCmd(dirProvider func() string) *cobra.Command {
+	var overwrite bool
+
+	importCmd := &cobra.Command{
+		Use:   "import [bundle.yaml|-]",
+		Short: "Import agent configs from a YAML bundle",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			dir := dirProvider()
+			name := args[0]
+			var (
+				data []byte
+				err  error
+			)
+			if name == "-" {
+				data, err = io.ReadAll(cmd.InOrStdin())
+				name = "<stdin>"
+			} else {
+				data, err = os.ReadFile(name)
+			}
+			if err != nil {
+				return err
+			}
+
+			configs, err := checkBundle(cmd, dir, name, data)
+			if err != nil {
+				return err
+			}
+
+			existing, err := agent.IndexConfigDir(dir)
+			if err != nil {
+				existing = agent.ConfigIndex{}
+			}
+			lm := agent.NewLifecycleManager(dir, nil)
+			var imported, skipped []string
+			for _, cfg := range configs {
+				if f, ok := existing[cfg.ID]; ok {
+					if !overwrite {
+						skipped = append(skipped, cfg.ID)
+						continue
+					}
+					err = agent.WriteConfigFile(f.Path, cfg)
+				} else {
+					err = lm.SaveOrMergeConfig(cfg)
+				}
+				if err != nil {
+					return fmt.Errorf("writing agent %s: %w", cfg.ID, err)
+				}
+				imported = append(imported, cfg.ID)
+			}
+
+			msg := fmt.Sprintf("Imported %d agent(s) into %s", len(imported), dir)
+			if len(skipped) > 0 {
+				msg += fmt.Sprintf("; skipped existing: %s (use --overwrite to replace)", strings.Join(skipped, ", "))
+			}
+			Print(map[string]interface{}{"imported": imported, "skipped": skipped}, msg, cmd)
+			return nil
+		},
+	}
+	importCmd.Flags().BoolVar(&overwrite, "overwrite", false, "replace agents that already exist")
+	return importCmd
+}
+
+// encodeBundle writes configs as consecutive YAML documents.
+func encodeBundle(configs []agent.AgentConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	for i := range configs {
+		if err := enc.Encode(&configs[i]); err != nil {
+			return nil, fmt.Errorf("encoding agent %s: %w", configs[i].ID, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var docSeparator = regexp.MustCompile(`^---\s*$`)
+
+// bundleDoc is one YAML document in a bundle and the line it starts on.
+type bundleDoc struct {
+	text string
+	line int
+}
+
+// splitBundle splits a multi-document YAML stream, skipping empty documents.
+func splitBundle(data []byte) []bundleDoc {
+	var (
+		docs    []bundleDoc
+		current []string
+		start   = 1
+	)
+	flush := func() {
+		text := strings.Join(current, "\n")
+		if strings.TrimSpace(text) != "" {
+			docs = append(docs, bundleDoc{text: text + "\n", line: start})
+		}
+		current = nil
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		if docSeparator.MatchString(line) {
+			flush()
+			start = i + 2
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return docs
+}
+
+// checkBundle parses and validates every document in a bundle, reporting issues
+// with line numbers relative to the whole bundle.
+func checkBundle(cmd *cobra.Command, dir, name string, data []byte) ([]agent.AgentConfig, error) {
+	docs := splitBundle(data)
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%s contains no agent configs", name)
+	}
+
+	v, err := newValidator(dir)
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]agent.AgentConfig, 0, len(docs))
+	for _, d := range docs {
+		var cfg agent.AgentConfig
+		if err := yaml.Unmarshal([]byte(d.text), &cfg); err == nil && cfg.ID != "" {
+			v.Index[cfg.ID] = agent.ConfigFile{Path: name, Config: cfg}
+		}
+		configs = append(configs, cfg)
+	}
+
+	errCount := 0
+	seen := map[string]bool{}
+	for i, d := range docs {
+		for _, issue := range v.Validate(name, []byte(d.text)) {
+			if issue.Line > 0 {
+				issue.Line += d.line - 1
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), issue.String())
+			if issue.Severity == agent.SeverityError {
+				errCount++
+			}
+		}
+		if id := configs[i].ID; id != "" {
+			if seen[id] {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s:%d: error: duplicate agent id %q in bundle\n", name, d.line, id)
+				errCount++
+			}
+			seen[id] = true
+		}
+	}
+	if errCount > 0 {
+		return nil, fmt.Errorf("bundle %s has %d error(s); nothing imported", name, errCount)
+	}
+	return configs, nil
+}
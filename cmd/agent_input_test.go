@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"keystone/internal/agent"
+)
+
+func TestAgentRunReadsStdinAndFiles(t *testing.T) {
+	manager := agent.NewManager()
+	_ = manager.Register(agent.NewAgent("echo", "Echo", "", &agent.MockProvider{}, "m", "none"))
+
+	run := func(stdin string, args ...string) (string, error) {
+		return runCLI(t, cliOptions{manager: manager, stdin: stdin, stderr: io.Discard}, append([]string{"agent", "run", "echo"}, args...)...)
+	}
+
+	doc := "# Title\n\n  indented   line\n"
+	if out, err := run(doc); err != nil || out != "mock response: # Title\n\n  indented   line\n" {
+		t.Errorf("piped input should be kept verbatim, got %v\n%q", err, out)
+	}
+	if out, err := run("from stdin\n", "-"); err != nil || out != "mock response: from stdin\n" {
+		t.Errorf("'-' should read stdin, got %v\n%q", err, out)
+	}
+	if out, err := run("ignored", "hello", "world"); err != nil || out != "mock response: hello world\n" {
+		t.Errorf("args should take precedence over stdin, got %v\n%q", err, out)
+	}
+
+	dir := t.TempDir()
+	in, outPath := filepath.Join(dir, "doc.md"), filepath.Join(dir, "out.md")
+	if err := os.WriteFile(in, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := run("", "--input-file", in, "--output-file", outPath)
+	if err != nil || !strings.Contains(out, "Response written to "+outPath) {
+		t.Fatalf("file run failed: %v\n%s", err, out)
+	}
+	if data, _ := os.ReadFile(outPath); string(data) != "mock response: # Title\n\n  indented   line\n" {
+		t.Errorf("unexpected output file: %q", data)
+	}
+
+	if _, err := run("", "--input-file", in, "extra"); err == nil || !strings.Contains(err.Error(), "not both") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+	if _, err := run("\n\n"); err == nil || !strings.Contains(err.Error(), "input from stdin is empty") {
+		t.Errorf("expected empty input error, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"keystone/internal/agent"
+
+	"github.com/spf13/cobra"
+)
+
+// agentSummary is one row of "agent list".
+type agentSummary struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Tags         []string `json:"tags,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// newAgentListCmd creates "agent list", optionally filtered by tag and capability.
+func newAgentListCmd(managerProvider func() *agent.AgentManager) *cobra.Command {
+	var query agent.Query
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List available agents",
+		Long:  "List registered agents. Repeat --tag or --capability to require several; an agent must match all of them.",
+		Run: func(cmd *cobra.Command, args []string) {
+			agents := managerProvider().Find(query)
+			out := make([]agentSummary, 0, len(agents))
+			lines := make([]string, 0, len(agents)+1)
+			for _, a := range agents {
+				s := agentSummary{
+					ID:           a.ID(),
+					Name:         a.Name(),
+					Description:  a.Description(),
+					Tags:         agent.TagsOf(a),
+					Capabilities: agent.CapabilitiesOf(a),
+				}
+				out = append(out, s)
+				lines = append(lines, formatAgentSummary(s))
+			}
+			lines = append(lines, fmt.Sprintf("Available agents listed (%d)", len(out)))
+			printOrJSON(out, strings.Join(lines, "\n"), cmd)
+		},
+	}
+	listCmd.Flags().StringArrayVar(&query.Tags, "tag", nil, "only list agents with this tag (repeatable)")
+	listCmd.Flags().StringArrayVar(&query.Capabilities, "capability", nil, "only list agents with this capability (repeatable)")
+	return listCmd
+}
+
+func formatAgentSummary(s agentSummary) string {
+	line := fmt.Sprintf("%-20s %s", s.ID, s.Name)
+	if len(s.Capabilities) > 0 {
+		line += "  capabilities: " + strings.Join(s.Capabilities, ", ")
+	}
+	if len(s.Tags) > 0 {
+		line += "  tags: " + strings.Join(s.Tags, ", ")
+	}
+	return line
+}
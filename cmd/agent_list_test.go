@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"keystone/internal/agent"
+)
+
+func TestAgentListFilters(t *testing.T) {
+	manager := agent.NewManager()
+	_ = manager.Register(agent.NewAgent("summarizer", "Summarizer", "", &agent.MockProvider{}, "m", "none",
+		agent.WithTags("text"), agent.WithCapabilities("summarization")))
+	_ = manager.Register(agent.NewAgent("calendar", "Calendar", "", &agent.MockProvider{}, "m", "none",
+		agent.WithTags("office"), agent.WithCapabilities("scheduling")))
+
+	list := func(args ...string) []agentSummary {
+		raw, err := runCLI(t, cliOptions{manager: manager}, append([]string{"agent", "list", "--json"}, args...)...)
+		if err != nil {
+			t.Fatalf("agent list failed: %v", err)
+		}
+		var out []agentSummary
+		if err := json.Unmarshal([]byte(raw), &out); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, raw)
+		}
+		return out
+	}
+
+	if got := list(); len(got) != 2 || got[0].ID != "calendar" {
+		t.Errorf("expected both agents sorted by ID, got %+v", got)
+	}
+	got := list("--capability", "summarization")
+	if len(got) != 1 || got[0].ID != "summarizer" || got[0].Tags[0] != "text" {
+		t.Errorf("unexpected capability filter result: %+v", got)
+	}
+	if got := list("--tag", "office", "--capability", "summarization"); len(got) != 0 {
+		t.Errorf("filters should combine with AND, got %+v", got)
+	}
+}
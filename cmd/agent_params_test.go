@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"keystone/internal/agent"
+)
+
+func TestAgentRunTypedParameters(t *testing.T) {
+	manager := agent.NewManager()
+	schema := agent.ParamSchema{
+		"limit": {Type: agent.ParamInt, Default: "10", Max: func() *float64 { v := 50.0; return &v }()},
+		"tags":  {Type: agent.ParamList},
+	}
+	_ = manager.Register(agent.NewAgent("typed", "Typed", "", &agent.MockProvider{}, "m", "none",
+		agent.WithPromptTemplate("limit={{limit}} tags={{tags}}"), agent.WithParameterSchema(schema)))
+
+	run := func(params string) (string, error) {
+		return runCLI(t, cliOptions{manager: manager}, "agent", "run", "typed", "hi", "--json", "--parameters", params)
+	}
+
+	out, err := run(`{"limit": 7, "tags": ["a", "b"]}`)
+	if err != nil {
+		t.Fatalf("run failed: %v\n%s", err, out)
+	}
+	var res struct {
+		Parameters map[string]string `json:"parameters"`
+	}
+	if err := json.Unmarshal([]byte(out[strings.Index(out, "{"):]), &res); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if res.Parameters["limit"] != "7" || res.Parameters["tags"] != "a,b" {
+		t.Errorf("unexpected parameters: %v", res.Parameters)
+	}
+
+	if out, err := run(`{"limit": 500}`); err == nil || !strings.Contains(out, "above the maximum 50") {
+		t.Errorf("expected out-of-range override to fail, got %v\n%s", err, out)
+	}
+	if out, err := run(`{"limti": 5}`); err == nil || !strings.Contains(out, `unknown parameter "limti"`) {
+		t.Errorf("expected undeclared override to fail, got %v\n%s", err, out)
+	}
+}
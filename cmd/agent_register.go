@@ -9,7 +9,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func newAgentRegisterCmd(managerProvider func() *agent.AgentManager, configDir string) *cobra.Command {
+// newAgentRegisterCmd creates "agent register", which loads one agent config from the agents dir.
+// dirProvider returns the agents directory once flags and config are resolved.
+func newAgentRegisterCmd(managerProvider func() *agent.AgentManager, dirProvider func() string) *cobra.Command {
 	return &cobra.Command{
 		Use:   "register [agentID]",
 		Short: "Register a new agent",
@@ -33,13 +35,7 @@ func newAgentRegisterCmd(managerProvider func() *agent.AgentManager, configDir s
 			}
 
 			// Load agent from configuration
-			dir := configDir
-			if dir == "" {
-				dir = agent.DefaultAgentsDir
-			}
-
-			lm := agent.NewLifecycleManager(dir, nil)
-			if err := lm.LoadAgent(agentID); err != nil {
+			if err := agent.NewLoader(dirProvider(), nil).WithSecrets(agentSecrets).LoadAgent(manager, agentID); err != nil {
 				errMsg := fmt.Sprintf("Failed to load agent '%s': %v", agentID, err)
 				logger.Error(errMsg, jsonFlag)
 				PrintError("agent register", errMsg, cmd)
@@ -1,9 +1,54 @@
 package cmd
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"keystone/internal/agent"
+	"keystone/internal/config"
 )
 
 func TestAgentRegisterCLI(t *testing.T) {
-	t.Skip("Skipping agent register CLI test until LifecycleManager and config mocking are implemented")
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "team")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Registration finds agents by ID anywhere under the agents dir, like the full loader.
+	if err := os.WriteFile(filepath.Join(nested, "helper.yml"), []byte("id: helper\nname: Helper\nprovider: mock\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := agent.NewManager()
+	run := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		cfgLoader := func(_ string) (*config.Config, error) { return config.New(), nil }
+		cmd := NewRootCmd(func(string) *agent.AgentManager { return manager }, cfgLoader, buf)
+		cmd.SetArgs(append([]string{"--agents-dir", dir, "register"}, args...))
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+
+	out, err := run("helper")
+	if err != nil {
+		t.Fatalf("register failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Registered agent 'helper' successfully.") {
+		t.Errorf("unexpected output: %s", out)
+	}
+	if _, err := manager.Get("helper"); err != nil {
+		t.Errorf("expected helper in manager: %v", err)
+	}
+
+	out, _ = run("helper")
+	if !strings.Contains(out, "already exists") {
+		t.Errorf("expected already-exists message, got: %s", out)
+	}
+
+	if _, err := run("missing"); err == nil {
+		t.Error("expected error registering an unknown agent")
+	}
 }
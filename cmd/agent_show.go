@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"keystone/internal/agent"
+	"keystone/internal/prompt"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// newAgentShowCmd creates "agent show", which prints an agent's YAML config.
+// With --resolved, extends, prompt references, interpolation and defaults are applied
+// first; secrets are always masked.
+func newAgentShowCmd(dirProvider func() string) *cobra.Command {
+	var resolved bool
+
+	showCmd := &cobra.Command{
+		Use:   "show [agentID]",
+		Short: "Show an agent's configuration",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := dirProvider()
+			index, err := agent.IndexConfigDir(dir)
+			if err != nil {
+				return err
+			}
+			file, ok := index[args[0]]
+			if !ok {
+				PrintError("agent show", fmt.Sprintf("No config for agent '%s' in %s", args[0], dir), cmd)
+				return fmt.Errorf("agent %s not found", args[0])
+			}
+
+			cfg := file.Config
+			header := "# " + file.Path
+			if resolved {
+				chain, err := index.Chain(cfg)
+				if err != nil {
+					return err
+				}
+				vars := agent.Vars{Secrets: agentSecrets, MaskSecrets: true}
+				if cfg, err = resolveConfig(index, cfg, dir, &vars); err != nil {
+					return err
+				}
+				if len(chain) > 1 {
+					ids := make([]string, len(chain))
+					for i, c := range chain {
+						ids[i] = c.ID
+					}
+					header += "\n# resolved: " + strings.Join(ids, " <- ")
+				}
+			}
+
+			if docs := paramDocs(cfg.ParamSchema); docs != "" {
+				header += "\n" + docs
+			}
+
+			data, err := yaml.Marshal(&cfg)
+			if err != nil {
+				return fmt.Errorf("encoding config: %w", err)
+			}
+			Print(cfg, header+"\n"+strings.TrimRight(string(data), "\n"), cmd)
+			return nil
+		},
+	}
+	showCmd.Flags().BoolVar(&resolved, "resolved", false, "apply extends, prompt references, ${...} interpolation (secrets masked) and defaults")
+	return showCmd
+}
+
+// paramDocs describes a parameter schema as YAML comments, one line per parameter.
+func paramDocs(schema agent.ParamSchema) string {
+	if len(schema) == 0 {
+		return ""
+	}
+	lines := []string{"# parameters:"}
+	for _, name := range schema.Names() {
+		spec := schema[name]
+		attrs := []string{string(spec.Type)}
+		if spec.Type == "" {
+			attrs[0] = string(agent.ParamString)
+		}
+		if len(spec.Values) > 0 {
+			attrs[0] += " of " + strings.Join(spec.Values, "|")
+		}
+		if spec.Required {
+			attrs = append(attrs, "required")
+		}
+		if spec.Default != "" {
+			attrs = append(attrs, "default "+spec.Default)
+		}
+		if spec.Min != nil || spec.Max != nil {
+			attrs = append(attrs, "range "+bound(spec.Min)+".."+bound(spec.Max))
+		}
+		line := fmt.Sprintf("#   %s (%s)", name, strings.Join(attrs, ", "))
+		if spec.Description != "" {
+			line += ": " + spec.Description
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func bound(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// resolveConfig produces the config an agent is actually built from. References are
+// interpolated with vars when it is set and otherwise kept as written.
+func resolveConfig(index agent.ConfigIndex, cfg agent.AgentConfig, dir string, vars *agent.Vars) (agent.AgentConfig, error) {
+	cfg, err := index.Resolve(cfg)
+	if err != nil {
+		return cfg, err
+	}
+	if cfg.PromptRef != "" {
+		lib, err := prompt.LoadLibrary(agent.PromptsDir(dir))
+		if err != nil {
+			return cfg, err
+		}
+		if err := cfg.ResolvePrompt(lib); err != nil {
+			return cfg, err
+		}
+	}
+	if vars != nil {
+		_, missing := vars.Interpolate(&cfg)
+		if err := agent.MissingRefsError(missing); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"keystone/internal/config"
+)
+
+func TestAgentShowResolved(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml":  "id: base\nname: Base\nprovider: mock\nmodel: base-model\nparameters:\n  tone: calm\n",
+		"child.yaml": "id: child\nname: Child\nextends: base\nparameters:\n  lang: en\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(args ...string) string {
+		out, err := runCLI(t, cliOptions{}, append([]string{"--agents-dir", dir, "agent", "show"}, args...)...)
+		if err != nil {
+			t.Fatalf("agent show failed: %v\n%s", err, out)
+		}
+		return out
+	}
+
+	raw := run("child")
+	if strings.Contains(raw, "base-model") {
+		t.Errorf("raw config should not include inherited fields:\n%s", raw)
+	}
+
+	resolved := run("child", "--resolved")
+	for _, want := range []string{"resolved: child <- base", "provider: mock", "model: base-model", "tone: calm", "lang: en"} {
+		if !strings.Contains(resolved, want) {
+			t.Errorf("expected %q in resolved config:\n%s", want, resolved)
+		}
+	}
+}
+
+func TestAgentShowMasksSecrets(t *testing.T) {
+	t.Setenv("KEYSTONE_TEST_REGION", "eu-west")
+	dir := t.TempDir()
+	body := "id: svc\nname: Svc\nprovider: mock\nparameters:\n  token: ${secret:svc_token}\n  region: ${env:KEYSTONE_TEST_REGION}\n"
+	if err := os.WriteFile(filepath.Join(dir, "svc.yaml"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.New()
+	cfg.Secrets["svc_token"] = "tok-very-secret"
+	out, err := runCLI(t, cliOptions{config: cfg}, "--agents-dir", dir, "agent", "show", "svc", "--resolved")
+	if err != nil {
+		t.Fatalf("agent show failed: %v\n%s", err, out)
+	}
+	if strings.Contains(out, "tok-very-secret") || !strings.Contains(out, `token: '********'`) || !strings.Contains(out, "region: eu-west") {
+		t.Errorf("expected interpolated config with the secret masked:\n%s", out)
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"keystone/internal/agent"
+	"keystone/internal/agenttest"
+
+	"github.com/spf13/cobra"
+)
+
+// newAgentTestCmd creates "agent test", which runs YAML test suites against agents
+// and exits non-zero when any case fails so prompt changes can gate CI.
+func newAgentTestCmd(managerProvider func() *agent.AgentManager, dirProvider func() string) *cobra.Command {
+	var (
+		suiteDir  string
+		suiteFile string
+		live      bool
+		junitPath string
+	)
+
+	testCmd := &cobra.Command{
+		Use:   "test [id...]",
+		Short: "Run agent test suites (every suite in --dir when no IDs are given)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			paths, err := suitePaths(suiteDir, suiteFile, args)
+			if err != nil {
+				return err
+			}
+			runner := &agenttest.Runner{AgentsDir: dirProvider(), ForceLive: live, Secrets: agentSecrets}
+
+			var (
+				results        []agenttest.SuiteResult
+				lines          []string
+				passed, failed int
+			)
+			for _, path := range paths {
+				suite, err := agenttest.LoadSuite(path)
+				if err != nil {
+					return err
+				}
+				if (suite.Live || live) && runner.Live == nil {
+					runner.Live = managerProvider()
+				}
+				res := runner.Run(context.Background(), suite)
+				results = append(results, res)
+				lines = append(lines, suiteLines(res)...)
+				failed += res.Failed()
+				passed += len(res.Cases) - res.Failed()
+			}
+			lines = append(lines, fmt.Sprintf("Total: %d passed, %d failed", passed, failed))
+
+			if junitPath != "" {
+				if err := writeJUnitFile(junitPath, results); err != nil {
+					return err
+				}
+			}
+			Print(map[string]interface{}{
+				"passed": passed,
+				"failed": failed,
+				"suites": results,
+			}, strings.Join(lines, "\n"), cmd)
+
+			if failed > 0 {
+				cmd.SilenceErrors = true
+				return fmt.Errorf("%d agent test case(s) failed", failed)
+			}
+			return nil
+		},
+	}
+
+	f := testCmd.Flags()
+	f.StringVar(&suiteDir, "dir", agenttest.DefaultDir, "directory of suites named <agent_id>.yaml")
+	f.StringVarP(&suiteFile, "file", "f", "", "run this suite file instead of looking one up by ID")
+	f.BoolVar(&live, "live", false, "call the real providers instead of the mock for every suite")
+	f.StringVar(&junitPath, "junit", "", "also write a JUnit XML report to this path")
+	return testCmd
+}
+
+// suitePaths resolves which suite files to run.
+func suitePaths(dir, file string, ids []string) ([]string, error) {
+	if file != "" {
+		if len(ids) > 0 {
+			return nil, fmt.Errorf("--file cannot be combined with agent IDs")
+		}
+		return []string{file}, nil
+	}
+	if len(ids) == 0 {
+		paths, err := agenttest.SuiteFiles(dir)
+		if err == nil && len(paths) == 0 {
+			err = fmt.Errorf("no test suites found in %s", dir)
+		}
+		return paths, err
+	}
+	paths := make([]string, 0, len(ids))
+	for _, id := range ids {
+		path, err := agenttest.FindSuite(dir, id)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// suiteLines renders one suite's results as PASS/FAIL lines and a summary.
+func suiteLines(res agenttest.SuiteResult) []string {
+	var lines []string
+	for _, c := range res.Cases {
+		if c.Passed {
+			lines = append(lines, fmt.Sprintf("PASS %s/%s (%s)", res.Agent, c.Name, c.Duration.Round(time.Millisecond)))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("FAIL %s/%s (%s)", res.Agent, c.Name, c.Duration.Round(time.Millisecond)))
+		for _, f := range c.Failures {
+			lines = append(lines, "     "+f)
+		}
+	}
+	mode := "mock"
+	if res.Live {
+		mode = "live"
+	}
+	return append(lines, fmt.Sprintf("%s (%s): %d passed, %d failed", res.Agent, mode, len(res.Cases)-res.Failed(), res.Failed()))
+}
+
+func writeJUnitFile(path string, results []agenttest.SuiteResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("junit report: %w", err)
+	}
+	defer f.Close()
+	if err := agenttest.WriteJUnit(f, results); err != nil {
+		return fmt.Errorf("junit report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAgentTestRunsSuites(t *testing.T) {
+	dir := t.TempDir()
+	suites := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "echo.yaml"), []byte("id: echo\nname: Echo\nprovider: mock\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	suite := `cases:
+  - name: echoes
+    input: hi
+    expect:
+      equals: "mock response: hi"
+  - name: scripted
+    input: hi
+    mock_responses: ["nope"]
+    expect:
+      contains: [yes]
+`
+	if err := os.WriteFile(filepath.Join(suites, "echo.yaml"), []byte(suite), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	junit := filepath.Join(t.TempDir(), "report.xml")
+
+	out, err := runAgentCLI(t, dir, "", "test", "echo", "--dir", suites, "--junit", junit)
+	if err == nil || !strings.Contains(err.Error(), "1 agent test case(s) failed") {
+		t.Fatalf("expected failing run, got %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"PASS echo/echoes",
+		"FAIL echo/scripted",
+		`contains: "yes" not found`,
+		"echo (mock): 1 passed, 1 failed",
+		"Total: 1 passed, 1 failed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if data, err := os.ReadFile(junit); err != nil || !strings.Contains(string(data), `<testsuite name="agent.echo" tests="2" failures="1"`) {
+		t.Errorf("unexpected JUnit report (%v):\n%s", err, data)
+	}
+
+	if out, err := runAgentCLI(t, dir, "", "test", "missing", "--dir", suites); err == nil || !strings.Contains(err.Error(), "no test suite for agent missing") {
+		t.Errorf("expected missing suite error, got %v\n%s", err, out)
+	}
+}
@@ -116,3 +116,29 @@ func TestAgentCannotOverwriteOtherNamespace(t *testing.T) {
 		t.Errorf("agentB did not correctly write to its namespace, got '%s'", val)
 	}
 }
+
+func TestLoadOrCreateTicketReloadsSavedTicket(t *testing.T) {
+	orig := tickets.TicketDir
+	tickets.TicketDir = t.TempDir()
+	defer func() { tickets.TicketDir = orig }()
+
+	tkt, store, err := loadOrCreateTicket("t4")
+	if err != nil {
+		t.Fatalf("unexpected error creating ticket: %v", err)
+	}
+	if tkt.ID != "t4" || tkt.UserID != "default" {
+		t.Fatalf("expected ticket t4 for user default, got %s for %s", tkt.ID, tkt.UserID)
+	}
+	tkt.Step = 3
+	if err := store.Save(tkt); err != nil {
+		t.Fatalf("unexpected error saving ticket: %v", err)
+	}
+
+	again, _, err := loadOrCreateTicket("t4")
+	if err != nil {
+		t.Fatalf("unexpected error loading ticket: %v", err)
+	}
+	if again.Step != 3 {
+		t.Errorf("expected the saved ticket to be reloaded, got step %d", again.Step)
+	}
+}
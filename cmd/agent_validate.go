@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"keystone/internal/agent"
+
+	"github.com/spf13/cobra"
+)
+
+// newAgentValidateCmd creates "agent validate", which checks agent configs and
+// exits non-zero when any error is found so it can gate CI.
+func newAgentValidateCmd(dirProvider func() string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [path...]",
+		Short: "Validate agent config files (defaults to the agents dir)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := dirProvider()
+			paths := args
+			if len(paths) == 0 {
+				paths = []string{dir}
+			}
+
+			v, err := newValidator(dir)
+			if err != nil {
+				return err
+			}
+			issues, files, err := v.ValidatePaths(paths)
+			if err != nil {
+				return err
+			}
+
+			errCount, warnCount := 0, 0
+			lines := make([]string, 0, len(issues)+1)
+			for _, i := range issues {
+				if i.Severity == agent.SeverityError {
+					errCount++
+				} else {
+					warnCount++
+				}
+				lines = append(lines, i.String())
+			}
+			lines = append(lines, fmt.Sprintf("Validated %d file(s): %d error(s), %d warning(s)", files, errCount, warnCount))
+
+			if issues == nil {
+				issues = []agent.Issue{}
+			}
+			Print(map[string]interface{}{
+				"files":    files,
+				"errors":   errCount,
+				"warnings": warnCount,
+				"issues":   issues,
+			}, strings.Join(lines, "\n"), cmd)
+
+			if errCount > 0 {
+				cmd.SilenceUsage = true
+				cmd.SilenceErrors = true
+				return fmt.Errorf("agent validation failed with %d error(s)", errCount)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runValidateCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	return runCLI(t, cliOptions{}, append([]string{"agent", "validate"}, args...)...)
+}
+
+func TestAgentValidate(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yaml")
+	bad := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(good, []byte("id: good\nname: Good\nprovider: mock\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte("id: bad\nname: Bad\nprovider: nope\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runValidateCLI(t, good)
+	if err != nil {
+		t.Fatalf("expected valid config to pass, got %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Validated 1 file(s): 0 error(s), 0 warning(s)") {
+		t.Errorf("unexpected summary:\n%s", out)
+	}
+
+	out, err = runValidateCLI(t, "--agents-dir", dir)
+	if err == nil {
+		t.Fatalf("expected validation failure for %s\n%s", bad, out)
+	}
+	if !strings.Contains(out, bad+`:3: error: unknown provider "nope"`) {
+		t.Errorf("expected file:line error, got:\n%s", out)
+	}
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"keystone/internal/agent"
+
+	"github.com/spf13/cobra"
+)
+
+// newAgentWatchCmd creates "agent watch", which keeps the agent manager in sync
+// with the agents dir and prints each reload event until interrupted.
+func newAgentWatchCmd(managerProvider func() *agent.AgentManager, dirProvider func() string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch",
+		Short: "Watch agent definitions and hot-reload them on change",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			dir := dirProvider()
+			w := agent.NewWatcher(agent.NewLoader(dir, nil).WithSecrets(agentSecrets), managerProvider())
+			w.OnEvent = func(e agent.ReloadEvent) {
+				out := map[string]string{"action": e.Action, "id": e.ID, "path": e.Path}
+				if e.Err != nil {
+					out["error"] = e.Err.Error()
+				}
+				Print(out, e.String(), cmd)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Watching %s for agent changes (Ctrl+C to stop)\n", dir)
+			return w.Run(ctx)
+		},
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"keystone/internal/agent"
 	"keystone/internal/config"
 
 	"github.com/spf13/cobra"
@@ -26,14 +27,19 @@ func newConfigCmd(loadFn func(string) (*config.Config, error)) *cobra.Command {
 				return
 			}
 
-			// TODO: mask secrets if showSecrets is false
-			conf := cfg
+			conf := *cfg
+			if !showSecrets && len(cfg.Secrets) > 0 {
+				conf.Secrets = make(map[string]string, len(cfg.Secrets))
+				for name := range cfg.Secrets {
+					conf.Secrets[name] = agent.SecretMask
+				}
+			}
 
 			if getJSONFlag(cmd) {
-				Print(conf, "", cmd)
+				Print(&conf, "", cmd)
 			} else {
 				Print(nil, "Current Keystone configuration", cmd)
-				Print(conf, "", cmd)
+				Print(&conf, "", cmd)
 			}
 		},
 	}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"keystone/internal/config"
@@ -40,3 +41,27 @@ func TestConfigCommand(t *testing.T) {
 		t.Errorf("expected config output with secrets, got empty string")
 	}
 }
+
+func TestConfigCommandMasksSecrets(t *testing.T) {
+	loader := func(string) (*config.Config, error) {
+		return &config.Config{Secrets: map[string]string{"api_key": "sk-live-123"}}, nil
+	}
+	run := func(args ...string) string {
+		buf := new(bytes.Buffer)
+		cmd := newConfigCmd(loader)
+		cmd.PersistentFlags().Bool("json", false, "")
+		cmd.SetOut(buf)
+		cmd.SetArgs(append([]string{"--json"}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("failed to execute command: %v", err)
+		}
+		return buf.String()
+	}
+
+	if out := run(); strings.Contains(out, "sk-live-123") || !strings.Contains(out, `"api_key": "********"`) {
+		t.Errorf("secrets should be masked:\n%s", out)
+	}
+	if out := run("--show-secrets"); !strings.Contains(out, "sk-live-123") {
+		t.Errorf("--show-secrets should reveal secrets:\n%s", out)
+	}
+}
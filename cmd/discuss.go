@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"keystone/internal/agent"
+	"keystone/internal/discuss"
+	"keystone/internal/tickets"
+
+	"github.com/spf13/cobra"
+)
+
+// newDiscussCmd creates "discuss", which has several agents take turns on a topic
+// and ends with a synthesis of the discussion.
+func newDiscussCmd(managerProvider func() *agent.AgentManager, store *tickets.Store) *cobra.Command {
+	var (
+		agentIDs    []string
+		policy      string
+		moderator   string
+		synthesizer string
+		keyword     string
+		maxRounds   int
+		maxHops     int
+	)
+
+	discussCmd := &cobra.Command{
+		Use:   "discuss [topic]",
+		Short: "Have several agents discuss a topic",
+		Long: `Have several agents take turns on a shared transcript stored in a ticket, then
+synthesize the discussion.
+
+Policies:
+  round-robin   every agent speaks once per round until --max-rounds
+  moderator     the --moderator agent picks each next speaker or answers DONE
+  consensus     round-robin until every agent's latest turn says --keyword
+                (as a whole word, not negated)
+
+Every agent call is a ticket hop. The ticket gets enough hops for --max-rounds
+unless --max-hops is given; the discussion stops early when the hops run out,
+always keeping one for the synthesis.`,
+		Example: `  keystone discuss --agents a,b,c "Should we adopt tabs?"`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d := &discuss.Discussion{
+				Manager:     managerProvider(),
+				Agents:      agentIDs,
+				Policy:      discuss.Policy(policy),
+				Moderator:   moderator,
+				Synthesizer: synthesizer,
+				Keyword:     keyword,
+				MaxRounds:   maxRounds,
+			}
+			if err := d.Validate(); err != nil {
+				PrintError("discuss", err.Error(), cmd)
+				return err
+			}
+
+			ticket := tickets.NewTicket(tickets.NewID("cli", "discuss"), "default", nil)
+			ticket.MaxHops = d.Hops()
+			if maxHops > 0 {
+				ticket.MaxHops = maxHops
+			}
+
+			jsonOut := getJSONFlag(cmd)
+			out := cmd.OutOrStdout()
+			d.OnTurn = func(turn discuss.Turn) {
+				if !jsonOut {
+					fmt.Fprintf(out, "[round %d] %s: %s\n\n", turn.Round, turn.Agent, strings.TrimSpace(turn.Text))
+				}
+				_ = store.Save(ticket)
+			}
+
+			tr, err := d.Run(cmd.Context(), args[0], ticket)
+			if saveErr := store.Save(ticket); saveErr != nil && err == nil {
+				err = fmt.Errorf("saving ticket: %w", saveErr)
+			}
+			if err != nil {
+				PrintError("discuss", err.Error(), cmd)
+				return err
+			}
+
+			msg := fmt.Sprintf("Stopped: %s (%d turns, ticket %s)\n\nSynthesis:\n%s",
+				tr.Stopped, len(tr.Turns), ticket.ID, strings.TrimSpace(tr.Synthesis))
+			Print(map[string]interface{}{"ticket": ticket.ID, "transcript": tr}, msg, cmd)
+			return nil
+		},
+	}
+
+	discussCmd.Flags().StringSliceVar(&agentIDs, "agents", nil, "IDs of the participating agents (repeatable or comma-separated)")
+	discussCmd.Flags().StringVar(&policy, "policy", string(discuss.RoundRobin), "Turn policy: round-robin, moderator or consensus")
+	discussCmd.Flags().StringVar(&moderator, "moderator", "", "Agent that picks the next speaker (moderator policy)")
+	discussCmd.Flags().StringVar(&synthesizer, "synthesizer", "", "Agent that writes the synthesis (default: the moderator, else the first agent)")
+	discussCmd.Flags().StringVar(&keyword, "keyword", discuss.DefaultKeyword, "Word that signals agreement (consensus policy)")
+	discussCmd.Flags().IntVar(&maxRounds, "max-rounds", discuss.DefaultMaxRounds, "Maximum number of rounds")
+	discussCmd.Flags().IntVar(&maxHops, "max-hops", 0, "Ticket hop limit (default: enough for --max-rounds)")
+	return discussCmd
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"keystone/internal/agent"
+	"keystone/internal/discuss"
+	"keystone/internal/tickets"
+)
+
+// runDiscussCLI runs "discuss" against two mock agents and returns its output.
+func runDiscussCLI(t *testing.T, store *tickets.Store, args ...string) (string, error) {
+	t.Helper()
+	manager := agent.NewManager()
+	for _, id := range []string{"alice", "bob"} {
+		_ = manager.Register(agent.NewAgent(id, id, "", &agent.MockProvider{}, "m", "none"))
+	}
+	return runCLI(t, cliOptions{manager: manager, store: store}, append([]string{"discuss"}, args...)...)
+}
+
+func TestDiscussRoundRobin(t *testing.T) {
+	store := tickets.NewStore(t.TempDir())
+	out, err := runDiscussCLI(t, store, "--agents", "alice,bob", "--max-rounds", "2", "tabs or spaces")
+	if err != nil {
+		t.Fatalf("discuss failed: %v\n%s", err, out)
+	}
+	if strings.Count(out, "[round ") != 4 || !strings.Contains(out, "[round 2] bob: mock response:") {
+		t.Errorf("expected four turns over two rounds:\n%s", out)
+	}
+	if !strings.Contains(out, "Stopped: max rounds reached") || !strings.Contains(out, "Synthesis:\nmock response: Synthesize") {
+		t.Errorf("expected a synthesis:\n%s", out)
+	}
+
+	saved, err := store.List("default")
+	if err != nil || len(saved) != 1 {
+		t.Fatalf("expected one saved ticket, got %d, %v", len(saved), err)
+	}
+	if s, ok := saved[0].GetNamespaced(discuss.TicketNamespace, "synthesis"); !ok || s == "" {
+		t.Error("synthesis not stored on the ticket")
+	}
+	if saved[0].Hops != 5 {
+		t.Errorf("hops = %d, want 5", saved[0].Hops)
+	}
+}
+
+func TestDiscussHopLimitAndErrors(t *testing.T) {
+	store := tickets.NewStore(t.TempDir())
+	out, err := runDiscussCLI(t, store, "--agents", "alice,bob", "--max-hops", "2", "topic")
+	if err != nil || !strings.Contains(out, "Stopped: ticket hop limit reached (1 turns") {
+		t.Errorf("expected the hop limit to stop the discussion, got %v\n%s", err, out)
+	}
+
+	if _, err := runDiscussCLI(t, store, "--agents", "alice", "topic"); err == nil || !strings.Contains(err.Error(), "at least two agents") {
+		t.Errorf("expected a participant error, got %v", err)
+	}
+	if _, err := runDiscussCLI(t, store, "--agents", "alice,bob", "--policy", "moderator", "topic"); err == nil || !strings.Contains(err.Error(), "needs a moderator") {
+		t.Errorf("expected a moderator error, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"keystone/internal/agent"
+	"keystone/internal/eval"
+	"keystone/internal/logger"
+	"keystone/internal/providers"
+	"keystone/internal/usage"
+
+	"github.com/spf13/cobra"
+)
+
+// newEvalCmd creates the "eval" command for comparing agent variants on a dataset.
+// Agents are loaded from the agents dir on metered providers so each run's tokens
+// and cost can be reported.
+func newEvalCmd(dirProvider func() string) *cobra.Command {
+	evalCmd := &cobra.Command{
+		Use:   "eval",
+		Short: "Evaluate and compare agents on a dataset",
+	}
+	evalCmd.AddCommand(newEvalRunCmd(dirProvider))
+	return evalCmd
+}
+
+func newEvalRunCmd(dirProvider func() string) *cobra.Command {
+	var (
+		agentIDs   []string
+		scorers    []string
+		judgeID    string
+		rubric     string
+		rubricFile string
+		prices     []string
+		outPath    string
+	)
+
+	runCmd := &cobra.Command{
+		Use:   "run <dataset.jsonl>",
+		Short: "Run every example through each agent and score the outputs",
+		Long: `Run every example in a JSONL dataset ({"id", "input", "expected", "params"} per line)
+through each --agent, score the outputs and print a per-agent comparison.
+
+Scorers: exact (matches expected), similarity (term overlap with expected) and
+judge (a --judge agent grades the output 0-10 against --rubric). Token use is
+estimated per run; --price name=usd_per_1k_tokens prices it by model or provider.
+--out writes the full report, as Markdown when the path ends in .md, else JSON.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(agentIDs) == 0 {
+				return fmt.Errorf("at least one --agent is required")
+			}
+			cmd.SilenceUsage = true
+
+			examples, err := eval.LoadDataset(args[0])
+			if err != nil {
+				return err
+			}
+			pricing, err := usage.ParsePricing(prices)
+			if err != nil {
+				return err
+			}
+			if rubricFile != "" {
+				data, err := os.ReadFile(rubricFile)
+				if err != nil {
+					return fmt.Errorf("rubric: %w", err)
+				}
+				rubric = string(data)
+			}
+
+			tracker := usage.NewTracker()
+			manager := meteredManager(dirProvider(), tracker)
+			selected, err := evalScorers(scorers, judgeID, rubric, manager)
+			if err != nil {
+				return err
+			}
+
+			runner := &eval.Runner{Manager: manager, Tracker: tracker, Pricing: pricing, Scorers: selected}
+			report, err := runner.Run(context.Background(), filepath.Base(args[0]), examples, agentIDs)
+			if err != nil {
+				return err
+			}
+
+			msg := fmt.Sprintf("Evaluated %d example(s) across %d agent(s)\n%s", len(examples), len(agentIDs), report.Table())
+			if outPath != "" {
+				if err := writeEvalReport(outPath, report); err != nil {
+					return err
+				}
+				msg += "\nReport written to " + outPath
+			}
+			Print(report, msg, cmd)
+			return nil
+		},
+	}
+
+	f := runCmd.Flags()
+	f.StringArrayVar(&agentIDs, "agent", nil, "agent to evaluate (repeat to compare variants)")
+	f.StringSliceVar(&scorers, "scorer", nil, "scorers to apply: exact, similarity, judge (default exact,similarity, plus judge with --judge)")
+	f.StringVar(&judgeID, "judge", "", "agent that grades outputs for the judge scorer")
+	f.StringVar(&rubric, "rubric", "", "grading rubric for the judge")
+	f.StringVar(&rubricFile, "rubric-file", "", "read the judge rubric from a file")
+	f.StringArrayVar(&prices, "price", nil, "price as model_or_provider=usd_per_1k_tokens (repeatable)")
+	f.StringVarP(&outPath, "out", "o", "", "write the full report to this path (.md for Markdown, otherwise JSON)")
+	return runCmd
+}
+
+// meteredManager loads the agents in dir with every provider recording usage in tracker.
+func meteredManager(dir string, tracker *usage.Tracker) *agent.AgentManager {
+	metered := map[string]providers.Provider{}
+	for name, p := range agent.DefaultProviders() {
+		metered[name] = usage.Meter(p, name, tracker)
+	}
+	manager := agent.NewManager()
+	report, err := agent.NewLoader(dir, metered).WithSecrets(agentSecrets).Load(manager)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to load agents from %s: %v", dir, err), false)
+	} else if len(report.Errors) > 0 {
+		logger.Warn(report.Summary(), false)
+	}
+	return manager
+}
+
+// evalScorers builds the named scorers, defaulting to exact and similarity, plus
+// the judge when a judge agent is given.
+func evalScorers(names []string, judgeID, rubric string, manager *agent.AgentManager) ([]eval.Scorer, error) {
+	if len(names) == 0 {
+		names = []string{eval.ScorerExact, eval.ScorerSimilarity}
+		if judgeID != "" {
+			names = append(names, eval.ScorerJudge)
+		}
+	}
+	var out []eval.Scorer
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case eval.ScorerExact:
+			out = append(out, eval.ExactMatch{})
+		case eval.ScorerSimilarity:
+			out = append(out, eval.Similarity{})
+		case eval.ScorerJudge:
+			if judgeID == "" {
+				return nil, fmt.Errorf("the judge scorer needs --judge <agent_id>")
+			}
+			judge, err := manager.Get(judgeID)
+			if err != nil {
+				return nil, fmt.Errorf("judge: %w", err)
+			}
+			out = append(out, eval.Judge{Agent: judge, Rubric: rubric})
+		default:
+			return nil, fmt.Errorf("unknown scorer %q (want %s, %s or %s)", name, eval.ScorerExact, eval.ScorerSimilarity, eval.ScorerJudge)
+		}
+	}
+	return out, nil
+}
+
+func writeEvalReport(path string, report *eval.Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("eval report: %w", err)
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".md") {
+		err = report.WriteMarkdown(f)
+	} else {
+		err = report.WriteJSON(f)
+	}
+	if err != nil {
+		return fmt.Errorf("eval report: %w", err)
+	}
+	return nil
+}
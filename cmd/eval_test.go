@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runEvalCLI(t *testing.T, dir string, args ...string) (string, error) {
+	t.Helper()
+	return runCLI(t, cliOptions{}, append([]string{"--agents-dir", dir, "eval", "run"}, args...)...)
+}
+
+func TestEvalRunComparesAgents(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"plain.yaml": "id: plain\nname: Plain\nprovider: mock\n",
+		"loud.yaml":  "id: loud\nname: Loud\nprovider: mock\nmodel: big\nprompt_template: \"{{input | upper}}\"\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dataset := filepath.Join(t.TempDir(), "d.jsonl")
+	if err := os.WriteFile(dataset, []byte(`{"id": "greet", "input": "hi", "expected": "mock response: hi"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(t.TempDir(), "report.md")
+
+	out, err := runEvalCLI(t, dir, dataset, "--agent", "plain", "--agent", "loud", "--scorer", "exact", "--price", "big=1", "-o", report)
+	if err != nil {
+		t.Fatalf("eval run failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"Evaluated 1 example(s) across 2 agent(s)", "plain  1.00", "loud   1.00", "$0.0000", "Report written to"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if data, err := os.ReadFile(report); err != nil || !strings.Contains(string(data), "| greet | loud | 1.00 |") {
+		t.Errorf("unexpected report (%v):\n%s", err, data)
+	}
+
+	if out, err := runEvalCLI(t, dir, dataset, "--agent", "plain", "--scorer", "judge"); err == nil || !strings.Contains(err.Error(), "needs --judge") {
+		t.Errorf("expected judge error, got %v\n%s", err, out)
+	}
+	if out, err := runEvalCLI(t, dir, dataset); err == nil || !strings.Contains(err.Error(), "at least one --agent") {
+		t.Errorf("expected missing agent error, got %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ask prints label and reads a line from in, returning def when the answer is empty.
+func ask(in *bufio.Reader, out io.Writer, label, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(out, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(out, "%s: ", label)
+	}
+	line, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// confirm asks a yes/no question; def is returned for an empty answer or EOF.
+func confirm(in *bufio.Reader, out io.Writer, question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := ask(in, out, fmt.Sprintf("%s (%s)", question, hint), "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// readAgentInput resolves an agent's input from args, inputFile or stdin. "-" as the
+// file or sole argument reads stdin, and stdin is also read when it is piped and no
+// input args are given. Input from a file or stdin is kept verbatim apart from the
+// trailing newline.
+func readAgentInput(cmd *cobra.Command, args []string, inputFile string) (string, error) {
+	if inputFile != "" && len(args) > 0 {
+		return "", fmt.Errorf("pass the input as arguments or with --input-file, not both")
+	}
+	switch {
+	case inputFile == "-" || (inputFile == "" && len(args) == 1 && args[0] == "-"):
+		return readInputFrom(cmd.InOrStdin(), "stdin")
+	case inputFile != "":
+		f, err := os.Open(inputFile)
+		if err != nil {
+			return "", fmt.Errorf("reading input: %w", err)
+		}
+		defer f.Close()
+		return readInputFrom(f, inputFile)
+	case len(args) > 0:
+		return strings.Join(args, " "), nil
+	case stdinPiped(cmd.InOrStdin()):
+		return readInputFrom(cmd.InOrStdin(), "stdin")
+	default:
+		return "", fmt.Errorf("no input: pass it as arguments, with --input-file, or on stdin")
+	}
+}
+
+func readInputFrom(r io.Reader, name string) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("reading input from %s: %w", name, err)
+	}
+	input := strings.TrimRight(string(data), "\r\n")
+	if strings.TrimSpace(input) == "" {
+		return "", fmt.Errorf("input from %s is empty", name)
+	}
+	return input, nil
+}
+
+// stdinPiped reports whether in is a pipe or file rather than a terminal. Readers
+// that are not files, as set by tests, count as piped.
+func stdinPiped(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	if !ok {
+		return true
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// writeAgentOutput writes a response to path, ending it with a newline.
+func writeAgentOutput(path, response string) error {
+	if !strings.HasSuffix(response, "\n") {
+		response += "\n"
+	}
+	if err := os.WriteFile(path, []byte(response), 0o644); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+
+	"keystone/internal/agent"
+	"keystone/internal/pack"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// newPackCmd creates the "pack" command group, which installs shared bundles of
+// agents, prompts and workflows alongside the agents dir.
+func newPackCmd(dirProvider func() string) *cobra.Command {
+	packCmd := &cobra.Command{
+		Use:   "pack",
+		Short: "Install, upgrade and remove agent packs",
+		Long: "A pack is a directory or git repository with a " + pack.ManifestFile + " manifest naming its version,\n" +
+			"agents, prompts, workflows and required providers. Installed agents, prompts and workflows\n" +
+			"get IDs prefixed with the pack's namespace (\"<namespace>.<id>\") so packs cannot collide.",
+	}
+	packCmd.AddCommand(
+		newPackInstallCmd(dirProvider),
+		newPackUpgradeCmd(dirProvider),
+		newPackListCmd(dirProvider),
+		newPackRemoveCmd(dirProvider),
+	)
+	return packCmd
+}
+
+// packChange is one file in an install or upgrade, as reported to the user.
+type packChange struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Diff string `json:"diff,omitempty"`
+}
+
+func newPackInstallCmd(dirProvider func() string) *cobra.Command {
+	var namespace, ref string
+
+	installCmd := &cobra.Command{
+		Use:   "install [path|git-url]",
+		Short: "Install a pack from a local directory or git repository",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			dir := dirProvider()
+			src, cleanup, err := pack.Fetch(cmd.Context(), args[0], ref)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			m, err := pack.ReadManifest(src)
+			if err != nil {
+				return err
+			}
+			inst := pack.NewInstaller(dir)
+			if prev, err := inst.Get(m.Name); err != nil {
+				return err
+			} else if prev != nil {
+				return fmt.Errorf("pack %s %s is already installed (use 'keystone pack upgrade')", prev.Name, prev.Version)
+			}
+
+			p, plan, err := planPack(cmd, dir, src, namespace)
+			if err != nil {
+				return err
+			}
+			rec, err := inst.Apply(plan, packSource(args[0], ref))
+			if err != nil {
+				return err
+			}
+
+			lines := []string{fmt.Sprintf("Installed pack %s %s into namespace %s (%s)", rec.Name, rec.Version, rec.Namespace, packContents(p))}
+			for _, c := range plan.Changes {
+				lines = append(lines, "  + "+c.Path)
+			}
+			Print(rec, strings.Join(lines, "\n"), cmd)
+			return nil
+		},
+	}
+	installCmd.Flags().StringVar(&namespace, "namespace", "", "namespace for the pack's IDs (defaults to the manifest's)")
+	installCmd.Flags().StringVar(&ref, "ref", "", "branch or tag to clone from a git URL")
+	return installCmd
+}
+
+func newPackUpgradeCmd(dirProvider func() string) *cobra.Command {
+	var (
+		namespace, ref     string
+		dryRun, yes, force bool
+	)
+
+	upgradeCmd := &cobra.Command{
+		Use:   "upgrade [path|git-url]",
+		Short: "Upgrade an installed pack, previewing the changes as a diff",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			dir := dirProvider()
+			src, cleanup, err := pack.Fetch(cmd.Context(), args[0], ref)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			m, err := pack.ReadManifest(src)
+			if err != nil {
+				return err
+			}
+			inst := pack.NewInstaller(dir)
+			prev, err := inst.Get(m.Name)
+			if err != nil {
+				return err
+			}
+			if prev == nil {
+				return fmt.Errorf("pack %s is not installed (use 'keystone pack install')", m.Name)
+			}
+			if namespace == "" {
+				namespace = prev.Namespace
+			}
+
+			_, plan, err := planPack(cmd, dir, src, namespace)
+			if err != nil {
+				return err
+			}
+			if !plan.Changed() {
+				Print(map[string]string{"status": "unchanged", "pack": m.Name, "version": prev.Version},
+					fmt.Sprintf("Pack %s %s is up to date", m.Name, prev.Version), cmd)
+				return nil
+			}
+			cmp, err := pack.CompareVersions(m.Version, prev.Version)
+			if err != nil {
+				return err
+			}
+			if cmp <= 0 && !force {
+				return fmt.Errorf("pack %s %s is not newer than the installed %s (use --force to replace it anyway)", m.Name, m.Version, prev.Version)
+			}
+
+			changes := make([]packChange, 0, len(plan.Changes))
+			lines := []string{fmt.Sprintf("Upgrade pack %s %s -> %s:", m.Name, prev.Version, m.Version)}
+			var diffs []string
+			for _, c := range plan.Changes {
+				if c.Kind == pack.Unchanged {
+					continue
+				}
+				d := c.Diff()
+				changes = append(changes, packChange{Path: c.Path, Kind: string(c.Kind), Diff: d})
+				lines = append(lines, fmt.Sprintf("  %s %s", changeMark(c.Kind), c.Path))
+				diffs = append(diffs, strings.TrimRight(d, "\n"))
+			}
+			preview := strings.Join(lines, "\n") + "\n\n" + strings.Join(diffs, "\n")
+			if dryRun {
+				Print(changes, preview, cmd)
+				return nil
+			}
+			if !yes {
+				if !getJSONFlag(cmd) {
+					fmt.Fprintln(cmd.OutOrStdout(), preview)
+				}
+				ok, err := confirm(bufio.NewReader(cmd.InOrStdin()), cmd.OutOrStdout(), fmt.Sprintf("Apply upgrade of pack %s?", m.Name), false)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					Print(map[string]string{"status": "aborted", "pack": m.Name}, "Aborted.", cmd)
+					return nil
+				}
+			}
+
+			rec, err := inst.Apply(plan, packSource(args[0], ref))
+			if err != nil {
+				return err
+			}
+			Print(rec, fmt.Sprintf("Upgraded pack %s %s -> %s (%d file(s) changed)", rec.Name, prev.Version, rec.Version, len(changes)), cmd)
+			return nil
+		},
+	}
+	upgradeCmd.Flags().StringVar(&namespace, "namespace", "", "namespace for the pack's IDs (defaults to the installed one)")
+	upgradeCmd.Flags().StringVar(&ref, "ref", "", "branch or tag to clone from a git URL")
+	upgradeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "show the changes without applying them")
+	upgradeCmd.Flags().BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation")
+	upgradeCmd.Flags().BoolVar(&force, "force", false, "upgrade even if the version is not newer")
+	return upgradeCmd
+}
+
+func newPackListCmd(dirProvider func() string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List installed packs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			records, err := pack.NewInstaller(dirProvider()).List()
+			if err != nil {
+				return err
+			}
+			if records == nil {
+				records = []pack.Record{}
+			}
+			lines := make([]string, 0, len(records)+1)
+			for _, r := range records {
+				lines = append(lines, fmt.Sprintf("%-20s %-10s namespace: %s  agents: %d  source: %s", r.Name, r.Version, r.Namespace, len(r.Agents), r.Source))
+			}
+			lines = append(lines, fmt.Sprintf("Installed packs listed (%d)", len(records)))
+			Print(records, strings.Join(lines, "\n"), cmd)
+			return nil
+		},
+	}
+}
+
+func newPackRemoveCmd(dirProvider func() string) *cobra.Command {
+	var yes, force bool
+
+	removeCmd := &cobra.Command{
+		Use:   "remove [name]",
+		Short: "Remove an installed pack and everything it installed",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			dir := dirProvider()
+			name := args[0]
+			inst := pack.NewInstaller(dir)
+			rec, err := inst.Get(name)
+			if err != nil {
+				return err
+			}
+			if rec == nil {
+				return fmt.Errorf("pack %s is not installed", name)
+			}
+
+			if users := packDependents(dir, rec); len(users) > 0 && !force {
+				return fmt.Errorf("pack %s is used by %s (use --force to remove it anyway)", name, strings.Join(users, ", "))
+			}
+			if !yes {
+				question := fmt.Sprintf("Remove pack %s %s and its %d file(s)?", rec.Name, rec.Version, len(rec.Files))
+				ok, err := confirm(bufio.NewReader(cmd.InOrStdin()), cmd.OutOrStdout(), question, false)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					Print(map[string]string{"status": "aborted", "pack": name}, "Aborted.", cmd)
+					return nil
+				}
+			}
+
+			if _, err := inst.Remove(name); err != nil {
+				return err
+			}
+			Print(map[string]interface{}{"status": "removed", "pack": name, "files": rec.Files},
+				fmt.Sprintf("Removed pack %s %s (%d file(s))", rec.Name, rec.Version, len(rec.Files)), cmd)
+			return nil
+		},
+	}
+	removeCmd.Flags().BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation")
+	removeCmd.Flags().BoolVar(&force, "force", false, "remove even if agents outside the pack depend on it")
+	return removeCmd
+}
+
+// planPack opens the pack in src, checks it can run here and works out its install plan.
+func planPack(cmd *cobra.Command, dir, src, namespace string) (*pack.Pack, *pack.Plan, error) {
+	p, err := pack.Open(src, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkPackProviders(p); err != nil {
+		return nil, nil, err
+	}
+	if err := checkPack(cmd, dir, p); err != nil {
+		return nil, nil, err
+	}
+	plan, err := pack.NewInstaller(dir).Plan(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, plan, nil
+}
+
+// checkPackProviders ensures every provider the pack requires is available.
+func checkPackProviders(p *pack.Pack) error {
+	available := agent.DefaultProviders()
+	for _, name := range p.Manifest.Providers {
+		if _, ok := available[name]; ok {
+			continue
+		}
+		names := make([]string, 0, len(available))
+		for n := range available {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("pack %s requires provider %q, which is not available (known: %s)", p.Manifest.Name, name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// checkPack validates the pack's agents as they will be installed, alongside the
+// agents and prompts already present, printing every issue.
+func checkPack(cmd *cobra.Command, dir string, p *pack.Pack) error {
+	v, err := newValidator(dir)
+	if err != nil {
+		return err
+	}
+	for _, t := range p.Prompts {
+		v.Prompts.Add(t)
+	}
+	for _, cfg := range p.Agents {
+		v.Index[cfg.ID] = agent.ConfigFile{Path: p.AgentPath(dir, cfg.ID), Config: cfg}
+	}
+
+	errCount := 0
+	for i := range p.Agents {
+		data, err := yaml.Marshal(&p.Agents[i])
+		if err != nil {
+			return err
+		}
+		for _, issue := range v.Validate(p.AgentPath(dir, p.Agents[i].ID), data) {
+			fmt.Fprintln(cmd.ErrOrStderr(), issue.String())
+			if issue.Severity == agent.SeverityError {
+				errCount++
+			}
+		}
+	}
+	if errCount > 0 {
+		return fmt.Errorf("pack %s has %d error(s); nothing installed", p.Manifest.Name, errCount)
+	}
+	return nil
+}
+
+// packDependents lists agents outside the pack that extend, call, route to or
+// classify with one of its agents.
+func packDependents(dir string, rec *pack.Record) []string {
+	index, err := agent.IndexConfigDir(dir)
+	if err != nil {
+		return nil
+	}
+	inPack := make(map[string]bool, len(rec.Agents))
+	for _, id := range rec.Agents {
+		inPack[id] = true
+	}
+	var users []string
+	for id, f := range index {
+		if inPack[id] {
+			continue
+		}
+		cfg := f.Config
+		refs := append([]string{cfg.Extends, cfg.Router.Default, cfg.Guardrails.Classifier, cfg.Voting.Scorer}, cfg.Tools...)
+		for _, r := range cfg.Router.Routes {
+			refs = append(refs, r.Agent)
+		}
+		for _, ref := range refs {
+			if inPack[ref] {
+				users = append(users, id)
+				break
+			}
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// packSource describes where a pack was installed from.
+func packSource(src, ref string) string {
+	source := pack.SourceOf(src)
+	if ref != "" {
+		source += "#" + ref
+	}
+	return source
+}
+
+// packContents summarizes what a pack ships, such as "2 agent(s), 1 prompt(s)".
+func packContents(p *pack.Pack) string {
+	parts := []string{fmt.Sprintf("%d agent(s)", len(p.Agents))}
+	if len(p.Prompts) > 0 {
+		parts = append(parts, fmt.Sprintf("%d prompt(s)", len(p.Prompts)))
+	}
+	if len(p.Workflows) > 0 {
+		parts = append(parts, fmt.Sprintf("%d workflow(s)", len(p.Workflows)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func changeMark(kind pack.ChangeKind) string {
+	switch kind {
+	case pack.Added:
+		return "+"
+	case pack.Removed:
+		return "-"
+	default:
+		return "~"
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"keystone/internal/agent"
+)
+
+// runPackCLI runs a pack subcommand against the agents dir with the given stdin.
+func runPackCLI(t *testing.T, agentsDir, stdin string, args ...string) (string, error) {
+	t.Helper()
+	return runCLI(t, cliOptions{stdin: stdin}, append([]string{"--agents-dir", agentsDir, "pack"}, args...)...)
+}
+
+func writePack(t *testing.T, dir, version, tone string) {
+	t.Helper()
+	for name, body := range map[string]string{
+		"pack.yaml":            "name: support\nversion: " + version + "\nproviders: [mock]\nagents: [agents]\n",
+		"agents/triage.yaml":   "id: triage\nname: Triage\nprovider: mock\nparameters:\n  tone: " + tone + "\n",
+		"agents/escalate.yaml": "id: escalate\nname: Escalate\nprovider: mock\ntools: [triage]\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPackInstallUpgradeRemove(t *testing.T) {
+	agentsDir := filepath.Join(t.TempDir(), "agents")
+	src := t.TempDir()
+	writePack(t, src, "1.0.0", "calm")
+
+	out, err := runPackCLI(t, agentsDir, "", "install", src)
+	if err != nil {
+		t.Fatalf("install failed: %v\n%s", err, out)
+	}
+	cfg, err := agent.ReadConfigFile(filepath.Join(agentsDir, "support", "escalate.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ID != "support.escalate" || cfg.Tools[0] != "support.triage" {
+		t.Errorf("agent not namespaced: %+v", cfg)
+	}
+	if out, err := runPackCLI(t, agentsDir, "", "install", src); err == nil || !strings.Contains(err.Error(), "already installed") {
+		t.Errorf("expected a second install to fail, got %v\n%s", err, out)
+	}
+
+	writePack(t, src, "1.0.0", "brisk")
+	if out, err := runPackCLI(t, agentsDir, "", "upgrade", src, "-y"); err == nil || !strings.Contains(err.Error(), "not newer") {
+		t.Errorf("expected an upgrade to the same version to fail, got %v\n%s", err, out)
+	}
+	writePack(t, src, "1.1.0", "brisk")
+	out, err = runPackCLI(t, agentsDir, "", "upgrade", src, "--dry-run")
+	if err != nil {
+		t.Fatalf("dry run failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "1.0.0 -> 1.1.0") || !strings.Contains(out, "-    tone: calm") || !strings.Contains(out, "+    tone: brisk") {
+		t.Errorf("expected a diff preview:\n%s", out)
+	}
+	out, _ = runPackCLI(t, agentsDir, "n\n", "upgrade", src)
+	if !strings.Contains(out, "Aborted") {
+		t.Errorf("expected the upgrade to abort without confirmation:\n%s", out)
+	}
+	if out, err := runPackCLI(t, agentsDir, "y\n", "upgrade", src); err != nil {
+		t.Fatalf("upgrade failed: %v\n%s", err, out)
+	}
+	cfg, err = agent.ReadConfigFile(filepath.Join(agentsDir, "support", "triage.yaml"))
+	if err != nil || cfg.Parameters["tone"] != "brisk" {
+		t.Errorf("upgrade not applied: %+v, %v", cfg, err)
+	}
+
+	out, err = runPackCLI(t, agentsDir, "", "list")
+	if err != nil || !strings.Contains(out, "support") || !strings.Contains(out, "1.1.0") {
+		t.Errorf("unexpected list output: %v\n%s", err, out)
+	}
+	if out, err := runPackCLI(t, agentsDir, "", "remove", "support", "-y"); err != nil {
+		t.Fatalf("remove failed: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(agentsDir, "support")); !os.IsNotExist(err) {
+		t.Error("pack agents should have been removed")
+	}
+}
+
+func TestPackInstallRejectsInvalidPacks(t *testing.T) {
+	agentsDir := filepath.Join(t.TempDir(), "agents")
+	src := t.TempDir()
+	writePack(t, src, "1.0.0", "calm")
+
+	manifest := "name: support\nversion: 1.0.0\nproviders: [openai]\nagents: [agents]\n"
+	if err := os.WriteFile(filepath.Join(src, "pack.yaml"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runPackCLI(t, agentsDir, "", "install", src); err == nil || !strings.Contains(err.Error(), `requires provider "openai"`) {
+		t.Errorf("expected a missing provider error, got %v\n%s", err, out)
+	}
+
+	writePack(t, src, "1.0.0", "calm")
+	if err := os.WriteFile(filepath.Join(src, "agents", "triage.yaml"), []byte("id: triage\nname: Triage\nprovider: nope\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runPackCLI(t, agentsDir, "", "install", src)
+	if err == nil || !strings.Contains(out, `unknown provider "nope"`) {
+		t.Errorf("expected validation to fail, got %v\n%s", err, out)
+	}
+	if _, err := os.Stat(agentsDir); !os.IsNotExist(err) {
+		t.Error("nothing should be installed from an invalid pack")
+	}
+}
@@ -14,6 +14,11 @@ func getJSONFlag(cmd *cobra.Command) bool {
 	if cmd == nil {
 		return false
 	}
+	// Command groups such as "agent" declare their own --json, which shadows the root flag when parsed.
+	if f := cmd.Flags().Lookup("json"); f != nil && f.Changed {
+		val, _ := cmd.Flags().GetBool("json")
+		return val
+	}
 	if f := cmd.Root().PersistentFlags().Lookup("json"); f != nil {
 		val, _ := cmd.Root().PersistentFlags().GetBool("json")
 		return val
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"keystone/internal/diff"
+	"keystone/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+// newPromptCmd creates the "prompt" command group for the prompt template library.
+// dirProvider returns the default library directory once flags and config are resolved.
+func newPromptCmd(dirProvider func() string) *cobra.Command {
+	var promptsDir string
+
+	promptCmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Browse the versioned prompt template library",
+	}
+	promptCmd.PersistentFlags().StringVar(&promptsDir, "prompts-dir", "", "path to the prompt library (defaults to prompts/ next to the agents dir)")
+
+	loadLibrary := func() (*prompt.Library, error) {
+		dir := promptsDir
+		if dir == "" {
+			dir = dirProvider()
+		}
+		return prompt.LoadLibrary(dir)
+	}
+
+	promptCmd.AddCommand(
+		newPromptListCmd(loadLibrary),
+		newPromptShowCmd(loadLibrary),
+		newPromptDiffCmd(loadLibrary),
+	)
+	return promptCmd
+}
+
+func newPromptListCmd(loadLibrary func() (*prompt.Library, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List prompts and their versions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lib, err := loadLibrary()
+			if err != nil {
+				return fmt.Errorf("loading prompt library: %w", err)
+			}
+			list := lib.List()
+			lines := make([]string, 0, len(list)+1)
+			lines = append(lines, fmt.Sprintf("Prompts in %s (%d)", lib.Dir(), len(list)))
+			for _, t := range list {
+				lines = append(lines, fmt.Sprintf("- %-30s %s", t.Ref(), t.Path))
+			}
+			Print(list, strings.Join(lines, "\n"), cmd)
+			return nil
+		},
+	}
+}
+
+func newPromptShowCmd(loadLibrary func() (*prompt.Library, error)) *cobra.Command {
+	var resolved bool
+
+	showCmd := &cobra.Command{
+		Use:   "show [name[@version]]",
+		Short: "Show a prompt template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lib, err := loadLibrary()
+			if err != nil {
+				return fmt.Errorf("loading prompt library: %w", err)
+			}
+			t, err := lib.Get(args[0])
+			if err != nil {
+				return err
+			}
+			text := t.Text
+			if resolved {
+				if text, err = lib.Resolve(args[0]); err != nil {
+					return err
+				}
+			}
+			Print(map[string]interface{}{
+				"name":    t.Name,
+				"version": t.Version,
+				"path":    t.Path,
+				"text":    text,
+			}, strings.TrimRight(text, "\n"), cmd)
+			return nil
+		},
+	}
+	showCmd.Flags().BoolVar(&resolved, "resolved", false, "inline included prompts")
+	return showCmd
+}
+
+func newPromptDiffCmd(loadLibrary func() (*prompt.Library, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff [name@from] [name@to]",
+		Short: "Diff two prompt versions (defaults to the previous version against the given one)",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lib, err := loadLibrary()
+			if err != nil {
+				return fmt.Errorf("loading prompt library: %w", err)
+			}
+
+			var from, to prompt.Template
+			if len(args) == 2 {
+				if from, err = lib.Get(args[0]); err != nil {
+					return err
+				}
+				if to, err = lib.Get(args[1]); err != nil {
+					return err
+				}
+			} else {
+				if to, err = lib.Get(args[0]); err != nil {
+					return err
+				}
+				if from, err = previousVersion(lib, to); err != nil {
+					return err
+				}
+			}
+
+			out := diff.Unified(from.Ref(), to.Ref(), from.Text, to.Text)
+			msg := strings.TrimRight(out, "\n")
+			if out == "" {
+				msg = fmt.Sprintf("No differences between %s and %s", from.Ref(), to.Ref())
+			}
+			Print(map[string]string{"from": from.Ref(), "to": to.Ref(), "diff": out}, msg, cmd)
+			return nil
+		},
+	}
+}
+
+// previousVersion returns the version of t's prompt that precedes it.
+func previousVersion(lib *prompt.Library, t prompt.Template) (prompt.Template, error) {
+	versions := lib.Versions(t.Name)
+	for i, v := range versions {
+		if v.Version == t.Version && i > 0 {
+			return versions[i-1], nil
+		}
+	}
+	return prompt.Template{}, fmt.Errorf("prompt %s has no earlier version to diff against", t.Ref())
+}
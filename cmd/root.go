@@ -17,6 +17,9 @@ var (
 	cfgFile string
 	verbose bool
 	version = "v0.1.0"
+
+	// agentSecrets holds the config's secrets, which ${secret:name} references in agent files resolve to.
+	agentSecrets map[string]string
 )
 
 // NewRootCmd creates the root CLI command.
@@ -65,6 +68,8 @@ func NewRootCmd(
 				os.Exit(1)
 			}
 
+			agentSecrets = cfg.Secrets
+
 			flagVal, _ := cmd.Flags().GetString("agents-dir")
 			switch {
 			case flagVal != "":
@@ -94,11 +99,15 @@ func NewRootCmd(
 	// Subcommands
 	cmd.AddCommand(
 		newTicketCmd(ticketStore),
-		newAgentCmd(func() *agent.AgentManager { return managerProvider(agentsDir) }),
-		newAgentRegisterCmd(func() *agent.AgentManager { return managerProvider(agentsDir) }, agentsDir),
+		newAgentCmd(func() *agent.AgentManager { return managerProvider(agentsDir) }, func() string { return agentsDir }),
+		newAgentRegisterCmd(func() *agent.AgentManager { return managerProvider(agentsDir) }, func() string { return agentsDir }),
 		newConfigCmd(configLoader),
+		newPromptCmd(func() string { return agent.PromptsDir(agentsDir) }),
+		newPackCmd(func() string { return agentsDir }),
 		newUsageCmd(),
+		newEvalCmd(func() string { return agentsDir }),
 		newWorkflowCmd(func() *agent.AgentManager { return managerProvider(agentsDir) }, ticketStore),
+		newDiscussCmd(func() *agent.AgentManager { return managerProvider(agentsDir) }, ticketStore),
 	)
 
 	return cmd
@@ -121,10 +130,23 @@ func loadManagerWithConfig(dir string) *agent.AgentManager {
 	}
 
 	mgr := agent.NewManager()
-	if err := agent.LoadAgentsFromConfig(mgr, dir); err != nil {
+	report, err := agent.NewLoader(dir, nil).WithSecrets(agentSecrets).Load(mgr)
+	if err != nil {
 		logger.Warn(fmt.Sprintf("Failed to load agents from %s: %v", dir, err), false)
+	} else if len(report.Errors) > 0 {
+		logger.Warn(report.Summary(), false)
 	}
 
 	agent.LoadDefaultAgent(mgr)
 	return mgr
 }
+
+// newValidator returns a validator for dir that resolves secrets from the keystone config.
+func newValidator(dir string) (*agent.Validator, error) {
+	v, err := agent.NewValidator(dir)
+	if err != nil {
+		return nil, err
+	}
+	v.Secrets = agentSecrets
+	return v, nil
+}
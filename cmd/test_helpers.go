@@ -3,10 +3,12 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"strings"
 	"testing"
 
 	"keystone/internal/agent"
+	"keystone/internal/config"
 	"keystone/internal/tickets"
 
 	"github.com/spf13/cobra"
@@ -17,6 +19,41 @@ func managerProvider() *agent.AgentManager {
 	return agent.NewManager()
 }
 
+// cliOptions sets up the environment runCLI runs a command in.
+type cliOptions struct {
+	manager *agent.AgentManager // agents served to the command; a new empty manager when nil
+	store   *tickets.Store      // ticket store; the default store when nil
+	config  *config.Config      // loaded config; the defaults when nil
+	stdin   string              // text the command reads as standard input
+	stderr  io.Writer           // receives standard error; the output when nil
+}
+
+// runCLI runs the root command with args and default config, and returns its output.
+func runCLI(t *testing.T, opts cliOptions, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	managerFor := func(string) *agent.AgentManager {
+		if opts.manager != nil {
+			return opts.manager
+		}
+		return agent.NewManager()
+	}
+	cfgLoader := func(_ string) (*config.Config, error) {
+		if opts.config != nil {
+			return opts.config, nil
+		}
+		return config.New(), nil
+	}
+	cmd := NewRootCmd(managerFor, cfgLoader, buf, opts.store)
+	cmd.SetIn(strings.NewReader(opts.stdin))
+	if opts.stderr != nil {
+		cmd.SetErr(opts.stderr)
+	}
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return buf.String(), err
+}
+
 // captureOutput runs a cobra command and captures its stdout/stderr.
 func captureOutput(f func(cmd *cobra.Command)) string {
 	var buf bytes.Buffer
@@ -4,8 +4,13 @@ package agent
 import (
 	"context"
 	"fmt"
+
+	"keystone/internal/guardrails"
+	"keystone/internal/logger"
+	"keystone/internal/memory"
 	"keystone/internal/providers"
 	"keystone/internal/tickets"
+	"keystone/internal/voting"
 )
 
 // AgentBase is a simple base implementation of an Agent.
@@ -17,8 +22,22 @@ type AgentBase struct {
 	model          string
 	provider       providers.Provider
 	promptTemplate string
+	systemPrompt   string
+	examples       []Example
 	parameters     map[string]string
+	paramSchema    ParamSchema
+	tags           []string
+	capabilities   []string
 	logging        bool
+	memoryStore    *memory.Store
+	memoryConfig   memory.Config
+	tools          []string
+	toolManager    *AgentManager
+	maxToolCalls   int
+	guard          *guardrails.Guard
+	voting         voting.Config
+	voteScorer     voting.Scorer
+	limiter        *Limiter
 }
 
 // AgentOption is a functional option to configure AgentBase.
@@ -34,11 +53,39 @@ func WithParameters(params map[string]string) AgentOption {
 	return func(a *AgentBase) { a.parameters = params }
 }
 
+// WithParameterSchema declares the agent's parameter types, defaults and constraints.
+func WithParameterSchema(schema ParamSchema) AgentOption {
+	return func(a *AgentBase) { a.paramSchema = schema }
+}
+
+// WithTags sets the agent's descriptive tags.
+func WithTags(tags ...string) AgentOption {
+	return func(a *AgentBase) { a.tags = tags }
+}
+
+// WithCapabilities sets the capabilities the agent advertises for discovery.
+func WithCapabilities(capabilities ...string) AgentOption {
+	return func(a *AgentBase) { a.capabilities = capabilities }
+}
+
 // WithLogging enables or disables agent logging.
 func WithLogging(enabled bool) AgentOption {
 	return func(a *AgentBase) { a.logging = enabled }
 }
 
+// WithMemory persists conversation turns in store using the given strategy.
+func WithMemory(store *memory.Store, cfg memory.Config) AgentOption {
+	return func(a *AgentBase) {
+		a.memoryStore = store
+		a.memoryConfig = cfg
+	}
+}
+
+// WithGuardrails checks every input and response against guard.
+func WithGuardrails(guard *guardrails.Guard) AgentOption {
+	return func(a *AgentBase) { a.guard = guard }
+}
+
 // NewAgent creates a new AgentBase with defaults applied.
 func NewAgent(id, name, description string, provider providers.Provider, model, memory string, opts ...AgentOption) Agent {
 	if id == "" {
@@ -94,13 +141,202 @@ func (a *AgentBase) PromptTemplate() string { return a.promptTemplate }
 // Parameters returns the agent's parameters map.
 func (a *AgentBase) Parameters() map[string]string { return a.parameters }
 
+// ParameterSchema returns the agent's declared parameter schema, if any.
+func (a *AgentBase) ParameterSchema() ParamSchema { return a.paramSchema }
+
+// Tags returns the agent's descriptive tags.
+func (a *AgentBase) Tags() []string { return a.tags }
+
+// Capabilities returns the capabilities the agent advertises.
+func (a *AgentBase) Capabilities() []string { return a.capabilities }
+
 // LoggingEnabled returns true if logging is enabled.
 func (a *AgentBase) LoggingEnabled() bool { return a.logging }
 
+// MemoryEnabled returns true if the agent persists conversation history.
+func (a *AgentBase) MemoryEnabled() bool {
+	return a.memoryStore != nil && a.memoryConfig.Enabled() && a.memory != "" && a.memory != "none"
+}
+
+// MemoryKey returns the key the agent's history is stored under for a ticket.
+func (a *AgentBase) MemoryKey(t *tickets.Ticket) memory.Key {
+	key := memory.Key{Memory: a.memory, UserID: "default"}
+	if t != nil {
+		key.UserID = t.UserID
+		key.TicketID = t.ID
+	}
+	return key
+}
+
 // Handle processes input using the agent's provider.
+// When memory is enabled, prior turns are prepended and the new turn is stored.
 func (a *AgentBase) Handle(ctx context.Context, input string, t *tickets.Ticket) (string, error) {
+	return a.HandleStream(ctx, input, t, nil)
+}
+
+// HandleStream behaves like Handle but passes response chunks to onChunk as they arrive.
+// Providers that cannot stream deliver the whole response as a single chunk, as do
+// agents with output guardrails, whose responses must be checked before anyone sees them.
+// Calls run within the agent's timeout and concurrency limits, if it has any.
+func (a *AgentBase) HandleStream(ctx context.Context, input string, t *tickets.Ticket, onChunk func(string)) (string, error) {
+	return a.limiter.Do(ctx, func(ctx context.Context) (string, error) {
+		return a.handle(ctx, input, t, onChunk)
+	})
+}
+
+// handle guards input, renders the agent's prompt template around it, answers it and
+// keeps the agent's memory of the turn.
+func (a *AgentBase) handle(ctx context.Context, input string, t *tickets.Ticket, onChunk func(string)) (string, error) {
 	if a.provider == nil {
 		return "", fmt.Errorf("agent %s has no provider configured", a.id)
 	}
-	return a.provider.GenerateResponse(ctx, input, a.model)
+	if a.guard != nil {
+		checked, err := a.guard.CheckInput(ctx, input)
+		if err != nil {
+			return "", a.guardrailViolation(err, t)
+		}
+		input = checked
+	}
+	if !a.MemoryEnabled() || ctx.Value(noMemoryKey{a.id}) != nil {
+		text, err := a.renderPrompt(ctx, input, t)
+		if err != nil {
+			return "", err
+		}
+		return a.reply(ctx, text, t, onChunk)
+	}
+
+	// The turn holds its conversation throughout, so concurrent turns on one ticket,
+	// as in workflows and batches, do not drop each other's history. A turn nested in
+	// one already holding it, such as a tool call back into the agent, shares the hold.
+	key, store := a.MemoryKey(t), a.store(ctx)
+	if ctx.Value(memoryTurnKey{key}) == nil {
+		unlock := store.Lock(key)
+		defer unlock()
+		ctx = context.WithValue(ctx, memoryTurnKey{key}, true)
+	}
+	conv, err := store.Load(key)
+	if err != nil {
+		return "", fmt.Errorf("agent %s memory: %w", a.id, err)
+	}
+
+	// The template is rendered around the history and the input, while the history
+	// keeps only the input, so the template is not repeated in every turn.
+	text, err := a.renderPrompt(ctx, conv.Prepend(input), t)
+	if err != nil {
+		return "", err
+	}
+	resp, err := a.reply(ctx, text, t, onChunk)
+	if err != nil {
+		return "", err
+	}
+
+	conv.Append(memory.RoleUser, input)
+	conv.Append(memory.RoleAssistant, resp)
+	if err := memory.Compact(ctx, conv, a.memoryConfig, a.summarize); err != nil {
+		logger.Warn(fmt.Sprintf("Agent %s memory compaction failed: %v", a.id, err), false)
+	}
+	if err := store.Save(key, conv); err != nil {
+		return "", fmt.Errorf("agent %s memory: %w", a.id, err)
+	}
+	return resp, nil
+}
+
+type memoryTurnKey struct{ key memory.Key }
+
+type noMemoryKey struct{ agent string }
+
+// WithoutMemory makes the agent with the given ID answer without its conversation
+// memory, for callers such as discussions whose inputs already carry the history.
+func WithoutMemory(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, noMemoryKey{id}, true)
+}
+
+type memoryStoreKey struct{}
+
+// WithMemoryStore makes every agent with memory keep it in store instead of its own,
+// so runs such as tests and evaluations neither read nor leave history in the
+// user's memory. Agents the run calls as tools or routes to use store as well.
+func WithMemoryStore(ctx context.Context, store *memory.Store) context.Context {
+	return context.WithValue(ctx, memoryStoreKey{}, store)
+}
+
+// store returns the memory store set by WithMemoryStore, or the agent's own.
+func (a *AgentBase) store(ctx context.Context) *memory.Store {
+	if s, ok := ctx.Value(memoryStoreKey{}).(*memory.Store); ok {
+		return s
+	}
+	return a.memoryStore
+}
+
+// renderPrompt renders the agent's prompt template around input with the values its
+// caller passed to PrepareInput, or the agent's own parameters if it passed none.
+func (a *AgentBase) renderPrompt(ctx context.Context, input string, t *tickets.Ticket) (string, error) {
+	return buildPrompt(a, input, promptVars(ctx, a.id), t)
+}
+
+// reply responds to text and applies the output guardrails before the response is streamed or stored.
+func (a *AgentBase) reply(ctx context.Context, text string, t *tickets.Ticket, onChunk func(string)) (string, error) {
+	if a.guard == nil || !a.guard.ChecksOutput() {
+		return a.answer(ctx, text, t, onChunk)
+	}
+	resp, err := a.answer(ctx, text, t, nil)
+	if err != nil {
+		return "", err
+	}
+	if resp, err = a.guard.CheckOutput(ctx, resp); err != nil {
+		return "", a.guardrailViolation(err, t)
+	}
+	if onChunk != nil {
+		onChunk(resp)
+	}
+	return resp, nil
+}
+
+// guardrailViolation logs a guardrail error with the ticket it happened on and returns it.
+func (a *AgentBase) guardrailViolation(err error, t *tickets.Ticket) error {
+	ticketID := "none"
+	if t != nil {
+		ticketID = t.ID
+	}
+	logger.Warn(fmt.Sprintf("Guardrail violation on ticket %s: %v", ticketID, err), false)
+	return err
+}
+
+// ResetMemory clears the agent's stored history for a ticket.
+func (a *AgentBase) ResetMemory(t *tickets.Ticket) error {
+	if !a.MemoryEnabled() {
+		return nil
+	}
+	return a.memoryStore.Clear(a.MemoryKey(t))
+}
+
+// generate calls the provider with the model selected for this request, streaming when possible.
+// A system prompt and examples are sent as chat messages to providers that support them
+// and inlined ahead of the prompt otherwise; chat replies arrive as a single chunk.
+func (a *AgentBase) generate(ctx context.Context, prompt string, onChunk func(string)) (string, error) {
+	model := ModelFromContext(ctx, a.model)
+	if a.systemPrompt != "" || len(a.examples) > 0 {
+		msgs := a.messages(prompt)
+		if cp, ok := a.provider.(providers.ChatProvider); ok {
+			resp, err := cp.Chat(ctx, msgs, model)
+			if err == nil && onChunk != nil {
+				onChunk(resp)
+			}
+			return resp, err
+		}
+		prompt = inlineMessages(msgs)
+	}
+	if sp, ok := a.provider.(providers.StreamingProvider); ok && onChunk != nil {
+		return sp.StreamResponse(ctx, prompt, model, onChunk)
+	}
+	resp, err := a.provider.GenerateResponse(ctx, prompt, model)
+	if err == nil && onChunk != nil {
+		onChunk(resp)
+	}
+	return resp, err
+}
+
+// summarize asks the agent's provider to condense a transcript for summary memory.
+func (a *AgentBase) summarize(ctx context.Context, transcript string) (string, error) {
+	return a.provider.GenerateResponse(ctx, "Summarize the following conversation in a few sentences, keeping names, facts and decisions:\n"+transcript, ModelFromContext(ctx, a.model))
 }
@@ -3,8 +3,14 @@ package agent
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
+	"keystone/internal/memory"
+	"keystone/internal/providers/venice"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,3 +38,111 @@ func TestAgentBase_Handle_NoProvider(t *testing.T) {
 	_, err := agent.Handle(context.Background(), "input", NewMockTicket())
 	require.Error(t, err)
 }
+
+// TestAgentBase_HandleWithMemory verifies prior turns are prepended and new turns stored.
+func TestAgentBase_HandleWithMemory(t *testing.T) {
+	store := memory.NewStore(t.TempDir())
+	agent := NewAgent("m1", "MemoryAgent", "desc", &MockProvider{}, "model", "session_m1",
+		WithMemory(store, memory.Config{Strategy: memory.StrategyBuffer}))
+	ticket := NewMockTicket()
+
+	resp, err := agent.Handle(context.Background(), "first", ticket)
+	require.NoError(t, err)
+	require.Equal(t, "mock response: first", resp)
+
+	resp, err = agent.Handle(context.Background(), "second", ticket)
+	require.NoError(t, err)
+	require.Contains(t, resp, "User: first")
+	require.Contains(t, resp, "Assistant: mock response: first")
+	require.True(t, strings.HasSuffix(resp, "\nsecond"))
+
+	conv, err := store.Load(agent.(*AgentBase).MemoryKey(ticket))
+	require.NoError(t, err)
+	require.Len(t, conv.Turns, 4)
+
+	// A different ticket starts with a fresh history.
+	resp, err = agent.Handle(context.Background(), "third", nil)
+	require.NoError(t, err)
+	require.Equal(t, "mock response: third", resp)
+}
+
+// TestAgentBase_MemoryKeepsRawInput verifies the template is rendered around the history
+// while only the input the caller gave is remembered.
+func TestAgentBase_MemoryKeepsRawInput(t *testing.T) {
+	store := memory.NewStore(t.TempDir())
+	a := NewAgent("m3", "MemoryAgent", "desc", &MockProvider{}, "model", "session_m3",
+		WithPromptTemplate("Topic {{topic}}: {{input}}"), WithParameters(map[string]string{"topic": "dogs"}),
+		WithMemory(store, memory.Config{Strategy: memory.StrategyBuffer}))
+	ticket := NewMockTicket()
+	vars := PromptVars{Params: map[string]string{"topic": "cats"}}
+
+	ctx, input, err := PrepareInput(context.Background(), a, "first", vars, ticket)
+	require.NoError(t, err)
+	require.Equal(t, "first", input)
+	resp, err := a.Handle(ctx, input, ticket)
+	require.NoError(t, err)
+	require.Equal(t, "mock response: Topic cats: first", resp)
+
+	ctx, input, err = PrepareInput(context.Background(), a, "second", vars, ticket)
+	require.NoError(t, err)
+	resp, err = a.Handle(ctx, input, ticket)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(resp, "mock response: Topic cats: Conversation so far:\nUser: first\n"), "the template goes around the history")
+	require.NotContains(t, resp, "User: Topic", "the history keeps the input, not the prompt")
+
+	conv, err := store.Load(a.(*AgentBase).MemoryKey(ticket))
+	require.NoError(t, err)
+	require.Len(t, conv.Turns, 4)
+	require.Equal(t, "first", conv.Turns[0].Content)
+	require.Equal(t, "second", conv.Turns[2].Content)
+
+	// Without memory for the turn, nothing is prepended or stored.
+	resp, err = a.Handle(WithoutMemory(context.Background(), "m3"), "third", ticket)
+	require.NoError(t, err)
+	require.Equal(t, "mock response: Topic dogs: third", resp)
+	conv, _ = store.Load(a.(*AgentBase).MemoryKey(ticket))
+	require.Len(t, conv.Turns, 4)
+}
+
+// TestAgentBase_ConcurrentTurnsKeepMemory verifies concurrent turns on one ticket all reach memory.
+func TestAgentBase_ConcurrentTurnsKeepMemory(t *testing.T) {
+	store := memory.SharedStore(t.TempDir())
+	agent := NewAgent("m2", "MemoryAgent", "desc", &MockProvider{}, "model", "session_m2",
+		WithMemory(store, memory.Config{Strategy: memory.StrategyBuffer}))
+	ticket := NewMockTicket()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := agent.Handle(context.Background(), fmt.Sprintf("turn %d", i), ticket)
+			require.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	conv, err := store.Load(agent.(*AgentBase).MemoryKey(ticket))
+	require.NoError(t, err)
+	require.Len(t, conv.Turns, 12)
+}
+
+// TestAgentBase_HandleStreamAndModelOverride verifies streaming and per-request model selection.
+func TestAgentBase_HandleStreamAndModelOverride(t *testing.T) {
+	a := NewAgent("s1", "Streamer", "desc", venice.New("MOCK_API_KEY", ""), "base-model", "none").(*AgentBase)
+
+	var chunks []string
+	ctx := WithModelOverride(context.Background(), "other-model")
+	resp, err := a.HandleStream(ctx, "hi there", nil, func(c string) { chunks = append(chunks, c) })
+	require.NoError(t, err)
+	require.Contains(t, resp, "model=other-model")
+	require.Greater(t, len(chunks), 1)
+	require.Equal(t, resp, strings.Join(chunks, ""))
+
+	// Non-streaming providers deliver a single chunk.
+	chunks = nil
+	b := BuildTestAgent("s2", "Plain").(*AgentBase)
+	resp, err = b.HandleStream(context.Background(), "x", nil, func(c string) { chunks = append(chunks, c) })
+	require.NoError(t, err)
+	require.Equal(t, []string{resp}, chunks)
+}
@@ -0,0 +1,106 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiscoverableAgent is implemented by agents that advertise tags and capabilities.
+// Tags are free-form labels for people; capabilities name what the agent can do
+// (summarization, scheduling, code) so routers and workflows can pick agents by skill.
+type DiscoverableAgent interface {
+	Agent
+	Tags() []string
+	Capabilities() []string
+}
+
+// TagsOf returns a's tags, or nil when it does not advertise any.
+func TagsOf(a Agent) []string {
+	if d, ok := a.(DiscoverableAgent); ok {
+		return d.Tags()
+	}
+	return nil
+}
+
+// CapabilitiesOf returns a's capabilities, or nil when it does not advertise any.
+func CapabilitiesOf(a Agent) []string {
+	if d, ok := a.(DiscoverableAgent); ok {
+		return d.Capabilities()
+	}
+	return nil
+}
+
+// Query selects agents that have every listed tag and capability. Matching ignores case.
+type Query struct {
+	Tags         []string
+	Capabilities []string
+}
+
+// Matches reports whether a satisfies the query.
+func (q Query) Matches(a Agent) bool {
+	return containsAll(TagsOf(a), q.Tags) && containsAll(CapabilitiesOf(a), q.Capabilities)
+}
+
+// Find returns the registered agents matching q, sorted by ID.
+func (m *AgentManager) Find(q Query) []Agent {
+	var out []Agent
+	for _, a := range m.List() {
+		if q.Matches(a) {
+			out = append(out, a)
+		}
+	}
+	sortAgents(out)
+	return out
+}
+
+// WithCapability returns the registered agents that advertise capability, sorted by ID.
+func (m *AgentManager) WithCapability(capability string) []Agent {
+	return m.Find(Query{Capabilities: []string{capability}})
+}
+
+// FirstWithCapability returns the agent with the lowest ID that advertises capability.
+func (m *AgentManager) FirstWithCapability(capability string) (Agent, error) {
+	agents := m.WithCapability(capability)
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("no agent with capability %q", capability)
+	}
+	return agents[0], nil
+}
+
+// validateLabels rejects empty and duplicate entries in a tags or capabilities list.
+func validateLabels(field string, labels []string) error {
+	seen := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		key := strings.ToLower(strings.TrimSpace(l))
+		if key == "" {
+			return fmt.Errorf("%s must not contain empty entries", field)
+		}
+		if seen[key] {
+			return fmt.Errorf("%s lists %q more than once", field, l)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(w)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func sortAgents(agents []Agent) {
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID() < agents[j].ID() })
+}
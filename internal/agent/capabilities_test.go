@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newDiscoveryManager(t *testing.T) *AgentManager {
+	t.Helper()
+	m := NewManager()
+	for _, a := range []Agent{
+		NewAgent("summarizer", "Summarizer", "Condenses long text", &MockProvider{}, "m", "none",
+			WithTags("text", "beta"), WithCapabilities("summarization")),
+		NewAgent("calendar", "Calendar", "Books meetings", &MockProvider{}, "m", "none",
+			WithTags("office"), WithCapabilities("scheduling")),
+		NewAgent("assistant", "Assistant", "Does a bit of everything", &MockProvider{}, "m", "none",
+			WithTags("text", "office"), WithCapabilities("Summarization", "scheduling")),
+		NewAgent("plain", "Plain", "", &MockProvider{}, "m", "none"),
+	} {
+		require.NoError(t, m.Register(a))
+	}
+	return m
+}
+
+func ids(agents []Agent) []string {
+	out := make([]string, len(agents))
+	for i, a := range agents {
+		out[i] = a.ID()
+	}
+	return out
+}
+
+func TestAgentManager_Find(t *testing.T) {
+	m := newDiscoveryManager(t)
+
+	require.Equal(t, []string{"assistant", "summarizer"}, ids(m.WithCapability("summarization")))
+	require.Equal(t, []string{"assistant", "calendar"}, ids(m.Find(Query{Tags: []string{"OFFICE"}})))
+	require.Equal(t, []string{"assistant"}, ids(m.Find(Query{Tags: []string{"text"}, Capabilities: []string{"scheduling"}})))
+	require.Len(t, m.Find(Query{}), 4)
+	require.Empty(t, m.WithCapability("translation"))
+
+	first, err := m.FirstWithCapability("scheduling")
+	require.NoError(t, err)
+	require.Equal(t, "assistant", first.ID())
+	_, err = m.FirstWithCapability("translation")
+	require.EqualError(t, err, `no agent with capability "translation"`)
+
+	require.Nil(t, TagsOf(&nonDiscoverable{}))
+}
+
+func TestAgentConfig_ValidateLabels(t *testing.T) {
+	cfg := AgentConfig{ID: "a", Name: "A", Provider: "mock", Capabilities: []string{"code", "Code"}}
+	require.ErrorContains(t, cfg.Validate(), `capabilities lists "Code" more than once`)
+	cfg.Capabilities = []string{"code"}
+	cfg.Tags = []string{" "}
+	require.ErrorContains(t, cfg.Validate(), "tags must not contain empty entries")
+}
+
+func TestRouter_CapabilityRoutes(t *testing.T) {
+	m := newDiscoveryManager(t)
+	r := newTestRouter(m, &MockProvider{}, RouterConfig{
+		Strategy: RouteByKeyword,
+		Routes: []Route{
+			{Capability: "scheduling", Keywords: []string{"meeting"}},
+			{Agent: "summarizer", Keywords: []string{"tl;dr"}},
+		},
+	})
+
+	var got []string
+	for _, c := range r.candidates() {
+		got = append(got, c.id)
+	}
+	require.Equal(t, []string{"assistant", "calendar", "summarizer"}, got)
+
+	d, err := r.Decide(context.Background(), "book a meeting")
+	require.NoError(t, err)
+	require.Equal(t, "assistant", d.Agent)
+
+	require.ErrorContains(t, RouterConfig{Strategy: RouteByLLM, Routes: []Route{{Agent: "a", Capability: "b"}}}.Validate(),
+		"exactly one of agent or capability")
+}
+
+// nonDiscoverable is an Agent that does not advertise tags or capabilities.
+type nonDiscoverable struct{ Agent }
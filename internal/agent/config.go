@@ -1,19 +1,51 @@
 // Package agent provides base implementations and helpers for AI agents.
 package agent
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+
+	"keystone/internal/guardrails"
+	"keystone/internal/memory"
+	"keystone/internal/prompt"
+	"keystone/internal/voting"
+)
 
 // AgentConfig defines the structure of an agent YAML configuration.
 type AgentConfig struct {
-	ID             string            `yaml:"id"`
-	Name           string            `yaml:"name"`
-	Description    string            `yaml:"description"`
-	Provider       string            `yaml:"provider"`
-	Model          string            `yaml:"model"`
-	Memory         string            `yaml:"memory"`
-	PromptTemplate string            `yaml:"prompt_template,omitempty"`
-	Parameters     map[string]string `yaml:"parameters,omitempty"`
-	Logging        bool              `yaml:"logging,omitempty"`
+	ID          string `yaml:"id" json:"id"`
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	// Kind is empty for a regular agent, KindRouter for a router configured by Router
+	// or KindReAct for an autonomous agent configured by ReAct.
+	Kind         string   `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Tags         []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Capabilities []string `yaml:"capabilities,omitempty" json:"capabilities,omitempty"`
+	// Extends names another agent ID whose config is inherited; see ConfigIndex.Resolve.
+	Extends        string        `yaml:"extends,omitempty" json:"extends,omitempty"`
+	Provider       string        `yaml:"provider" json:"provider"`
+	Model          string        `yaml:"model" json:"model"`
+	Memory         string        `yaml:"memory" json:"memory"`
+	MemoryOptions  memory.Config `yaml:"memory_options,omitempty" json:"memory_options,omitempty"`
+	PromptTemplate string        `yaml:"prompt_template,omitempty" json:"prompt_template,omitempty"`
+	PromptRef      string        `yaml:"prompt_ref,omitempty" json:"prompt_ref,omitempty"`
+	// SystemPrompt and Examples precede every input a regular agent sends to its provider.
+	SystemPrompt string            `yaml:"system_prompt,omitempty" json:"system_prompt,omitempty"`
+	Examples     []Example         `yaml:"examples,omitempty" json:"examples,omitempty"`
+	Parameters   map[string]string `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	ParamSchema  ParamSchema       `yaml:"parameter_schema,omitempty" json:"parameter_schema,omitempty"`
+	Router       RouterConfig      `yaml:"router,omitempty" json:"router,omitempty"`
+	ReAct        ReActConfig       `yaml:"react,omitempty" json:"react,omitempty"`
+	// Tools lists agent IDs a regular agent may call as tools, up to MaxToolCalls per input.
+	Tools        []string          `yaml:"tools,omitempty" json:"tools,omitempty"`
+	MaxToolCalls int               `yaml:"max_tool_calls,omitempty" json:"max_tool_calls,omitempty"`
+	Guardrails   guardrails.Config `yaml:"guardrails,omitempty" json:"guardrails,omitempty"`
+	// Voting makes a regular agent answer with the winner of a vote among several samples.
+	Voting voting.Config `yaml:"voting,omitempty" json:"voting,omitempty"`
+	// Timeout (a duration such as "30s") and MaxConcurrency bound every call to the agent.
+	Timeout        string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	MaxConcurrency int    `yaml:"max_concurrency,omitempty" json:"max_concurrency,omitempty"`
+	Logging        bool   `yaml:"logging,omitempty" json:"logging,omitempty"`
 }
 
 // Merge merges another AgentConfig (src) into this one, prioritizing non-empty fields from src.
@@ -27,6 +59,15 @@ func (dst *AgentConfig) Merge(src AgentConfig) {
 	if src.Description != "" {
 		dst.Description = src.Description
 	}
+	if src.Kind != "" {
+		dst.Kind = src.Kind
+	}
+	if len(src.Tags) > 0 {
+		dst.Tags = src.Tags
+	}
+	if len(src.Capabilities) > 0 {
+		dst.Capabilities = src.Capabilities
+	}
 	if src.Provider != "" {
 		dst.Provider = src.Provider
 	}
@@ -36,9 +77,24 @@ func (dst *AgentConfig) Merge(src AgentConfig) {
 	if src.Memory != "" {
 		dst.Memory = src.Memory
 	}
+	if src.MemoryOptions.Strategy != "" {
+		dst.MemoryOptions.Strategy = src.MemoryOptions.Strategy
+	}
+	if src.MemoryOptions.MaxTokens != 0 {
+		dst.MemoryOptions.MaxTokens = src.MemoryOptions.MaxTokens
+	}
 	if src.PromptTemplate != "" {
 		dst.PromptTemplate = src.PromptTemplate
 	}
+	if src.PromptRef != "" {
+		dst.PromptRef = src.PromptRef
+	}
+	if src.SystemPrompt != "" {
+		dst.SystemPrompt = src.SystemPrompt
+	}
+	if len(src.Examples) > 0 {
+		dst.Examples = src.Examples
+	}
 	if src.Parameters != nil {
 		if dst.Parameters == nil {
 			dst.Parameters = make(map[string]string)
@@ -47,6 +103,34 @@ func (dst *AgentConfig) Merge(src AgentConfig) {
 			dst.Parameters[k] = v
 		}
 	}
+	if src.ParamSchema != nil {
+		if dst.ParamSchema == nil {
+			dst.ParamSchema = make(ParamSchema)
+		}
+		for k, v := range src.ParamSchema {
+			dst.ParamSchema[k] = v
+		}
+	}
+	dst.Router.merge(src.Router)
+	dst.ReAct.merge(src.ReAct)
+	if len(src.Tools) > 0 {
+		dst.Tools = src.Tools
+	}
+	if src.MaxToolCalls != 0 {
+		dst.MaxToolCalls = src.MaxToolCalls
+	}
+	if !src.Guardrails.IsZero() {
+		dst.Guardrails = src.Guardrails
+	}
+	if !src.Voting.IsZero() {
+		dst.Voting = src.Voting
+	}
+	if src.Timeout != "" {
+		dst.Timeout = src.Timeout
+	}
+	if src.MaxConcurrency != 0 {
+		dst.MaxConcurrency = src.MaxConcurrency
+	}
 	if src.Logging {
 		dst.Logging = true
 	}
@@ -63,8 +147,134 @@ func (cfg *AgentConfig) Validate() error {
 	if cfg.Provider == "" {
 		return fmt.Errorf("missing provider for agent %s", cfg.ID)
 	}
+	if err := cfg.MemoryOptions.Validate(); err != nil {
+		return fmt.Errorf("agent %s: %w", cfg.ID, err)
+	}
+	switch cfg.Kind {
+	case "", KindRouter, KindReAct:
+	default:
+		return fmt.Errorf("agent %s: unknown kind %q", cfg.ID, cfg.Kind)
+	}
+	if !cfg.Router.IsZero() && cfg.Kind != KindRouter {
+		return fmt.Errorf("agent %s: router settings require kind: %s", cfg.ID, KindRouter)
+	}
+	if !cfg.ReAct.IsZero() && cfg.Kind != KindReAct {
+		return fmt.Errorf("agent %s: react settings require kind: %s", cfg.ID, KindReAct)
+	}
+	switch cfg.Kind {
+	case KindRouter:
+		if err := cfg.Router.Validate(); err != nil {
+			return fmt.Errorf("agent %s: %w", cfg.ID, err)
+		}
+		if len(cfg.Tools) > 0 {
+			return fmt.Errorf("agent %s: routers cannot declare tools", cfg.ID)
+		}
+		if cfg.SystemPrompt != "" || len(cfg.Examples) > 0 {
+			return fmt.Errorf("agent %s: routers do not use system_prompt or examples", cfg.ID)
+		}
+		if !cfg.Voting.IsZero() {
+			return fmt.Errorf("agent %s: routers do not vote", cfg.ID)
+		}
+		if !cfg.Guardrails.IsZero() {
+			return fmt.Errorf("agent %s: routers do not apply guardrails; set them on the routed agents", cfg.ID)
+		}
+	case KindReAct:
+		if err := cfg.ReAct.Validate(); err != nil {
+			return fmt.Errorf("agent %s: %w", cfg.ID, err)
+		}
+		if len(cfg.Tools) > 0 {
+			return fmt.Errorf("agent %s: react agents use react.tools, not tools", cfg.ID)
+		}
+		if cfg.SystemPrompt != "" || len(cfg.Examples) > 0 {
+			return fmt.Errorf("agent %s: react agents do not use system_prompt or examples", cfg.ID)
+		}
+		if !cfg.Voting.IsZero() {
+			return fmt.Errorf("agent %s: react agents do not vote", cfg.ID)
+		}
+		if !cfg.Guardrails.IsZero() {
+			return fmt.Errorf("agent %s: react agents do not apply guardrails", cfg.ID)
+		}
+		if cfg.MemoryOptions.Enabled() {
+			return fmt.Errorf("agent %s: react agents do not use memory_options", cfg.ID)
+		}
+	}
+	if err := validateLabels("tags", cfg.Tags); err != nil {
+		return fmt.Errorf("agent %s: %w", cfg.ID, err)
+	}
+	if err := validateLabels("capabilities", cfg.Capabilities); err != nil {
+		return fmt.Errorf("agent %s: %w", cfg.ID, err)
+	}
+	if err := validateTools(cfg.ID, cfg.Tools, cfg.MaxToolCalls); err != nil {
+		return fmt.Errorf("agent %s: %w", cfg.ID, err)
+	}
+	if err := validateExamples(cfg.Examples); err != nil {
+		return fmt.Errorf("agent %s: %w", cfg.ID, err)
+	}
+	if err := cfg.Guardrails.Validate(); err != nil {
+		return fmt.Errorf("agent %s: %w", cfg.ID, err)
+	}
+	if !cfg.Voting.IsZero() {
+		if err := cfg.Voting.Validate(); err != nil {
+			return fmt.Errorf("agent %s: %w", cfg.ID, err)
+		}
+		if cfg.Voting.Scorer == cfg.ID {
+			return fmt.Errorf("agent %s: cannot score its own votes", cfg.ID)
+		}
+	}
+	if _, err := cfg.Limits(); err != nil {
+		return fmt.Errorf("agent %s: %w", cfg.ID, err)
+	}
+	if err := cfg.ParamSchema.Validate(); err != nil {
+		return fmt.Errorf("agent %s: %w", cfg.ID, err)
+	}
+	if err := cfg.ParamSchema.Check(cfg.Parameters); err != nil {
+		return fmt.Errorf("agent %s: %w", cfg.ID, err)
+	}
 	if cfg.Model == "" {
 		cfg.Model = "default"
 	}
 	return nil
 }
+
+// Limits returns the timeout and concurrency limits the config sets.
+func (cfg *AgentConfig) Limits() (Limits, error) {
+	timeout, err := parseTimeout(cfg.Timeout)
+	if err != nil {
+		return Limits{}, err
+	}
+	if cfg.MaxConcurrency < 0 {
+		return Limits{}, fmt.Errorf("max_concurrency must not be negative")
+	}
+	return Limits{Timeout: timeout, MaxConcurrency: cfg.MaxConcurrency}, nil
+}
+
+// parseTimeout parses a positive duration such as "30s"; empty means no timeout.
+func parseTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: want a duration such as 30s or 2m", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("timeout must be positive, got %s", s)
+	}
+	return d, nil
+}
+
+// ResolvePrompt replaces PromptTemplate with the library prompt named by PromptRef, if any.
+func (cfg *AgentConfig) ResolvePrompt(lib *prompt.Library) error {
+	if cfg.PromptRef == "" {
+		return nil
+	}
+	if lib == nil {
+		return fmt.Errorf("agent %s references prompt %q but no prompt library is loaded", cfg.ID, cfg.PromptRef)
+	}
+	text, err := lib.Resolve(cfg.PromptRef)
+	if err != nil {
+		return fmt.Errorf("agent %s: %w", cfg.ID, err)
+	}
+	cfg.PromptTemplate = text
+	return nil
+}
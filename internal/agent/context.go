@@ -0,0 +1,22 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import "context"
+
+type modelKey struct{}
+
+// WithModelOverride returns a context that makes agents use model instead of their default.
+func WithModelOverride(ctx context.Context, model string) context.Context {
+	if model == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, modelKey{}, model)
+}
+
+// ModelFromContext returns the model override carried by ctx, or fallback if there is none.
+func ModelFromContext(ctx context.Context, fallback string) string {
+	if m, ok := ctx.Value(modelKey{}).(string); ok && m != "" {
+		return m
+	}
+	return fallback
+}
@@ -0,0 +1,108 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is an agent config as read from disk, before inheritance is applied.
+type ConfigFile struct {
+	Path   string
+	Config AgentConfig
+}
+
+// ConfigIndex maps agent IDs to their raw config files so extends chains can be followed.
+type ConfigIndex map[string]ConfigFile
+
+// ReadConfigFile reads and parses a single agent YAML file without resolving extends or prompts.
+func ReadConfigFile(path string) (AgentConfig, error) {
+	var cfg AgentConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// NewConfigIndex indexes files by agent ID. The first file wins when IDs collide.
+func NewConfigIndex(files []ConfigFile) ConfigIndex {
+	idx := make(ConfigIndex, len(files))
+	for _, f := range files {
+		if f.Config.ID == "" {
+			continue
+		}
+		if _, dup := idx[f.Config.ID]; !dup {
+			idx[f.Config.ID] = f
+		}
+	}
+	return idx
+}
+
+// IndexConfigDir reads and indexes every agent config under dir. Malformed files are skipped.
+func IndexConfigDir(dir string) (ConfigIndex, error) {
+	files, _, err := readConfigDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent config dir %s: %w", dir, err)
+	}
+	return NewConfigIndex(files), nil
+}
+
+// Chain returns cfg followed by each config it extends, nearest parent first.
+func (idx ConfigIndex) Chain(cfg AgentConfig) ([]AgentConfig, error) {
+	chain := []AgentConfig{cfg}
+	ids := []string{cfg.ID}
+	for cur := cfg; cur.Extends != ""; {
+		parent, ok := idx[cur.Extends]
+		if !ok {
+			return nil, fmt.Errorf("agent %s extends unknown agent %q", cur.ID, cur.Extends)
+		}
+		for _, id := range ids {
+			if id == parent.Config.ID {
+				return nil, fmt.Errorf("agent extends cycle: %s -> %s", strings.Join(ids, " -> "), id)
+			}
+		}
+		ids = append(ids, parent.Config.ID)
+		chain = append(chain, parent.Config)
+		cur = parent.Config
+	}
+	return chain, nil
+}
+
+// Resolve returns cfg with every config in its extends chain applied beneath it.
+// Fields set closer to cfg win; parameters are merged key by key.
+func (idx ConfigIndex) Resolve(cfg AgentConfig) (AgentConfig, error) {
+	if cfg.Extends == "" {
+		return cfg, nil
+	}
+	chain, err := idx.Chain(cfg)
+	if err != nil {
+		return cfg, err
+	}
+
+	var out AgentConfig
+	for i := len(chain) - 1; i >= 0; i-- {
+		out.inherit(chain[i])
+	}
+	out.ID = cfg.ID
+	out.Extends = cfg.Extends
+	return out, nil
+}
+
+// inherit layers src over dst. A prompt set inline or by reference replaces
+// whichever form the parent used, so a child template is not clobbered by a parent prompt_ref.
+func (dst *AgentConfig) inherit(src AgentConfig) {
+	if src.PromptTemplate != "" {
+		dst.PromptRef = ""
+	}
+	if src.PromptRef != "" {
+		dst.PromptTemplate = ""
+	}
+	dst.Merge(src)
+}
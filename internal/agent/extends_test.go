@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"path/filepath"
+	"testing"
+
+	"keystone/internal/providers"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigIndex_ResolveMultiLevel(t *testing.T) {
+	idx := NewConfigIndex([]ConfigFile{
+		{Path: "base.yaml", Config: AgentConfig{
+			ID: "base", Name: "Base", Provider: "mock", Model: "base-model", Logging: true,
+			PromptRef:  "assistant@v1",
+			Parameters: map[string]string{"tone": "calm", "lang": "en"},
+		}},
+		{Path: "mid.yaml", Config: AgentConfig{
+			ID: "mid", Extends: "base", Name: "Mid",
+			PromptTemplate: "Mid: {{input}}",
+			Parameters:     map[string]string{"tone": "friendly"},
+		}},
+	})
+
+	leaf := AgentConfig{ID: "leaf", Extends: "mid", Model: "leaf-model"}
+	cfg, err := idx.Resolve(leaf)
+	require.NoError(t, err)
+
+	require.Equal(t, "leaf", cfg.ID)
+	require.Equal(t, "mid", cfg.Extends)
+	require.Equal(t, "Mid", cfg.Name)
+	require.Equal(t, "mock", cfg.Provider)
+	require.Equal(t, "leaf-model", cfg.Model)
+	require.True(t, cfg.Logging)
+	require.Equal(t, "Mid: {{input}}", cfg.PromptTemplate)
+	require.Empty(t, cfg.PromptRef, "child template should replace the inherited prompt_ref")
+	require.Equal(t, map[string]string{"tone": "friendly", "lang": "en"}, cfg.Parameters)
+
+	// Parents must not be modified by resolution.
+	require.Equal(t, "calm", idx["base"].Config.Parameters["tone"])
+
+	chain, err := idx.Chain(leaf)
+	require.NoError(t, err)
+	require.Len(t, chain, 3)
+}
+
+func TestConfigIndex_ResolveErrors(t *testing.T) {
+	idx := NewConfigIndex([]ConfigFile{
+		{Config: AgentConfig{ID: "a", Extends: "b"}},
+		{Config: AgentConfig{ID: "b", Extends: "a"}},
+	})
+
+	_, err := idx.Resolve(idx["a"].Config)
+	require.ErrorContains(t, err, "cycle: a -> b -> a")
+
+	_, err = idx.Resolve(AgentConfig{ID: "c", Extends: "missing"})
+	require.ErrorContains(t, err, `unknown agent "missing"`)
+}
+
+func TestLoadAgentsWithExtends(t *testing.T) {
+	dir := t.TempDir()
+	WriteYAML(t, filepath.Join(dir, "base.yaml"), AgentConfig{
+		ID: "base", Name: "Base", Provider: "mock", Model: "base-model",
+		Parameters: map[string]string{"tone": "calm"},
+	})
+	WriteYAML(t, filepath.Join(dir, "child.yaml"), AgentConfig{ID: "child", Name: "Child", Extends: "base"})
+
+	manager := NewManager()
+	require.NoError(t, LoadAgentsFromConfig(manager, dir))
+	child, err := manager.Get("child")
+	require.NoError(t, err)
+	require.Equal(t, "base-model", child.DefaultModel())
+	require.Equal(t, "calm", child.Parameters()["tone"])
+
+	lm := NewLifecycleManager(dir, map[string]providers.Provider{"mock": &MockProvider{}})
+	require.NoError(t, lm.LoadAgent("child"))
+	child, err = lm.Manager().Get("child")
+	require.NoError(t, err)
+	require.Equal(t, "base-model", child.DefaultModel())
+}
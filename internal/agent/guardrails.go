@@ -0,0 +1,30 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"keystone/internal/guardrails"
+)
+
+// topicClassifier asks the agent with the given ID, looked up in manager when a check runs,
+// which banned topic a text discusses. The classifier runs without a ticket so checks do
+// not spend the caller's hops.
+func topicClassifier(manager *AgentManager, id string) guardrails.Classifier {
+	return func(ctx context.Context, text string, topics []string) (string, error) {
+		classifier, err := manager.Get(id)
+		if err != nil {
+			return "", err
+		}
+		question := fmt.Sprintf("Which of these topics does the text below discuss: %s?\n"+
+			"Reply with the topic name only, or \"none\" if it discusses none of them.\n\nText:\n%s",
+			strings.Join(topics, ", "), text)
+		reply, err := classifier.Handle(ctx, question, nil)
+		if err != nil {
+			return "", err
+		}
+		return guardrails.MatchTopic(reply, topics), nil
+	}
+}
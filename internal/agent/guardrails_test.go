@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"keystone/internal/guardrails"
+	"keystone/internal/tickets"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardrails_AroundHandle(t *testing.T) {
+	guard, err := guardrails.New("bot", guardrails.Config{
+		Input:  guardrails.Policy{PII: guardrails.PIIRedact},
+		Output: guardrails.Policy{Deny: []string{`(?i)secret`}},
+	}, nil)
+	require.NoError(t, err)
+	p := &replyProvider{reply: "all good"}
+	bot := NewAgent("bot", "Bot", "", p, "m", "none", WithGuardrails(guard))
+
+	var chunks []string
+	resp, err := bot.(StreamingAgent).HandleStream(context.Background(), "mail me at a@b.io", nil, func(c string) { chunks = append(chunks, c) })
+	require.NoError(t, err)
+	require.Equal(t, "all good", resp)
+	require.Equal(t, []string{"all good"}, chunks)
+	require.Equal(t, "mail me at [REDACTED:EMAIL]", p.prompt, "the provider only sees redacted input")
+
+	p.reply = "the secret is 42"
+	_, err = bot.Handle(context.Background(), "tell me", tickets.NewTicket("t1", "u1", nil))
+	var ge *guardrails.Error
+	require.True(t, errors.As(err, &ge))
+	require.Equal(t, guardrails.StageOutput, ge.Stage)
+	require.Equal(t, guardrails.RuleDeny, ge.Rule)
+}
+
+func TestGuardrails_LoaderWiresClassifier(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"topics.yaml": "id: topics\nname: Topics\nprovider: mock\n",
+		"bot.yaml": `id: bot
+name: Bot
+provider: mock
+guardrails:
+  classifier: topics
+  input:
+    max_length: 100
+    banned_topics: [medical advice]
+`,
+	}
+	for name, body := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+	}
+	m := NewManager()
+	report, err := NewLoader(dir, nil).Load(m)
+	require.NoError(t, err)
+	require.NoError(t, report.Err())
+
+	bot, err := m.Get("bot")
+	require.NoError(t, err)
+	// The mock classifier echoes the question, which names no single topic, so input passes.
+	resp, err := bot.Handle(context.Background(), "hello", nil)
+	require.NoError(t, err)
+	require.Equal(t, "mock response: hello", resp)
+
+	m.Replace(NewAgent("topics", "Topics", "", &replyProvider{reply: "Medical advice"}, "m", "none"))
+	_, err = bot.Handle(context.Background(), "what dose should I take?", nil)
+	require.ErrorContains(t, err, `agent bot input blocked by guardrail banned_topic: discusses banned topic "medical advice"`)
+
+	_, err = bot.Handle(context.Background(), string(make([]byte, 101)), nil)
+	require.ErrorContains(t, err, "guardrail max_length")
+}
+
+func TestValidator_Guardrails(t *testing.T) {
+	v := newTestValidator(t)
+	doc := `id: bot
+name: Bot
+provider: mock
+guardrails:
+  classifier: ghost
+  output:
+    pii: hide
+`
+	var got []string
+	for _, i := range v.Validate("bot.yaml", []byte(doc)) {
+		got = append(got, i.String())
+	}
+	require.Equal(t, []string{
+		`bot.yaml:4: error: guardrails output: unknown pii mode "hide" (want block or redact)`,
+		`bot.yaml:5: error: classifier refers to unknown agent "ghost"`,
+	}, got)
+}
@@ -23,3 +23,9 @@ type ContextualAgent interface {
 	Agent
 	ContextData() map[string]string
 }
+
+// StreamingAgent is implemented by agents that can stream their response as it is generated.
+type StreamingAgent interface {
+	Agent
+	HandleStream(ctx context.Context, input string, t *tickets.Ticket, onChunk func(string)) (string, error)
+}
@@ -0,0 +1,207 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SecretMask replaces secret values when configs are displayed.
+const SecretMask = "********"
+
+// refRe matches ${env:NAME}, ${secret:name} and ${param:name} references.
+var refRe = regexp.MustCompile(`\$\{(env|secret|param):([^}]*)\}`)
+
+// Vars supplies the values that references in agent configs resolve to: Secrets
+// for ${secret:name} and Env, which defaults to os.LookupEnv, for ${env:NAME}.
+// With MaskSecrets, secret references resolve to SecretMask, for display.
+type Vars struct {
+	Secrets     map[string]string
+	Env         func(string) (string, bool)
+	MaskSecrets bool
+}
+
+// MissingRef is a reference that could not be resolved. Field is the YAML path of
+// the value holding it, such as "parameters.api_key".
+type MissingRef struct {
+	Field  string
+	Ref    string
+	Reason string
+}
+
+func (m MissingRef) Error() string {
+	return fmt.Sprintf("%s: %s %s", m.Field, m.Ref, m.Reason)
+}
+
+// Interpolate replaces references in every string value of cfg other than id,
+// extends and kind, without modifying maps or slices cfg shares with other configs.
+// ${param:name} refers to the agent's parameters, or failing that its
+// parameter_schema default; parameters are interpolated first and cannot
+// themselves use ${param:...}. It returns the secret values substituted, so callers
+// can keep them out of logs, and every reference that could not be resolved.
+func (v Vars) Interpolate(cfg *AgentConfig) ([]string, []MissingRef) {
+	in := &interpolation{vars: v, cfg: cfg}
+	if in.vars.Env == nil {
+		in.vars.Env = os.LookupEnv
+	}
+
+	if cfg.Parameters != nil {
+		params := make(map[string]string, len(cfg.Parameters))
+		for _, name := range sortedParamNames(cfg.Parameters) {
+			params[name] = in.expand("parameters."+name, cfg.Parameters[name], false)
+		}
+		cfg.Parameters = params
+	}
+	in.walk(reflect.ValueOf(cfg).Elem(), "")
+
+	secrets := make([]string, 0, len(in.secrets))
+	for s := range in.secrets {
+		secrets = append(secrets, s)
+	}
+	sort.Strings(secrets)
+	return secrets, in.missing
+}
+
+// MissingRefsError combines unresolved references into one error, or returns nil.
+func MissingRefsError(missing []MissingRef) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(missing))
+	for i, m := range missing {
+		msgs[i] = m.Error()
+	}
+	return fmt.Errorf("unresolved references: %s", strings.Join(msgs, "; "))
+}
+
+type interpolation struct {
+	vars    Vars
+	cfg     *AgentConfig
+	secrets map[string]bool
+	missing []MissingRef
+}
+
+// skipFields are top-level keys that identify the config and are never interpolated.
+var skipFields = map[string]bool{"id": true, "extends": true, "kind": true, "parameters": true}
+
+// walk interpolates the strings reachable from v, an addressable value at YAML path.
+// Slices, maps and pointers are replaced by copies so that values shared with other
+// configs, such as the index an agent was resolved from, are left untouched.
+func (in *interpolation) walk(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.String:
+		if s := v.String(); strings.Contains(s, "${") {
+			v.SetString(in.expand(path, s, true))
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		elem := reflect.New(v.Type().Elem())
+		elem.Elem().Set(v.Elem())
+		in.walk(elem.Elem(), path)
+		v.Set(elem)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			if path == "" && skipFields[name] {
+				continue
+			}
+			in.walk(v.Field(i), joinPath(path, name))
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(out, v)
+		for i := 0; i < out.Len(); i++ {
+			in.walk(out.Index(i), path)
+		}
+		v.Set(out)
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			// Map elements are not addressable, so interpolate a copy and store it.
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			in.walk(elem, joinPath(path, key.String()))
+			out.SetMapIndex(key, elem)
+		}
+		v.Set(out)
+	}
+}
+
+// expand resolves the references in s, recording those that cannot be resolved.
+func (in *interpolation) expand(field, s string, allowParams bool) string {
+	return refRe.ReplaceAllStringFunc(s, func(ref string) string {
+		m := refRe.FindStringSubmatch(ref)
+		kind, name := m[1], strings.TrimSpace(m[2])
+		miss := func(reason string) string {
+			in.missing = append(in.missing, MissingRef{Field: field, Ref: ref, Reason: reason})
+			return ref
+		}
+		if name == "" {
+			return miss("has no name")
+		}
+
+		switch kind {
+		case "env":
+			if value, ok := in.vars.Env(name); ok {
+				return value
+			}
+			return miss("refers to an unset environment variable")
+		case "secret":
+			value, ok := in.vars.Secrets[name]
+			if !ok {
+				return miss("refers to a secret missing from the keystone config")
+			}
+			if in.vars.MaskSecrets {
+				return SecretMask
+			}
+			if value != "" {
+				if in.secrets == nil {
+					in.secrets = map[string]bool{}
+				}
+				in.secrets[value] = true
+			}
+			return value
+		default:
+			if !allowParams {
+				return miss("cannot be used in parameters")
+			}
+			if value, ok := in.cfg.Parameters[name]; ok {
+				return value
+			}
+			if spec, ok := in.cfg.ParamSchema[name]; ok && spec.Default != "" {
+				return spec.Default
+			}
+			return miss("refers to an undefined parameter")
+		}
+	})
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
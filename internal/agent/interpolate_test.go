@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testEnv(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestVars_Interpolate(t *testing.T) {
+	cfg := AgentConfig{
+		ID:             "${env:NOPE}",
+		Name:           "Support (${env:REGION})",
+		Model:          "${param:model}",
+		PromptTemplate: "Use key ${secret:api_key} in ${param:region}: {{input}}",
+		Tags:           []string{"team-${env:TEAM}"},
+		Parameters:     map[string]string{"model": "gpt-${env:MODEL_VERSION}", "region": "${env:REGION}"},
+		ParamSchema:    ParamSchema{"tone": {Default: "${env:TONE}"}},
+	}
+	shared := cfg.Tags
+	vars := Vars{
+		Secrets: map[string]string{"api_key": "sk-123"},
+		Env:     testEnv(map[string]string{"REGION": "eu", "TEAM": "ops", "MODEL_VERSION": "4", "TONE": "calm"}),
+	}
+
+	secrets, missing := vars.Interpolate(&cfg)
+	require.Empty(t, missing)
+	require.Equal(t, []string{"sk-123"}, secrets)
+	require.Equal(t, "${env:NOPE}", cfg.ID, "id is never interpolated")
+	require.Equal(t, "Support (eu)", cfg.Name)
+	require.Equal(t, "gpt-4", cfg.Model)
+	require.Equal(t, "Use key sk-123 in eu: {{input}}", cfg.PromptTemplate)
+	require.Equal(t, []string{"team-ops"}, cfg.Tags)
+	require.Equal(t, "calm", cfg.ParamSchema["tone"].Default)
+	require.Equal(t, []string{"team-${env:TEAM}"}, shared, "shared slices are copied, not modified")
+
+	masked := AgentConfig{PromptTemplate: "key=${secret:api_key}"}
+	secrets, _ = Vars{Secrets: vars.Secrets, MaskSecrets: true}.Interpolate(&masked)
+	require.Empty(t, secrets)
+	require.Equal(t, "key="+SecretMask, masked.PromptTemplate)
+}
+
+func TestVars_InterpolateReportsMissing(t *testing.T) {
+	cfg := AgentConfig{
+		Description: "${env:UNSET} ${secret:nope} ${param:ghost} ${env:}",
+		Parameters:  map[string]string{"a": "${param:b}", "b": "x"},
+	}
+	_, missing := Vars{Env: testEnv(nil)}.Interpolate(&cfg)
+	require.Len(t, missing, 5)
+	require.Equal(t, "parameters.a: ${param:b} cannot be used in parameters", missing[0].Error())
+	require.Equal(t, "description: ${env:UNSET} refers to an unset environment variable", missing[1].Error())
+	require.Equal(t, "description: ${secret:nope} refers to a secret missing from the keystone config", missing[2].Error())
+	require.Equal(t, "description: ${param:ghost} refers to an undefined parameter", missing[3].Error())
+	require.Equal(t, "description: ${env:} has no name", missing[4].Error())
+	require.ErrorContains(t, MissingRefsError(missing), "unresolved references: parameters.a:")
+	require.NoError(t, MissingRefsError(nil))
+}
+
+func TestLoader_InterpolatesSecrets(t *testing.T) {
+	t.Setenv("KEYSTONE_TEST_MODEL", "m-large")
+	dir := t.TempDir()
+	writeAgentFile(t, dir, "a.yaml", "id: a\nname: A\nprovider: mock\nmodel: ${env:KEYSTONE_TEST_MODEL}\nprompt_template: \"token ${secret:token}: {{input}}\"\n")
+	writeAgentFile(t, dir, "b.yaml", "id: b\nname: B\nprovider: mock\nprompt_template: \"${secret:absent}\"\n")
+
+	m := NewManager()
+	report, err := NewLoader(dir, nil).WithSecrets(map[string]string{"token": "tok-42"}).Load(m)
+	require.NoError(t, err)
+	require.Len(t, report.Loaded, 1)
+	require.Len(t, report.Errors, 1)
+	require.Contains(t, report.Errors[0].Reason, "prompt_template: ${secret:absent} refers to a secret missing from the keystone config")
+
+	a, err := m.Get("a")
+	require.NoError(t, err)
+	require.Equal(t, "m-large", a.DefaultModel())
+	resp, err := a.Handle(context.Background(), "hi", nil)
+	require.NoError(t, err)
+	require.Equal(t, "mock response: token tok-42: hi", resp)
+	require.Equal(t, "token tok-42: {{input}}", a.PromptTemplate())
+}
+
+func TestValidator_MissingReferences(t *testing.T) {
+	v := newTestValidator(t)
+	v.Secrets = map[string]string{"known": "s"}
+	doc := `id: refs
+name: Refs
+provider: mock
+prompt_template: "${secret:known} ${secret:unknown}"
+parameters:
+  key: ${env:KEYSTONE_SURELY_UNSET}
+`
+	var got []string
+	for _, i := range v.Validate("refs.yaml", []byte(doc)) {
+		got = append(got, i.String())
+	}
+	require.Equal(t, []string{
+		`refs.yaml:4: error: ${secret:unknown} refers to a secret missing from the keystone config`,
+		`refs.yaml:6: error: ${env:KEYSTONE_SURELY_UNSET} refers to an unset environment variable`,
+	}, got)
+}
+
+func writeAgentFile(t *testing.T, dir, name, body string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+}
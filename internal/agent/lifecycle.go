@@ -3,10 +3,10 @@ package agent
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 
+	"keystone/internal/logger"
 	"keystone/internal/providers"
 
 	"gopkg.in/yaml.v3"
@@ -14,7 +14,18 @@ import (
 
 const DefaultAgentsDir = "./agents"
 
+// PromptsDir returns the prompt library directory that sits alongside an agents directory.
+func PromptsDir(agentsDir string) string {
+	return filepath.Join(filepath.Dir(filepath.Clean(agentsDir)), "prompts")
+}
+
+// WorkflowsDir returns the workflow directory that sits alongside an agents directory.
+func WorkflowsDir(agentsDir string) string {
+	return filepath.Join(filepath.Dir(filepath.Clean(agentsDir)), "workflows")
+}
+
 // LifecycleManager manages agent configurations and provider resolution.
+// Loading is delegated to a Loader sharing the manager's provider map.
 type LifecycleManager struct {
 	configDir string
 	manager   *AgentManager
@@ -22,12 +33,13 @@ type LifecycleManager struct {
 }
 
 // NewLifecycleManager creates a new LifecycleManager with optional config directory and provider map.
+// A nil provider map starts from DefaultProviders.
 func NewLifecycleManager(configDir string, providersMap map[string]providers.Provider) *LifecycleManager {
 	if configDir == "" {
 		configDir = DefaultAgentsDir
 	}
 	if providersMap == nil {
-		providersMap = make(map[string]providers.Provider)
+		providersMap = DefaultProviders()
 	}
 	return &LifecycleManager{
 		configDir: configDir,
@@ -41,6 +53,11 @@ func (lm *LifecycleManager) Manager() *AgentManager {
 	return lm.manager
 }
 
+// Loader returns a Loader for the config directory using the registered providers.
+func (lm *LifecycleManager) Loader() *Loader {
+	return NewLoader(lm.configDir, lm.providers)
+}
+
 // RegisterProvider adds a provider under the specified name.
 func (lm *LifecycleManager) RegisterProvider(name string, p providers.Provider) {
 	lm.providers[name] = p
@@ -63,94 +80,40 @@ func (lm *LifecycleManager) SaveOrMergeConfig(cfg AgentConfig) error {
 	if err := os.MkdirAll(lm.configDir, 0755); err != nil {
 		return fmt.Errorf("failed to create config dir: %w", err)
 	}
-	path := filepath.Join(lm.configDir, cfg.ID+".yaml")
-	data, _ := yaml.Marshal(&cfg)
-	return os.WriteFile(path, data, 0644)
+	return WriteConfigFile(filepath.Join(lm.configDir, cfg.ID+".yaml"), cfg)
 }
 
-// LoadAgent loads a single agent by ID and registers it.
-func (lm *LifecycleManager) LoadAgent(agentID string) error {
-	if agentID == "" {
-		return fmt.Errorf("agentID required")
-	}
-	path := filepath.Join(lm.configDir, agentID+".yaml")
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return fmt.Errorf("agent config not found: %s", path)
-	}
+// ConfigDir returns the directory agent configs are saved to and loaded from.
+func (lm *LifecycleManager) ConfigDir() string {
+	return lm.configDir
+}
 
-	cfg, err := lm.loadAgentConfig(path)
-	if err != nil {
-		return fmt.Errorf("failed to load agent config: %w", err)
-	}
-	provider, err := lm.ResolveProvider(cfg.Provider)
+// WriteConfigFile marshals cfg to YAML at path.
+func WriteConfigFile(path string, cfg AgentConfig) error {
+	data, err := yaml.Marshal(&cfg)
 	if err != nil {
-		return fmt.Errorf("failed to resolve provider: %w", err)
+		return fmt.Errorf("failed to encode agent config %s: %w", cfg.ID, err)
 	}
-
-	agent := NewAgent(cfg.ID, cfg.Name, cfg.Description, provider, cfg.Model, cfg.Memory,
-		WithParameters(cfg.Parameters),
-		WithPromptTemplate(cfg.PromptTemplate),
-		WithLogging(cfg.Logging),
-	)
-	return lm.manager.Register(agent)
+	return os.WriteFile(path, data, 0644)
 }
 
-// LoadAgentsFromDir loads all YAML agent configs from the config directory.
-func (lm *LifecycleManager) LoadAgentsFromDir() error {
-	dir := lm.configDir
-	info, err := os.Stat(dir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			log.Printf("warning: agents directory %q does not exist; continuing", dir)
-			return nil
-		}
-		return fmt.Errorf("failed to read agents directory %q: %w", dir, err)
-	}
-	if !info.IsDir() {
-		return fmt.Errorf("agents path %q is not a directory", dir)
-	}
+// LoadAgent loads a single agent by ID and registers it.
+func (lm *LifecycleManager) LoadAgent(agentID string) error {
+	return lm.Loader().LoadAgent(lm.manager, agentID)
+}
 
-	count := 0
-	entries, _ := os.ReadDir(dir)
-	for _, entry := range entries {
-		if entry.IsDir() || (filepath.Ext(entry.Name()) != ".yaml" && filepath.Ext(entry.Name()) != ".yml") {
-			continue
-		}
-		path := filepath.Join(dir, entry.Name())
-		cfg, err := lm.loadAgentConfig(path)
-		if err != nil {
-			log.Printf("warning: skipping malformed agent file %s: %v", entry.Name(), err)
-			continue
-		}
-		provider, err := lm.ResolveProvider(cfg.Provider)
-		if err != nil {
-			log.Printf("warning: skipping agent %s (provider %q not found)", cfg.ID, cfg.Provider)
-			continue
-		}
-		agent := NewAgent(cfg.ID, cfg.Name, cfg.Description, provider, cfg.Model, cfg.Memory,
-			WithParameters(cfg.Parameters),
-			WithPromptTemplate(cfg.PromptTemplate),
-			WithLogging(cfg.Logging),
-		)
-		if err := lm.manager.Register(agent); err != nil {
-			log.Printf("warning: failed to register agent %s: %v", cfg.ID, err)
-			continue
-		}
-		count++
-	}
-	log.Printf("loaded %d agent(s) from %q", count, dir)
-	return nil
+// Load registers every agent in the config directory and returns the load report.
+func (lm *LifecycleManager) Load() (*LoadReport, error) {
+	return lm.Loader().Load(lm.manager)
 }
 
-// loadAgentConfig reads a YAML file and unmarshals it into an AgentConfig.
-func (lm *LifecycleManager) loadAgentConfig(path string) (AgentConfig, error) {
-	var cfg AgentConfig
-	data, err := os.ReadFile(path)
+// LoadAgentsFromDir loads all agent configs from the config directory.
+// Malformed files, unknown providers and duplicates are logged and skipped rather than returned.
+func (lm *LifecycleManager) LoadAgentsFromDir() error {
+	report, err := lm.Load()
 	if err != nil {
-		return cfg, err
-	}
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return cfg, err
+		return err
 	}
-	return cfg, nil
+	logger.Info(report.Summary(), false)
+	return nil
 }
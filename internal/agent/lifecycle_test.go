@@ -66,3 +66,23 @@ func TestLifecycleManager_LoadAgentsFromDir(t *testing.T) {
 	err = lm.LoadAgentsFromDir()
 	require.NoError(t, err) // logs a warning but does not fail
 }
+
+func TestLifecycleManager_LoadAgentResolvesPromptRef(t *testing.T) {
+	root := t.TempDir()
+	agentsDir := filepath.Join(root, "agents")
+	promptDir := filepath.Join(PromptsDir(agentsDir), "greeting")
+	require.NoError(t, os.MkdirAll(promptDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(promptDir, "v1.tmpl"), []byte("Hello {{input}}"), 0o644))
+
+	lm := NewLifecycleManager(agentsDir, map[string]providers.Provider{"mock": &MockProvider{}})
+	require.NoError(t, lm.SaveOrMergeConfig(AgentConfig{ID: "greeter", Name: "Greeter", Provider: "mock", PromptRef: "greeting@v1"}))
+	require.NoError(t, lm.LoadAgent("greeter"))
+
+	a, err := lm.Manager().Get("greeter")
+	require.NoError(t, err)
+	require.Equal(t, "Hello {{input}}", a.PromptTemplate())
+
+	// Unknown references fail to load.
+	require.NoError(t, lm.SaveOrMergeConfig(AgentConfig{ID: "broken", Name: "Broken", Provider: "mock", PromptRef: "greeting@v9"}))
+	require.Error(t, lm.LoadAgent("broken"))
+}
@@ -0,0 +1,169 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limits bounds how an agent runs. Timeout caps each call, including any wait for a
+// free slot; MaxConcurrency caps how many calls run at once. Zero means no bound.
+type Limits struct {
+	Timeout        time.Duration `json:"timeout,omitempty"`
+	MaxConcurrency int           `json:"max_concurrency,omitempty"`
+}
+
+// IsZero reports whether the limits bound nothing.
+func (l Limits) IsZero() bool { return l.Timeout == 0 && l.MaxConcurrency == 0 }
+
+// TimeoutError reports an agent call that ran past the agent's timeout. Waiting is
+// set when the call timed out before a concurrency slot came free.
+type TimeoutError struct {
+	Agent          string
+	Timeout        time.Duration
+	MaxConcurrency int
+	Waiting        bool
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Waiting {
+		return fmt.Sprintf("agent %s timed out after %s waiting for a free slot (max_concurrency %d)", e.Agent, e.Timeout, e.MaxConcurrency)
+	}
+	return fmt.Sprintf("agent %s timed out after %s", e.Agent, e.Timeout)
+}
+
+// Unwrap lets callers match a timeout with errors.Is(err, context.DeadlineExceeded).
+func (e *TimeoutError) Unwrap() error { return context.DeadlineExceeded }
+
+// Limiter enforces an agent's Limits. It is owned by the AgentManager and shared by
+// every instance registered under the agent's ID, so calls still running on an
+// instance that a reload replaced keep counting against the limit.
+type Limiter struct {
+	id     string
+	mu     sync.Mutex
+	limits Limits
+	active int           // calls holding a slot
+	freed  chan struct{} // closed when a slot may have come free
+}
+
+// Limiter returns the limiter for the agent with the given ID, creating it or
+// applying new limits to the existing one.
+func (m *AgentManager) Limiter(id string, limits Limits) *Limiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.limiters == nil {
+		m.limiters = make(map[string]*Limiter)
+	}
+	l, ok := m.limiters[id]
+	if !ok {
+		l = &Limiter{id: id, freed: make(chan struct{})}
+		m.limiters[id] = l
+	}
+	l.set(limits)
+	return l
+}
+
+// set applies limits. Calls already running keep their slots and count against a
+// new MaxConcurrency, so lowering it only admits new calls once enough have finished.
+func (l *Limiter) set(limits Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	l.wakeLocked()
+}
+
+// Limits returns the limits being enforced.
+func (l *Limiter) Limits() Limits {
+	if l == nil {
+		return Limits{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limits
+}
+
+type heldKey struct{ l *Limiter }
+
+// Do runs call within the limits: it waits for a free slot and bounds the call,
+// waiting included, by the timeout. A nil limiter runs call directly, as does a call
+// nested in one that already holds a slot, such as a router's own model call.
+func (l *Limiter) Do(ctx context.Context, call func(context.Context) (string, error)) (string, error) {
+	if l == nil || ctx.Value(heldKey{l}) != nil {
+		return call(ctx)
+	}
+	timeout := l.Limits().Timeout
+
+	parent := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := l.acquire(ctx, parent, timeout); err != nil {
+		return "", err
+	}
+	defer l.release()
+
+	out, err := call(context.WithValue(ctx, heldKey{l}, true))
+	// A provider that ignores the deadline still has its late answer discarded.
+	if timeout > 0 && parent.Err() == nil && ctx.Err() == context.DeadlineExceeded {
+		return "", &TimeoutError{Agent: l.id, Timeout: timeout}
+	}
+	return out, err
+}
+
+// acquire takes a slot, waiting until ctx ends. A free slot is taken even when the
+// deadline has already passed, so only real waiting is reported as such.
+func (l *Limiter) acquire(ctx, parent context.Context, timeout time.Duration) error {
+	for {
+		l.mu.Lock()
+		max := l.limits.MaxConcurrency
+		if max <= 0 || l.active < max {
+			l.active++
+			l.mu.Unlock()
+			return nil
+		}
+		freed := l.freed
+		l.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			if parent.Err() != nil {
+				return parent.Err()
+			}
+			return &TimeoutError{Agent: l.id, Timeout: timeout, MaxConcurrency: max, Waiting: true}
+		}
+	}
+}
+
+func (l *Limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.wakeLocked()
+}
+
+// wakeLocked wakes every call waiting for a slot to check again; l.mu must be held.
+func (l *Limiter) wakeLocked() {
+	close(l.freed)
+	l.freed = make(chan struct{})
+}
+
+// WithLimiter runs every call to the agent within limiter's limits.
+func WithLimiter(limiter *Limiter) AgentOption {
+	return func(a *AgentBase) { a.limiter = limiter }
+}
+
+// Limiter returns the limiter bounding the agent's calls, or nil when it has none.
+func (a *AgentBase) Limiter() *Limiter { return a.limiter }
+
+// limiterOf returns the limiter of a, or of the agent a router or ReAct agent wraps.
+func limiterOf(a Agent) *Limiter {
+	if la, ok := a.(interface{ Limiter() *Limiter }); ok {
+		return la.Limiter()
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"keystone/internal/providers"
+
+	"github.com/stretchr/testify/require"
+)
+
+// slowProvider answers after delay, or fails when ctx ends first unless it ignores ctx.
+// It tracks how many calls are in flight at once.
+type slowProvider struct {
+	delay     time.Duration
+	ignoreCtx bool
+	inFlight  int32
+	peak      int32
+}
+
+func (p *slowProvider) GenerateResponse(ctx context.Context, prompt, _ string) (string, error) {
+	n := atomic.AddInt32(&p.inFlight, 1)
+	defer atomic.AddInt32(&p.inFlight, -1)
+	for {
+		peak := atomic.LoadInt32(&p.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&p.peak, peak, n) {
+			break
+		}
+	}
+	if p.ignoreCtx {
+		time.Sleep(p.delay)
+		return "slow: " + prompt, nil
+	}
+	select {
+	case <-time.After(p.delay):
+		return "slow: " + prompt, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (p *slowProvider) UsageInfo() (providers.Usage, error) { return providers.Usage{}, nil }
+
+func TestLimits_Timeout(t *testing.T) {
+	m := NewManager()
+	for _, ignore := range []bool{false, true} {
+		p := &slowProvider{delay: 200 * time.Millisecond, ignoreCtx: ignore}
+		a := NewAgent("slow", "Slow", "", p, "m", "none", WithLimiter(m.Limiter("slow", Limits{Timeout: 20 * time.Millisecond})))
+
+		_, err := a.Handle(context.Background(), "hi", nil)
+		var te *TimeoutError
+		require.ErrorAs(t, err, &te)
+		require.EqualError(t, err, "agent slow timed out after 20ms")
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+	}
+
+	// A caller's own cancellation is not reported as the agent timing out.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a := NewAgent("slow", "Slow", "", &slowProvider{delay: time.Second}, "m", "none", WithLimiter(m.Limiter("slow", Limits{Timeout: time.Second})))
+	_, err := a.Handle(ctx, "hi", nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLimits_MaxConcurrency(t *testing.T) {
+	m := NewManager()
+	p := &slowProvider{delay: 20 * time.Millisecond}
+	a := NewAgent("busy", "Busy", "", p, "m", "none", WithLimiter(m.Limiter("busy", Limits{MaxConcurrency: 2})))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 6)
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := a.Handle(context.Background(), "hi", nil)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(&p.peak))
+}
+
+func TestLimits_TimeoutWaitingForSlot(t *testing.T) {
+	l := NewManager().Limiter("one", Limits{Timeout: 20 * time.Millisecond, MaxConcurrency: 1})
+	held, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := l.Do(context.Background(), func(context.Context) (string, error) {
+			close(held)
+			<-release
+			return "first", nil
+		})
+		done <- err
+	}()
+	<-held
+
+	_, err := l.Do(context.Background(), func(context.Context) (string, error) { return "second", nil })
+	require.EqualError(t, err, "agent one timed out after 20ms waiting for a free slot (max_concurrency 1)")
+	close(release)
+	<-done
+
+	// A nested call under a held slot does not wait for a second one.
+	out, err := l.Do(context.Background(), func(ctx context.Context) (string, error) {
+		return l.Do(ctx, func(context.Context) (string, error) { return "nested", nil })
+	})
+	require.NoError(t, err)
+	require.Equal(t, "nested", out)
+}
+
+func TestLimits_ManagerSharesLimiterAcrossReloads(t *testing.T) {
+	dir := t.TempDir()
+	write := func(body string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bot.yaml"), []byte(body), 0o644))
+	}
+	write("id: bot\nname: Bot\nprovider: mock\ntimeout: 1s\nmax_concurrency: 2\n")
+	m := NewManager()
+	w := NewWatcher(NewLoader(dir, nil), m)
+	_, err := w.Reload()
+	require.NoError(t, err)
+
+	bot, err := m.Get("bot")
+	require.NoError(t, err)
+	first := bot.(*AgentBase).Limiter()
+	require.Equal(t, Limits{Timeout: time.Second, MaxConcurrency: 2}, first.Limits())
+
+	write("id: bot\nname: Bot\nprovider: mock\ntimeout: 5s\nmax_concurrency: 2\n")
+	events, err := w.Reload()
+	require.NoError(t, err)
+	require.Equal(t, ReloadUpdated, events[0].Action)
+	bot, err = m.Get("bot")
+	require.NoError(t, err)
+	require.Same(t, first, bot.(*AgentBase).Limiter())
+	require.Equal(t, 5*time.Second, first.Limits().Timeout)
+}
+
+func TestLimits_Validate(t *testing.T) {
+	cfg := AgentConfig{ID: "bot", Name: "Bot", Provider: "mock", Timeout: "soon"}
+	require.ErrorContains(t, cfg.Validate(), `invalid timeout "soon"`)
+	cfg = AgentConfig{ID: "bot", Name: "Bot", Provider: "mock", Timeout: "-1s"}
+	require.ErrorContains(t, cfg.Validate(), "timeout must be positive")
+
+	v := newTestValidator(t)
+	doc := "id: bot\nname: Bot\nprovider: mock\ntimeout: 0s\nmax_concurrency: -1\n"
+	var got []string
+	for _, i := range v.Validate("bot.yaml", []byte(doc)) {
+		got = append(got, i.String())
+	}
+	require.Equal(t, []string{
+		`bot.yaml:4: error: timeout must be positive, got 0s`,
+		`bot.yaml:5: error: max_concurrency must not be negative`,
+	}, got)
+}
+
+func TestLimits_ReloadKeepsRunningCallsCounted(t *testing.T) {
+	m := NewManager()
+	l := m.Limiter("busy", Limits{MaxConcurrency: 2})
+	release := make(chan struct{})
+	var started, done sync.WaitGroup
+	hold := func() {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			_, err := l.Do(context.Background(), func(context.Context) (string, error) {
+				started.Done()
+				<-release
+				return "", nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+		started.Wait()
+	}
+	tryNow := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := l.Do(ctx, func(context.Context) (string, error) { return "", nil })
+		return err
+	}
+
+	hold()
+	hold()
+	// Raising the limit admits exactly the extra calls.
+	m.Limiter("busy", Limits{MaxConcurrency: 3})
+	hold()
+	require.ErrorIs(t, tryNow(), context.DeadlineExceeded)
+
+	// Lowering it keeps the three running calls counted, so nothing new runs yet.
+	m.Limiter("busy", Limits{MaxConcurrency: 1})
+	require.ErrorIs(t, tryNow(), context.DeadlineExceeded)
+	close(release)
+	done.Wait()
+	require.NoError(t, tryNow())
+}
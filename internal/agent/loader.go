@@ -0,0 +1,331 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"keystone/internal/guardrails"
+	"keystone/internal/logger"
+	"keystone/internal/prompt"
+	"keystone/internal/providers"
+	"keystone/internal/voting"
+)
+
+// Loader is the single path for turning a directory of agent YAML files into
+// registered agents. Files are discovered recursively (.yaml and .yml, skipping
+// hidden directories), extends and prompt references are resolved, providers
+// are looked up by name, and the first definition of an ID wins. ${env:...},
+// ${secret:...} and ${param:...} references are interpolated before validation.
+type Loader struct {
+	dir       string
+	providers map[string]providers.Provider
+	vars      Vars
+}
+
+// NewLoader creates a loader for dir. A nil provider map uses DefaultProviders.
+func NewLoader(dir string, providersMap map[string]providers.Provider) *Loader {
+	if dir == "" {
+		dir = DefaultAgentsDir
+	}
+	if providersMap == nil {
+		providersMap = DefaultProviders()
+	}
+	return &Loader{dir: dir, providers: providersMap}
+}
+
+// WithSecrets sets the values ${secret:name} references resolve to, normally the
+// secrets section of the keystone config, and returns the loader.
+func (l *Loader) WithSecrets(secrets map[string]string) *Loader {
+	l.vars.Secrets = secrets
+	return l
+}
+
+// Dir returns the directory the loader reads from.
+func (l *Loader) Dir() string { return l.dir }
+
+// LoadResult records what happened to one agent config during a load.
+type LoadResult struct {
+	ID     string `json:"id,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// LoadReport summarizes a load: agents registered, skipped as duplicates, and failures.
+type LoadReport struct {
+	Dir     string       `json:"dir"`
+	Loaded  []LoadResult `json:"loaded"`
+	Skipped []LoadResult `json:"skipped,omitempty"`
+	Errors  []LoadResult `json:"errors,omitempty"`
+}
+
+// Err aggregates the report's failures, or returns nil when every file loaded or was skipped.
+func (r *LoadReport) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(r.Errors))
+	for i, e := range r.Errors {
+		msgs[i] = e.Reason
+	}
+	return fmt.Errorf("errors occurred while loading agents: %s", strings.Join(msgs, "; "))
+}
+
+// Summary returns a one-line description of the report.
+func (r *LoadReport) Summary() string {
+	return fmt.Sprintf("Loaded %d agent(s) from %s (%d skipped, %d error(s))", len(r.Loaded), r.Dir, len(r.Skipped), len(r.Errors))
+}
+
+func (r *LoadReport) fail(path, id string, err error) {
+	logger.Error(err.Error(), false)
+	r.Errors = append(r.Errors, LoadResult{ID: id, Path: path, Reason: err.Error()})
+}
+
+func (r *LoadReport) skip(path, id, reason string) {
+	logger.Warn(fmt.Sprintf("Skipping agent %s (%s): %s", id, path, reason), false)
+	r.Skipped = append(r.Skipped, LoadResult{ID: id, Path: path, Reason: reason})
+}
+
+// Load registers every agent found under the loader's directory with manager.
+// Per-file problems are recorded in the report; the error is reserved for a directory that cannot be read.
+func (l *Loader) Load(manager *AgentManager) (*LoadReport, error) {
+	report := &LoadReport{Dir: l.dir, Loaded: []LoadResult{}}
+	configs, err := l.resolveDir(report)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rc := range configs {
+		id := rc.Config.ID
+		if _, err := manager.Get(id); err == nil {
+			report.skip(rc.Path, id, "agent ID already registered")
+			continue
+		}
+		a, err := l.newAgent(rc, manager)
+		if err != nil {
+			report.fail(rc.Path, id, err)
+			continue
+		}
+		if err := manager.Register(a); err != nil {
+			report.fail(rc.Path, id, fmt.Errorf("failed to register agent %s: %w", id, err))
+			continue
+		}
+		report.Loaded = append(report.Loaded, LoadResult{ID: id, Path: rc.Path})
+		logger.Info(fmt.Sprintf("Loaded agent: %-20s (%s)", a.Name(), id), false)
+	}
+	return report, nil
+}
+
+// LoadAgent finds the config for agentID anywhere under the directory and registers it.
+func (l *Loader) LoadAgent(manager *AgentManager, agentID string) error {
+	if agentID == "" {
+		return fmt.Errorf("agentID required")
+	}
+	files, _, err := readConfigDir(l.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error walking agent config dir: %w", err)
+	}
+	index := NewConfigIndex(files)
+	f, ok := index[agentID]
+	if !ok {
+		return fmt.Errorf("agent config not found: %s in %s", agentID, l.dir)
+	}
+
+	lib, err := prompt.LoadLibrary(PromptsDir(l.dir))
+	if err != nil {
+		return fmt.Errorf("error loading prompt library: %w", err)
+	}
+	rc, err := resolveFile(f, index, lib, l.vars)
+	if err != nil {
+		return err
+	}
+	a, err := l.newAgent(rc, manager)
+	if err != nil {
+		return err
+	}
+	return manager.Register(a)
+}
+
+// resolvedConfig is an agent config after extends, prompt references and defaults are applied.
+type resolvedConfig struct {
+	Path   string
+	Config AgentConfig
+}
+
+// resolveDir reads and resolves every config under the directory. Files that fail are
+// recorded in report, and later definitions of an ID already seen are skipped.
+// A missing directory resolves to no configs.
+func (l *Loader) resolveDir(report *LoadReport) ([]resolvedConfig, error) {
+	info, err := os.Stat(l.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Warn(fmt.Sprintf("Agent config directory does not exist: %s", l.dir), false)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to access agent config dir %s: %w", l.dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("agents path %q is not a directory", l.dir)
+	}
+
+	files, readErrs, err := readConfigDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error walking agent config dir: %w", err)
+	}
+	for _, e := range readErrs {
+		report.fail(e.Path, "", errors.New(e.Reason))
+	}
+
+	lib, err := prompt.LoadLibrary(PromptsDir(l.dir))
+	if err != nil {
+		report.fail("", "", fmt.Errorf("error loading prompt library: %w", err))
+	}
+
+	index := NewConfigIndex(files)
+	var configs []resolvedConfig
+	for _, f := range files {
+		id := f.Config.ID
+		if first, ok := index[id]; ok && first.Path != f.Path {
+			report.skip(f.Path, id, fmt.Sprintf("duplicate agent ID (already defined in %s)", first.Path))
+			continue
+		}
+		rc, err := resolveFile(f, index, lib, l.vars)
+		if err != nil {
+			report.fail(f.Path, id, err)
+			continue
+		}
+		if _, ok := l.providers[rc.Config.Provider]; !ok {
+			report.fail(f.Path, id, fmt.Errorf("agent %s in %s: unknown provider: %s", id, f.Path, rc.Config.Provider))
+			continue
+		}
+		configs = append(configs, rc)
+	}
+	return configs, nil
+}
+
+// resolveFile applies extends, the prompt reference, interpolation and validation
+// defaults to a config file. Secret values it substitutes are redacted from logs.
+func resolveFile(f ConfigFile, index ConfigIndex, lib *prompt.Library, vars Vars) (resolvedConfig, error) {
+	cfg, err := index.Resolve(f.Config)
+	if err != nil {
+		return resolvedConfig{}, fmt.Errorf("error resolving extends for %s: %w", f.Path, err)
+	}
+	if err := cfg.ResolvePrompt(lib); err != nil {
+		return resolvedConfig{}, fmt.Errorf("error resolving prompt for %s: %w", f.Path, err)
+	}
+	secrets, missing := vars.Interpolate(&cfg)
+	logger.Redact(secrets...)
+	if err := MissingRefsError(missing); err != nil {
+		return resolvedConfig{}, fmt.Errorf("agent %s in %s: %w", cfg.ID, f.Path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return resolvedConfig{}, fmt.Errorf("invalid agent config %s: %w", f.Path, err)
+	}
+	return resolvedConfig{Path: f.Path, Config: cfg}, nil
+}
+
+// newAgent builds an agent from a resolved config using the loader's providers.
+// Routers dispatch to, and tools and scorers are looked up in, the agents registered in manager,
+// which also holds the limiter enforcing the agent's timeout and concurrency limits.
+func (l *Loader) newAgent(rc resolvedConfig, manager *AgentManager) (Agent, error) {
+	cfg := rc.Config
+	provider, ok := l.providers[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("agent %s in %s: unknown provider: %s", cfg.ID, rc.Path, cfg.Provider)
+	}
+	opts := configOptions(cfg)
+	if len(cfg.Tools) > 0 {
+		opts = append(opts, WithTools(manager, cfg.MaxToolCalls, cfg.Tools...))
+	}
+	if !cfg.Guardrails.IsZero() {
+		var classify guardrails.Classifier
+		if cfg.Guardrails.Classifier != "" {
+			classify = topicClassifier(manager, cfg.Guardrails.Classifier)
+		}
+		guard, err := guardrails.New(cfg.ID, cfg.Guardrails, classify)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s in %s: %w", cfg.ID, rc.Path, err)
+		}
+		opts = append(opts, WithGuardrails(guard))
+	}
+	if !cfg.Voting.IsZero() {
+		var score voting.Scorer
+		if cfg.Voting.Scorer != "" {
+			score = VoteScorer(manager, cfg.Voting.Scorer)
+		}
+		opts = append(opts, WithVoting(cfg.Voting, score))
+	}
+	limits, err := cfg.Limits()
+	if err != nil {
+		return nil, fmt.Errorf("agent %s in %s: %w", cfg.ID, rc.Path, err)
+	}
+	if !limits.IsZero() {
+		opts = append(opts, WithLimiter(manager.Limiter(cfg.ID, limits)))
+	}
+	a := NewAgent(cfg.ID, cfg.Name, cfg.Description, provider, cfg.Model, cfg.Memory, opts...)
+	switch cfg.Kind {
+	case KindRouter:
+		return NewRouter(a, cfg.Router, manager), nil
+	case KindReAct:
+		r, err := NewReAct(a, cfg.ReAct)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s in %s: %w", cfg.ID, rc.Path, err)
+		}
+		return r, nil
+	}
+	return a, nil
+}
+
+// isConfigFile reports whether path has an agent config extension.
+func isConfigFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// discoverConfigFiles returns every agent config file under dir in lexical order.
+// Hidden directories such as .git are skipped.
+func discoverConfigFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isConfigFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// readConfigDir parses every agent config file under dir. Files that fail to parse
+// are returned separately so callers can report them without stopping.
+func readConfigDir(dir string) ([]ConfigFile, []LoadResult, error) {
+	paths, err := discoverConfigFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		files  []ConfigFile
+		failed []LoadResult
+	)
+	for _, path := range paths {
+		cfg, err := ReadConfigFile(path)
+		if err != nil {
+			failed = append(failed, LoadResult{Path: path, Reason: fmt.Sprintf("error parsing agent config %s: %v", path, err)})
+			continue
+		}
+		files = append(files, ConfigFile{Path: path, Config: cfg})
+	}
+	return files, failed, nil
+}
@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"keystone/internal/providers"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_LoadReport(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "team"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".hidden"), 0o755))
+
+	WriteTempAgentConfig(t, dir, "a1", "mock")
+	WriteYAML(t, filepath.Join(dir, "team", "a2.yml"), AgentConfig{ID: "a2", Name: "A2", Provider: "mock"})
+	WriteYAML(t, filepath.Join(dir, "team", "z_dup.yaml"), AgentConfig{ID: "a1", Name: "Copy", Provider: "mock"})
+	WriteYAML(t, filepath.Join(dir, ".hidden", "h.yaml"), AgentConfig{ID: "hidden", Name: "H", Provider: "mock"})
+	WriteTempAgentConfig(t, dir, "remote", "openai")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("{ bad_yaml"), 0o644))
+
+	manager := NewManager()
+	report, err := NewLoader(dir, map[string]providers.Provider{"mock": &MockProvider{}}).Load(manager)
+	require.NoError(t, err)
+
+	var loaded []string
+	for _, r := range report.Loaded {
+		loaded = append(loaded, r.ID)
+	}
+	require.ElementsMatch(t, []string{"a1", "a2"}, loaded)
+	require.Len(t, report.Skipped, 1)
+	require.Equal(t, filepath.Join(dir, "team", "z_dup.yaml"), report.Skipped[0].Path)
+	require.Len(t, report.Errors, 2)
+	require.Error(t, report.Err())
+	require.Contains(t, report.Summary(), "Loaded 2 agent(s)")
+
+	_, err = manager.Get("hidden")
+	require.Error(t, err, "hidden directories are not scanned")
+}
+
+func TestLoader_MissingDirAndLoadAgent(t *testing.T) {
+	report, err := NewLoader(filepath.Join(t.TempDir(), "nope"), nil).Load(NewManager())
+	require.NoError(t, err)
+	require.Empty(t, report.Loaded)
+	require.NoError(t, report.Err())
+
+	dir := t.TempDir()
+	WriteTempAgentConfig(t, dir, "solo", "venice")
+	manager := NewManager()
+	require.NoError(t, NewLoader(dir, nil).LoadAgent(manager, "solo"))
+	require.ErrorContains(t, NewLoader(dir, nil).LoadAgent(manager, "ghost"), "agent config not found")
+}
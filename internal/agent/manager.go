@@ -6,10 +6,12 @@ import (
 	"sync"
 )
 
-// AgentManager manages a thread-safe collection of Agents.
+// AgentManager manages a thread-safe collection of Agents and the limiters that
+// bound how long and how many times at once each of them runs.
 type AgentManager struct {
-	agents map[string]Agent
-	mu     sync.RWMutex
+	agents   map[string]Agent
+	limiters map[string]*Limiter
+	mu       sync.RWMutex
 }
 
 // NewManager creates and returns a new AgentManager.
@@ -28,6 +30,14 @@ func (m *AgentManager) Register(a Agent) error {
 	return nil
 }
 
+// Replace registers a, swapping out any existing agent with the same ID.
+// Calls already running on the previous agent keep using it until they return.
+func (m *AgentManager) Replace(a Agent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.agents[a.ID()] = a
+}
+
 // Get retrieves an agent by ID.
 func (m *AgentManager) Get(id string) (Agent, error) {
 	m.mu.RLock()
@@ -0,0 +1,80 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"keystone/internal/providers"
+)
+
+// Example is a sample exchange shown to the model ahead of every input.
+type Example struct {
+	Input  string `yaml:"input" json:"input"`
+	Output string `yaml:"output" json:"output"`
+}
+
+// WithSystemPrompt sets instructions that precede every input the agent sends.
+func WithSystemPrompt(text string) AgentOption {
+	return func(a *AgentBase) { a.systemPrompt = text }
+}
+
+// WithExamples sets few-shot examples that precede every input, after the system prompt.
+func WithExamples(examples ...Example) AgentOption {
+	return func(a *AgentBase) { a.examples = examples }
+}
+
+// SystemPrompt returns the agent's system prompt.
+func (a *AgentBase) SystemPrompt() string { return a.systemPrompt }
+
+// Examples returns the agent's few-shot examples.
+func (a *AgentBase) Examples() []Example { return a.examples }
+
+// messages returns the chat request for prompt: the system prompt, each example
+// as a user turn answered by the assistant, then prompt itself.
+func (a *AgentBase) messages(prompt string) []providers.Message {
+	msgs := make([]providers.Message, 0, 2*len(a.examples)+2)
+	if a.systemPrompt != "" {
+		msgs = append(msgs, providers.Message{Role: providers.RoleSystem, Content: a.systemPrompt})
+	}
+	for _, ex := range a.examples {
+		msgs = append(msgs,
+			providers.Message{Role: providers.RoleUser, Content: ex.Input},
+			providers.Message{Role: providers.RoleAssistant, Content: ex.Output})
+	}
+	return append(msgs, providers.Message{Role: providers.RoleUser, Content: prompt})
+}
+
+// inlineMessages renders a chat request as one prompt for providers without chat
+// support: the system prompt, then the examples as a block, then the final message.
+func inlineMessages(msgs []providers.Message) string {
+	var b strings.Builder
+	last := len(msgs) - 1
+	examples := false
+	for _, m := range msgs[:last] {
+		switch m.Role {
+		case providers.RoleSystem:
+			fmt.Fprintf(&b, "%s\n\n", m.Content)
+		case providers.RoleUser:
+			if !examples {
+				b.WriteString("Examples:\n")
+				examples = true
+			}
+			fmt.Fprintf(&b, "Input: %s\n", m.Content)
+		case providers.RoleAssistant:
+			fmt.Fprintf(&b, "Output: %s\n\n", m.Content)
+		}
+	}
+	b.WriteString(msgs[last].Content)
+	return b.String()
+}
+
+// validateExamples rejects examples missing an input or an output.
+func validateExamples(examples []Example) error {
+	for i, ex := range examples {
+		if strings.TrimSpace(ex.Input) == "" || strings.TrimSpace(ex.Output) == "" {
+			return fmt.Errorf("examples[%d] needs both an input and an output", i)
+		}
+	}
+	return nil
+}
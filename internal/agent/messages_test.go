@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"keystone/internal/providers"
+
+	"github.com/stretchr/testify/require"
+)
+
+// chatRecorder is a chat provider that remembers the last conversation it was sent.
+type chatRecorder struct {
+	MockProvider
+	messages []providers.Message
+}
+
+func (c *chatRecorder) Chat(_ context.Context, messages []providers.Message, _ string) (string, error) {
+	c.messages = messages
+	return "chat response", nil
+}
+
+func TestAgentBase_SystemPromptAndExamples(t *testing.T) {
+	examples := []Example{{Input: "2+2", Output: "4"}, {Input: "3+3", Output: "6"}}
+	opts := []AgentOption{WithSystemPrompt("Answer with a number."), WithExamples(examples...)}
+
+	chat := &chatRecorder{}
+	resp, err := NewAgent("calc", "Calc", "", chat, "m", "none", opts...).Handle(context.Background(), "4+4", nil)
+	require.NoError(t, err)
+	require.Equal(t, "chat response", resp)
+	require.Equal(t, []providers.Message{
+		{Role: providers.RoleSystem, Content: "Answer with a number."},
+		{Role: providers.RoleUser, Content: "2+2"},
+		{Role: providers.RoleAssistant, Content: "4"},
+		{Role: providers.RoleUser, Content: "3+3"},
+		{Role: providers.RoleAssistant, Content: "6"},
+		{Role: providers.RoleUser, Content: "4+4"},
+	}, chat.messages)
+
+	var chunks []string
+	resp, err = NewAgent("calc", "Calc", "", &MockProvider{}, "m", "none", opts...).(*AgentBase).
+		HandleStream(context.Background(), "4+4", nil, func(c string) { chunks = append(chunks, c) })
+	require.NoError(t, err)
+	require.Equal(t, "mock response: Answer with a number.\n\nExamples:\nInput: 2+2\nOutput: 4\n\nInput: 3+3\nOutput: 6\n\n4+4", resp)
+	require.Equal(t, []string{resp}, chunks)
+
+	resp, err = NewAgent("plain", "Plain", "", chat, "m", "none").Handle(context.Background(), "hi", nil)
+	require.NoError(t, err)
+	require.Equal(t, "mock response: hi", resp, "agents without a system prompt or examples send a plain prompt")
+}
+
+func TestAgentConfig_ValidateExamples(t *testing.T) {
+	cfg := AgentConfig{ID: "a", Name: "A", Provider: "mock", Examples: []Example{{Input: "hi", Output: "hello"}, {Input: "bye"}}}
+	require.ErrorContains(t, cfg.Validate(), "examples[1] needs both an input and an output")
+
+	cfg = AgentConfig{ID: "r", Name: "R", Provider: "mock", Kind: KindRouter, SystemPrompt: "Route well.",
+		Router: RouterConfig{Strategy: "keyword", Routes: []Route{{Agent: "a", Keywords: []string{"x"}}}}}
+	require.ErrorContains(t, cfg.Validate(), "routers do not use system_prompt or examples")
+
+	base := AgentConfig{SystemPrompt: "base", Examples: []Example{{Input: "a", Output: "b"}}}
+	base.Merge(AgentConfig{SystemPrompt: "child"})
+	require.Equal(t, "child", base.SystemPrompt)
+	require.Len(t, base.Examples, 1, "examples are inherited unless overridden")
+}
+
+func TestLoader_SystemPromptAndExamples(t *testing.T) {
+	dir := t.TempDir()
+	writeAgentFile(t, dir, "tutor.yaml", `id: tutor
+name: Tutor
+provider: mock
+system_prompt: You are a patient tutor.
+examples:
+  - input: What is 2+2?
+    output: "4"
+`)
+	m := NewManager()
+	report, err := NewLoader(dir, nil).Load(m)
+	require.NoError(t, err)
+	require.NoError(t, report.Err())
+
+	a, err := m.Get("tutor")
+	require.NoError(t, err)
+	require.Equal(t, "You are a patient tutor.", a.(*AgentBase).SystemPrompt())
+	require.Equal(t, []Example{{Input: "What is 2+2?", Output: "4"}}, a.(*AgentBase).Examples())
+}
+
+func TestValidator_Examples(t *testing.T) {
+	v := newTestValidator(t)
+	doc := `id: tutor
+name: Tutor
+provider: mock
+examples:
+  - input: hi
+    ouput: hello
+`
+	var got []string
+	for _, i := range v.Validate("tutor.yaml", []byte(doc)) {
+		got = append(got, i.String())
+	}
+	require.Equal(t, []string{
+		`tutor.yaml:4: error: examples[0] needs both an input and an output`,
+		`tutor.yaml:6: error: unknown key "examples.ouput"`,
+	}, got)
+}
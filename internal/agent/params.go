@@ -0,0 +1,358 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"keystone/internal/prompt"
+	"keystone/internal/tickets"
+)
+
+// ParamType is the declared type of an agent parameter.
+type ParamType string
+
+const (
+	ParamString ParamType = "string"
+	ParamInt    ParamType = "int"
+	ParamFloat  ParamType = "float"
+	ParamBool   ParamType = "bool"
+	ParamEnum   ParamType = "enum"
+	ParamList   ParamType = "list"
+)
+
+var paramTypes = []ParamType{ParamString, ParamInt, ParamFloat, ParamBool, ParamEnum, ParamList}
+
+// ParamSpec declares one agent parameter. Values stay strings, as in AgentConfig.Parameters;
+// lists are comma-separated. Min and Max bound numbers, and the length of strings and lists.
+// Values lists the choices for an enum, or the allowed items of a list.
+type ParamSpec struct {
+	Type        ParamType `yaml:"type,omitempty" json:"type,omitempty"`
+	Description string    `yaml:"description,omitempty" json:"description,omitempty"`
+	Default     string    `yaml:"default,omitempty" json:"default,omitempty"`
+	Required    bool      `yaml:"required,omitempty" json:"required,omitempty"`
+	Min         *float64  `yaml:"min,omitempty" json:"min,omitempty"`
+	Max         *float64  `yaml:"max,omitempty" json:"max,omitempty"`
+	Values      []string  `yaml:"values,omitempty" json:"values,omitempty"`
+}
+
+// ParamSchema maps parameter names to their specs.
+type ParamSchema map[string]ParamSpec
+
+// ParameterizedAgent is implemented by agents that declare a parameter schema.
+type ParameterizedAgent interface {
+	Agent
+	ParameterSchema() ParamSchema
+}
+
+// kind returns the spec's type, treating an empty type as string.
+func (s ParamSpec) kind() ParamType {
+	if s.Type == "" {
+		return ParamString
+	}
+	return s.Type
+}
+
+// Validate checks that the spec is well formed and that its default satisfies it.
+func (s ParamSpec) Validate() error {
+	known := false
+	for _, t := range paramTypes {
+		known = known || s.kind() == t
+	}
+	if !known {
+		return fmt.Errorf("unknown type %q", s.Type)
+	}
+	if s.kind() == ParamEnum && len(s.Values) == 0 {
+		return fmt.Errorf("enum must list its values")
+	}
+	if s.kind() == ParamBool && (s.Min != nil || s.Max != nil) {
+		return fmt.Errorf("min and max do not apply to bool")
+	}
+	if s.Min != nil && s.Max != nil && *s.Min > *s.Max {
+		return fmt.Errorf("min %v is greater than max %v", *s.Min, *s.Max)
+	}
+	if s.Default != "" {
+		if _, err := s.Normalize(s.Default); err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+	}
+	return nil
+}
+
+// Normalize checks value against the spec and returns its canonical string form.
+func (s ParamSpec) Normalize(value string) (string, error) {
+	switch s.kind() {
+	case ParamInt:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not an int", value)
+		}
+		if err := s.checkRange(float64(n), "value"); err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(n, 10), nil
+	case ParamFloat:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("%q is not a float", value)
+		}
+		if err := s.checkRange(f, "value"); err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case ParamBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("%q is not a bool", value)
+		}
+		return strconv.FormatBool(b), nil
+	case ParamEnum:
+		if !s.allows(value) {
+			return "", fmt.Errorf("%q is not one of %s", value, strings.Join(s.Values, ", "))
+		}
+		return value, nil
+	case ParamList:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			if len(s.Values) > 0 && !s.allows(item) {
+				return "", fmt.Errorf("list item %q is not one of %s", item, strings.Join(s.Values, ", "))
+			}
+			items = append(items, item)
+		}
+		if err := s.checkRange(float64(len(items)), "list length"); err != nil {
+			return "", err
+		}
+		return strings.Join(items, ","), nil
+	default:
+		if err := s.checkRange(float64(len(value)), "length"); err != nil {
+			return "", err
+		}
+		return value, nil
+	}
+}
+
+func (s ParamSpec) checkRange(v float64, what string) error {
+	if s.Min != nil && v < *s.Min {
+		return fmt.Errorf("%s %v is below the minimum %v", what, v, *s.Min)
+	}
+	if s.Max != nil && v > *s.Max {
+		return fmt.Errorf("%s %v is above the maximum %v", what, v, *s.Max)
+	}
+	return nil
+}
+
+func (s ParamSpec) allows(v string) bool {
+	for _, allowed := range s.Values {
+		if v == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the declared parameter names in sorted order.
+func (s ParamSchema) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks every spec in the schema.
+func (s ParamSchema) Validate() error {
+	var errs []string
+	for _, name := range s.Names() {
+		if err := s[name].Validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("parameter %s: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid parameter schema: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Check verifies the values that are present in params. Parameters the schema does not
+// declare are rejected, so a mistyped override fails instead of being silently ignored.
+func (s ParamSchema) Check(params map[string]string) error {
+	_, err := s.apply(params, false)
+	return err
+}
+
+// Apply returns params with defaults filled in and values normalized, or an error
+// listing every missing, undeclared or invalid parameter. An empty schema accepts anything.
+func (s ParamSchema) Apply(params map[string]string) (map[string]string, error) {
+	return s.apply(params, true)
+}
+
+func (s ParamSchema) apply(params map[string]string, complete bool) (map[string]string, error) {
+	out := make(map[string]string, len(params)+len(s))
+	for k, v := range params {
+		out[k] = v
+	}
+	if len(s) == 0 {
+		return out, nil
+	}
+
+	var errs []string
+	for _, name := range sortedParamNames(params) {
+		if _, ok := s[name]; !ok {
+			errs = append(errs, fmt.Sprintf("unknown parameter %q", name))
+		}
+	}
+	for _, name := range s.Names() {
+		spec := s[name]
+		value, ok := out[name]
+		if !ok && complete && spec.Default != "" {
+			value, ok = spec.Default, true
+		}
+		if !ok {
+			if complete && spec.Required {
+				errs = append(errs, fmt.Sprintf("missing required parameter %q", name))
+			}
+			continue
+		}
+		norm, err := spec.Normalize(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("parameter %s: %v", name, err))
+			continue
+		}
+		out[name] = norm
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid parameters: %s", strings.Join(errs, "; "))
+	}
+	return out, nil
+}
+
+// MergeParams layers overrides over the agent's configured parameters and, when the
+// agent declares a schema, fills defaults and validates the result.
+func MergeParams(a Agent, overrides map[string]string) (map[string]string, error) {
+	params := make(map[string]string, len(a.Parameters())+len(overrides))
+	for k, v := range a.Parameters() {
+		params[k] = v
+	}
+	for k, v := range overrides {
+		params[k] = v
+	}
+	pa, ok := a.(ParameterizedAgent)
+	if !ok {
+		return params, nil
+	}
+	out, err := pa.ParameterSchema().Apply(params)
+	if err != nil {
+		return nil, fmt.Errorf("agent %s: %w", a.ID(), err)
+	}
+	return out, nil
+}
+
+// PromptVars are what a caller supplies, besides the input, to render an agent's prompt template.
+type PromptVars struct {
+	Params   map[string]string // overrides merged into the agent's parameters
+	Previous string            // output of the previous workflow step
+	Template string            // replaces the agent's own template when set
+}
+
+// promptRenderer is implemented by agents that render their own prompt template, so
+// that they can fit it around their conversation memory and remember the input as given.
+type promptRenderer interface {
+	renderPrompt(ctx context.Context, input string, t *tickets.Ticket) (string, error)
+}
+
+type promptKey struct{ agent string }
+
+// PrepareInput readies input for a run of a the way a run from the CLI or a workflow
+// step does, and returns the context and input to call a with. Agents that render
+// their own prompt template, as AgentBase does, get input as given and vars on the
+// context; others get the rendered prompt. Either way invalid parameters fail here.
+func PrepareInput(ctx context.Context, a Agent, input string, vars PromptVars, t *tickets.Ticket) (context.Context, string, error) {
+	if _, ok := a.(promptRenderer); !ok {
+		out, err := buildPrompt(a, input, vars, t)
+		return ctx, out, err
+	}
+	if _, err := MergeParams(a, vars.Params); err != nil {
+		return ctx, "", err
+	}
+	return context.WithValue(ctx, promptKey{a.ID()}, vars), input, nil
+}
+
+// promptVars returns the values set by PrepareInput for the agent with the given ID.
+func promptVars(ctx context.Context, id string) PromptVars {
+	vars, _ := ctx.Value(promptKey{id}).(PromptVars)
+	return vars
+}
+
+// buildPrompt merges vars.Params into a's parameters and renders its prompt template,
+// or vars.Template, around input.
+func buildPrompt(a Agent, input string, vars PromptVars, t *tickets.Ticket) (string, error) {
+	params, err := MergeParams(a, vars.Params)
+	if err != nil {
+		return "", err
+	}
+	text := a.PromptTemplate()
+	if vars.Template != "" {
+		text = vars.Template
+	}
+	out, err := prompt.Build(text, prompt.NewData(a.ID(), input, vars.Previous, params, t))
+	if err != nil {
+		return "", fmt.Errorf("agent %s prompt: %w", a.ID(), err)
+	}
+	return out, nil
+}
+
+// ParamsFromJSON converts decoded JSON parameter values to the string form agents
+// store them in: numbers and booleans are formatted, arrays joined with commas.
+func ParamsFromJSON(raw map[string]interface{}) (map[string]string, error) {
+	params := make(map[string]string, len(raw))
+	for k, v := range raw {
+		s, err := paramString(v)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", k, err)
+		}
+		params[k] = s
+	}
+	return params, nil
+}
+
+func paramString(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case nil:
+		return "", nil
+	case []interface{}:
+		items := make([]string, len(val))
+		for i, item := range val {
+			s, err := paramString(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+func sortedParamNames(params map[string]string) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
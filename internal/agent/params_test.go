@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestParamSpec_Normalize(t *testing.T) {
+	cases := []struct {
+		spec    ParamSpec
+		in      string
+		want    string
+		wantErr string
+	}{
+		{ParamSpec{}, "anything", "anything", ""},
+		{ParamSpec{Type: ParamString, Max: float(3)}, "long", "", "length 4 is above the maximum 3"},
+		{ParamSpec{Type: ParamInt}, " 42 ", "42", ""},
+		{ParamSpec{Type: ParamInt}, "4.2", "", "not an int"},
+		{ParamSpec{Type: ParamInt, Min: float(1), Max: float(10)}, "0", "", "below the minimum 1"},
+		{ParamSpec{Type: ParamFloat, Max: float(1)}, "0.50", "0.5", ""},
+		{ParamSpec{Type: ParamFloat}, "NaN", "", "not a float"},
+		{ParamSpec{Type: ParamBool}, "TRUE", "true", ""},
+		{ParamSpec{Type: ParamBool}, "yes", "", "not a bool"},
+		{ParamSpec{Type: ParamEnum, Values: []string{"short", "long"}}, "long", "long", ""},
+		{ParamSpec{Type: ParamEnum, Values: []string{"short", "long"}}, "medium", "", "not one of short, long"},
+		{ParamSpec{Type: ParamList}, "a, b,,c", "a,b,c", ""},
+		{ParamSpec{Type: ParamList, Values: []string{"a", "b"}}, "a,z", "", `list item "z"`},
+		{ParamSpec{Type: ParamList, Max: float(2)}, "a,b,c", "", "list length 3 is above the maximum 2"},
+	}
+	for _, c := range cases {
+		got, err := c.spec.Normalize(c.in)
+		if c.wantErr != "" {
+			require.ErrorContains(t, err, c.wantErr, "%+v %q", c.spec, c.in)
+			continue
+		}
+		require.NoError(t, err, "%+v %q", c.spec, c.in)
+		require.Equal(t, c.want, got)
+	}
+}
+
+func TestParamSpec_Validate(t *testing.T) {
+	require.NoError(t, ParamSpec{Type: ParamInt, Default: "5", Min: float(1)}.Validate())
+	require.ErrorContains(t, ParamSpec{Type: "number"}.Validate(), `unknown type "number"`)
+	require.ErrorContains(t, ParamSpec{Type: ParamEnum}.Validate(), "enum must list its values")
+	require.ErrorContains(t, ParamSpec{Type: ParamInt, Min: float(5), Max: float(1)}.Validate(), "greater than max")
+	require.ErrorContains(t, ParamSpec{Type: ParamInt, Default: "x"}.Validate(), "invalid default")
+}
+
+func TestParamSchema_Apply(t *testing.T) {
+	schema := ParamSchema{
+		"query": {Required: true},
+		"limit": {Type: ParamInt, Default: "10", Max: float(50)},
+		"fast":  {Type: ParamBool},
+	}
+
+	out, err := schema.Apply(map[string]string{"query": "go", "fast": "1"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"query": "go", "limit": "10", "fast": "true"}, out)
+
+	_, err = schema.Apply(map[string]string{"limit": "99", "colour": "red"})
+	require.EqualError(t, err, `invalid parameters: unknown parameter "colour"; parameter limit: value 99 is above the maximum 50; missing required parameter "query"`)
+
+	// Check only looks at the values present, so config files may leave required parameters to callers.
+	require.NoError(t, schema.Check(map[string]string{"limit": "5"}))
+
+	out, err = ParamSchema(nil).Apply(map[string]string{"free": "form"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"free": "form"}, out)
+}
+
+func TestMergeParams(t *testing.T) {
+	params := map[string]string{"limit": "5"}
+	a := NewAgent("typed", "Typed", "", &MockProvider{}, "m", "none",
+		WithParameters(params),
+		WithParameterSchema(ParamSchema{"limit": {Type: ParamInt}, "mode": {Type: ParamEnum, Values: []string{"a", "b"}, Default: "a"}}))
+
+	out, err := MergeParams(a, map[string]string{"limit": "7"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"limit": "7", "mode": "a"}, out)
+	require.Equal(t, "5", params["limit"], "overrides must not leak into the agent's parameters")
+
+	_, err = MergeParams(a, map[string]string{"mode": "c"})
+	require.ErrorContains(t, err, "agent typed: invalid parameters")
+
+	plain := NewAgent("plain", "Plain", "", &MockProvider{}, "m", "none")
+	out, err = MergeParams(plain, map[string]string{"anything": "goes"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"anything": "goes"}, out)
+}
+
+func TestAgentConfig_ValidateParamSchema(t *testing.T) {
+	cfg := AgentConfig{ID: "a", Name: "A", Provider: "mock",
+		Parameters:  map[string]string{"limit": "lots"},
+		ParamSchema: ParamSchema{"limit": {Type: ParamInt}},
+	}
+	require.ErrorContains(t, cfg.Validate(), `parameter limit: "lots" is not an int`)
+
+	cfg.Parameters["limit"] = "3"
+	require.NoError(t, cfg.Validate())
+
+	// Schemas are inherited key by key through extends.
+	idx := NewConfigIndex([]ConfigFile{{Path: "base.yaml", Config: cfg}})
+	child, err := idx.Resolve(AgentConfig{ID: "b", Extends: "a", ParamSchema: ParamSchema{"mode": {Type: ParamBool}}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"limit", "mode"}, child.ParamSchema.Names())
+}
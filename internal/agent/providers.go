@@ -0,0 +1,27 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"sort"
+
+	"keystone/internal/providers"
+	"keystone/internal/providers/venice"
+)
+
+// DefaultProviders returns the built-in providers keyed by the name used in agent YAML.
+func DefaultProviders() map[string]providers.Provider {
+	return map[string]providers.Provider{
+		"venice": venice.New("MOCK_API_KEY", ""),
+		"mock":   &MockProvider{},
+	}
+}
+
+// providerNames returns the sorted names of ps.
+func providerNames(ps map[string]providers.Provider) []string {
+	names := make([]string, 0, len(ps))
+	for name := range ps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,272 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"keystone/internal/logger"
+	"keystone/internal/tickets"
+	"keystone/internal/toolbox"
+)
+
+// KindReAct marks an agent config as an autonomous ReAct agent; see ReActConfig.
+const KindReAct = "react"
+
+// ReActConfig configures a ReAct agent. Tools names the built-in tools it may use
+// (see toolbox.Names). AllowedDir, resolved against the working directory, is the
+// only directory read_file and list_dir can see; HTTPAllowlist holds the hosts
+// http_get may fetch.
+type ReActConfig struct {
+	Tools         []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	AllowedDir    string   `yaml:"allowed_dir,omitempty" json:"allowed_dir,omitempty"`
+	HTTPAllowlist []string `yaml:"http_allowlist,omitempty" json:"http_allowlist,omitempty"`
+}
+
+// IsZero reports whether no ReAct settings are present.
+func (c ReActConfig) IsZero() bool {
+	return len(c.Tools) == 0 && c.AllowedDir == "" && len(c.HTTPAllowlist) == 0
+}
+
+// Validate checks the ReAct settings.
+func (c ReActConfig) Validate() error {
+	if len(c.Tools) == 0 {
+		return fmt.Errorf("react agents need at least one tool (%s)", strings.Join(toolbox.Names(), ", "))
+	}
+	return c.toolbox().Check(c.Tools)
+}
+
+func (c ReActConfig) toolbox() toolbox.Config {
+	return toolbox.Config{AllowedDir: c.AllowedDir, HTTPAllowlist: c.HTTPAllowlist}
+}
+
+// merge layers the ReAct settings in src over c.
+func (c *ReActConfig) merge(src ReActConfig) {
+	if len(src.Tools) > 0 {
+		c.Tools = src.Tools
+	}
+	if src.AllowedDir != "" {
+		c.AllowedDir = src.AllowedDir
+	}
+	if len(src.HTTPAllowlist) > 0 {
+		c.HTTPAllowlist = src.HTTPAllowlist
+	}
+}
+
+// ReActStep is one think/act/observe iteration. The last step of a finished run
+// carries the Answer instead of an action.
+type ReActStep struct {
+	Step        int    `json:"step"`
+	Thought     string `json:"thought,omitempty"`
+	Action      string `json:"action,omitempty"`
+	ActionInput string `json:"action_input,omitempty"`
+	Observation string `json:"observation,omitempty"`
+	Answer      string `json:"answer,omitempty"`
+}
+
+// ReActTrace records one run of a ReAct agent.
+type ReActTrace struct {
+	Agent  string      `json:"agent"`
+	Input  string      `json:"input"`
+	Steps  []ReActStep `json:"steps"`
+	Answer string      `json:"answer,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ReActAgent answers an input by iterating think/act/observe with built-in tools
+// until the model gives a final answer. Every iteration takes a ticket hop, so the
+// ticket's MaxHops bounds the loop; without a ticket a scratch one with the default
+// limit is used. Each run's trace is appended to react.trace in the agent's namespace.
+type ReActAgent struct {
+	Agent
+	config ReActConfig
+	tools  map[string]toolbox.Tool
+}
+
+// NewReAct wraps base, which supplies the agent's identity, provider and model, as a
+// ReAct agent using the tools named in cfg.
+func NewReAct(base Agent, cfg ReActConfig) (*ReActAgent, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	r := &ReActAgent{Agent: base, config: cfg, tools: map[string]toolbox.Tool{}}
+	for _, name := range cfg.Tools {
+		if name == toolbox.WriteContext {
+			continue
+		}
+		tool, err := toolbox.New(name, cfg.toolbox())
+		if err != nil {
+			return nil, err
+		}
+		r.tools[name] = tool
+	}
+	return r, nil
+}
+
+// Tags returns the tags of the agent the ReAct agent wraps.
+func (r *ReActAgent) Tags() []string { return TagsOf(r.Agent) }
+
+// Capabilities returns the capabilities of the agent the ReAct agent wraps.
+func (r *ReActAgent) Capabilities() []string { return CapabilitiesOf(r.Agent) }
+
+// Config returns the ReAct settings.
+func (r *ReActAgent) Config() ReActConfig { return r.config }
+
+// Handle runs the think/act/observe loop on input and returns the final answer. The
+// agent's timeout and concurrency limits cover the whole loop.
+func (r *ReActAgent) Handle(ctx context.Context, input string, t *tickets.Ticket) (string, error) {
+	return limiterOf(r.Agent).Do(ctx, func(ctx context.Context) (string, error) {
+		return r.run(ctx, input, t)
+	})
+}
+
+// run is the think/act/observe loop.
+func (r *ReActAgent) run(ctx context.Context, input string, t *tickets.Ticket) (string, error) {
+	if t == nil {
+		t = tickets.NewTicket("react-"+r.ID(), "", nil)
+	}
+	tools := r.toolsFor(t)
+	trace := &ReActTrace{Agent: r.ID(), Input: input}
+	defer recordReActTrace(t, trace)
+
+	fail := func(err error) (string, error) {
+		trace.Error = err.Error()
+		return "", err
+	}
+
+	transcript := reactPreamble(tools) + "\n\nQuestion: " + input + "\n"
+	for n := 1; ; n++ {
+		if err := t.Handoff(r.ID()); err != nil {
+			return fail(fmt.Errorf("react agent %s stopped after %d step(s) without a final answer: %w", r.ID(), n-1, err))
+		}
+		reply, err := r.Provider().GenerateResponse(ctx, transcript, ModelFromContext(ctx, r.DefaultModel()))
+		if err != nil {
+			return fail(err)
+		}
+		// Models sometimes invent the observation; everything from there on is dropped.
+		if i := observationRe.FindStringIndex(reply); i != nil {
+			reply = reply[:i[0]]
+		}
+
+		step := parseReActStep(reply)
+		step.Step = n
+		if step.Action == "" {
+			if step.Answer == "" {
+				step.Answer = strings.TrimSpace(reply)
+			}
+			trace.Steps = append(trace.Steps, step)
+			trace.Answer = step.Answer
+			return step.Answer, nil
+		}
+
+		step.Observation = r.act(ctx, tools, step)
+		trace.Steps = append(trace.Steps, step)
+		transcript += strings.TrimSpace(reply) + "\nObservation: " + step.Observation + "\n"
+	}
+}
+
+// act runs the step's tool and returns the observation; failures are reported to the model.
+func (r *ReActAgent) act(ctx context.Context, tools map[string]toolbox.Tool, step ReActStep) string {
+	tool, ok := tools[step.Action]
+	if !ok {
+		return fmt.Sprintf("ERROR: unknown tool %q; available tools: %s", step.Action, strings.Join(sortedToolNames(tools), ", "))
+	}
+	logger.Info(fmt.Sprintf("ReAct agent %s step %d: %s", r.ID(), step.Step, step.Action), false)
+	out, err := tool.Run(ctx, step.ActionInput)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("ReAct agent %s tool %s failed: %v", r.ID(), step.Action, err), false)
+		return "ERROR: " + err.Error()
+	}
+	return out
+}
+
+// toolsFor returns the agent's tools, with write_context bound to t.
+func (r *ReActAgent) toolsFor(t *tickets.Ticket) map[string]toolbox.Tool {
+	tools := make(map[string]toolbox.Tool, len(r.config.Tools))
+	for name, tool := range r.tools {
+		tools[name] = tool
+	}
+	for _, name := range r.config.Tools {
+		if name == toolbox.WriteContext {
+			tools[name] = toolbox.ContextWriter(func(key, value string) {
+				t.SetNamespaced(r.ID(), key, value)
+			})
+		}
+	}
+	return tools
+}
+
+// reactPreamble describes the tools and the reply format to the model.
+func reactPreamble(tools map[string]toolbox.Tool) string {
+	var b strings.Builder
+	b.WriteString("Answer the question by reasoning step by step. You can use these tools:\n")
+	for _, name := range sortedToolNames(tools) {
+		fmt.Fprintf(&b, "- %s: %s\n", name, tools[name].Description)
+	}
+	b.WriteString("\nTo use a tool, reply with:\nThought: <your reasoning>\nAction: <tool name>\nAction Input: <input for the tool>\n")
+	b.WriteString("and stop; you will be sent an Observation with the result. When you know the answer, reply with:\n")
+	b.WriteString("Thought: <your reasoning>\nFinal Answer: <the answer>")
+	return b.String()
+}
+
+func sortedToolNames(tools map[string]toolbox.Tool) []string {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	reactFieldRe  = regexp.MustCompile(`(?im)^[ \t]*(thought|action input|action|final answer)[ \t]*:`)
+	observationRe = regexp.MustCompile(`(?im)^[ \t]*observation[ \t]*:`)
+)
+
+// parseReActStep reads the Thought, Action, Action Input and Final Answer fields of
+// a reply. Each runs until the next field; the first occurrence of a field wins.
+func parseReActStep(reply string) ReActStep {
+	var s ReActStep
+	locs := reactFieldRe.FindAllStringSubmatchIndex(reply, -1)
+	for i, loc := range locs {
+		end := len(reply)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		value := strings.TrimSpace(reply[loc[1]:end])
+		var field *string
+		switch strings.ToLower(reply[loc[2]:loc[3]]) {
+		case "thought":
+			field = &s.Thought
+		case "action":
+			field = &s.Action
+		case "action input":
+			field = &s.ActionInput
+		case "final answer":
+			field = &s.Answer
+		}
+		if *field == "" {
+			*field = value
+		}
+	}
+	if s.Answer != "" {
+		s.Action, s.ActionInput = "", ""
+	}
+	return s
+}
+
+// recordReActTrace appends trace to react.trace in the agent's ticket namespace.
+func recordReActTrace(t *tickets.Ticket, trace *ReActTrace) {
+	var history []*ReActTrace
+	if prev, ok := t.GetNamespaced(trace.Agent, "react.trace"); ok {
+		_ = json.Unmarshal([]byte(prev), &history)
+	}
+	history = append(history, trace)
+	if data, err := json.Marshal(history); err == nil {
+		t.SetNamespaced(trace.Agent, "react.trace", string(data))
+	}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"keystone/internal/tickets"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReAct_ThinksActsAndObserves(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("budget: 1200"), 0o644))
+
+	p := &scriptProvider{replies: []string{
+		"Thought: I should read the notes.\nAction: read_file\nAction Input: notes.txt\nObservation: made up",
+		"Thought: Now compute.\nAction: calculator\nAction Input: 1200 * 3",
+		"Thought: Save it.\nAction: write_context\nAction Input: total=3600",
+		"Thought: Done.\nFinal Answer: The total is 3600.",
+	}}
+	r, err := NewReAct(NewAgent("planner", "Planner", "", p, "m", "none"),
+		ReActConfig{Tools: []string{"read_file", "calculator", "write_context"}, AllowedDir: dir})
+	require.NoError(t, err)
+
+	ticket := tickets.NewTicket("t1", "u1", nil)
+	ticket.MaxHops = 10
+	resp, err := r.Handle(context.Background(), "What is three times the budget?", ticket)
+	require.NoError(t, err)
+	require.Equal(t, "The total is 3600.", resp)
+
+	require.Len(t, p.prompts, 4)
+	require.Contains(t, p.prompts[0], "- calculator: evaluate an arithmetic expression")
+	require.Contains(t, p.prompts[0], "Question: What is three times the budget?")
+	require.Contains(t, p.prompts[1], "Action Input: notes.txt\nObservation: budget: 1200\n")
+	require.NotContains(t, p.prompts[1], "made up", "invented observations are dropped")
+	require.Contains(t, p.prompts[2], "Observation: 3600\n")
+	require.Equal(t, 4, ticket.Hops, "every iteration takes a hop")
+
+	ctx := ticket.GetAllNamespaced("planner")
+	require.Equal(t, "3600", ctx["total"])
+	var traces []ReActTrace
+	require.NoError(t, json.Unmarshal([]byte(ctx["react.trace"]), &traces))
+	require.Len(t, traces, 1)
+	require.Equal(t, "The total is 3600.", traces[0].Answer)
+	require.Len(t, traces[0].Steps, 4)
+	require.Equal(t, ReActStep{Step: 2, Thought: "Now compute.", Action: "calculator", ActionInput: "1200 * 3", Observation: "3600"}, traces[0].Steps[1])
+}
+
+func TestReAct_ReportsToolErrorsToModel(t *testing.T) {
+	dir := t.TempDir()
+	p := &scriptProvider{replies: []string{
+		"Action: read_file\nAction Input: ../secret.txt",
+		"Action: http_get\nAction Input: https://example.com",
+		"Final Answer: gave up",
+	}}
+	r, err := NewReAct(NewAgent("r", "R", "", p, "m", "none"), ReActConfig{Tools: []string{"read_file", "list_dir"}, AllowedDir: dir})
+	require.NoError(t, err)
+
+	resp, err := r.Handle(context.Background(), "peek", nil)
+	require.NoError(t, err)
+	require.Equal(t, "gave up", resp)
+	require.Contains(t, p.prompts[1], `Observation: ERROR: path "../secret.txt" is outside the allowed directory`)
+	require.Contains(t, p.prompts[2], `Observation: ERROR: unknown tool "http_get"; available tools: list_dir, read_file`)
+}
+
+func TestReAct_MaxHopsBoundsLoop(t *testing.T) {
+	p := &scriptProvider{replies: []string{"Thought: again\nAction: calculator\nAction Input: 1 + 1"}}
+	r, err := NewReAct(NewAgent("looper", "Looper", "", p, "m", "none"), ReActConfig{Tools: []string{"calculator"}})
+	require.NoError(t, err)
+
+	ticket := tickets.NewTicket("t1", "u1", nil)
+	ticket.MaxHops = 3
+	_, err = r.Handle(context.Background(), "loop forever", ticket)
+	require.ErrorContains(t, err, "react agent looper stopped after 3 step(s) without a final answer: ticket max hops exceeded")
+	require.Len(t, p.prompts, 3)
+
+	var traces []ReActTrace
+	require.NoError(t, json.Unmarshal([]byte(ticket.GetAllNamespaced("looper")["react.trace"]), &traces))
+	require.Len(t, traces[0].Steps, 3)
+	require.NotEmpty(t, traces[0].Error)
+}
+
+func TestReAct_ConfigValidation(t *testing.T) {
+	base := AgentConfig{ID: "r", Name: "R", Provider: "mock"}
+
+	cfg := base
+	cfg.Kind = KindReAct
+	require.ErrorContains(t, cfg.Validate(), "react agents need at least one tool")
+
+	cfg.ReAct = ReActConfig{Tools: []string{"read_file"}}
+	require.ErrorContains(t, cfg.Validate(), "tool read_file needs allowed_dir")
+
+	cfg.ReAct = ReActConfig{Tools: []string{"http_get", "shell"}, HTTPAllowlist: []string{"api.example.com"}}
+	require.ErrorContains(t, cfg.Validate(), `unknown tool "shell"`)
+
+	cfg.ReAct = ReActConfig{Tools: []string{"calculator"}}
+	require.NoError(t, cfg.Validate())
+
+	guarded := cfg
+	guarded.Guardrails.Output.PII = "redact"
+	require.ErrorContains(t, guarded.Validate(), "react agents do not apply guardrails")
+
+	remembering := cfg
+	remembering.MemoryOptions.Strategy = "window"
+	require.ErrorContains(t, remembering.Validate(), "react agents do not use memory_options")
+
+	cfg = base
+	cfg.ReAct = ReActConfig{Tools: []string{"calculator"}}
+	require.ErrorContains(t, cfg.Validate(), "react settings require kind: react")
+}
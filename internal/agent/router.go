@@ -0,0 +1,515 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"keystone/internal/logger"
+	"keystone/internal/providers"
+	"keystone/internal/tickets"
+)
+
+// KindRouter marks an agent config as a router; see RouterConfig.
+const KindRouter = "router"
+
+// Routing strategies.
+const (
+	RouteByKeyword    = "keyword"
+	RouteBySimilarity = "similarity"
+	RouteByLLM        = "llm"
+)
+
+// RouterConfig configures a router agent. Routes lists the candidate agents; when it is
+// empty every registered non-router agent is a candidate. Decisions below MinConfidence,
+// or inputs no route matches, go to Default when it is set and fail otherwise.
+type RouterConfig struct {
+	Strategy      string  `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+	Routes        []Route `yaml:"routes,omitempty" json:"routes,omitempty"`
+	Default       string  `yaml:"default,omitempty" json:"default,omitempty"`
+	MinConfidence float64 `yaml:"min_confidence,omitempty" json:"min_confidence,omitempty"`
+}
+
+// Route names one candidate agent, or with Capability every agent advertising that
+// capability. Keywords drive keyword routing; Description, when set, replaces the
+// agent's own description for similarity and LLM routing.
+type Route struct {
+	Agent       string   `yaml:"agent,omitempty" json:"agent,omitempty"`
+	Capability  string   `yaml:"capability,omitempty" json:"capability,omitempty"`
+	Keywords    []string `yaml:"keywords,omitempty" json:"keywords,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// IsZero reports whether no router settings are present.
+func (c RouterConfig) IsZero() bool {
+	return c.Strategy == "" && len(c.Routes) == 0 && c.Default == "" && c.MinConfidence == 0
+}
+
+// Validate checks the router settings.
+func (c RouterConfig) Validate() error {
+	switch c.Strategy {
+	case RouteByKeyword, RouteBySimilarity, RouteByLLM:
+	case "":
+		return fmt.Errorf("router strategy is required (%s, %s or %s)", RouteByKeyword, RouteBySimilarity, RouteByLLM)
+	default:
+		return fmt.Errorf("unknown router strategy %q (want %s, %s or %s)", c.Strategy, RouteByKeyword, RouteBySimilarity, RouteByLLM)
+	}
+	if c.MinConfidence < 0 || c.MinConfidence > 1 {
+		return fmt.Errorf("router min_confidence must be between 0 and 1")
+	}
+	if c.Strategy == RouteByKeyword && len(c.Routes) == 0 {
+		return fmt.Errorf("keyword routing needs routes with keywords")
+	}
+	seen := map[string]bool{}
+	for i, r := range c.Routes {
+		if (r.Agent == "") == (r.Capability == "") {
+			return fmt.Errorf("router route %d must set exactly one of agent or capability", i+1)
+		}
+		name := r.name()
+		if seen[name] {
+			return fmt.Errorf("router lists %s more than once", name)
+		}
+		seen[name] = true
+		if c.Strategy == RouteByKeyword && len(r.Keywords) == 0 {
+			return fmt.Errorf("router route %s has no keywords", name)
+		}
+	}
+	return nil
+}
+
+// name identifies the route in messages.
+func (r Route) name() string {
+	if r.Capability != "" {
+		return "capability " + r.Capability
+	}
+	return r.Agent
+}
+
+// merge layers the router settings in src over c.
+func (c *RouterConfig) merge(src RouterConfig) {
+	if src.Strategy != "" {
+		c.Strategy = src.Strategy
+	}
+	if len(src.Routes) > 0 {
+		c.Routes = src.Routes
+	}
+	if src.Default != "" {
+		c.Default = src.Default
+	}
+	if src.MinConfidence != 0 {
+		c.MinConfidence = src.MinConfidence
+	}
+}
+
+// RouteDecision records which agent a router picked and why.
+type RouteDecision struct {
+	Router     string  `json:"router"`
+	Agent      string  `json:"agent"`
+	Strategy   string  `json:"strategy"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+	Fallback   bool    `json:"fallback,omitempty"`
+}
+
+// RouterAgent chooses another registered agent for each input, hands the ticket
+// off to it and returns its response. Each decision is recorded in the ticket under
+// the router's namespace: route.agent, route.confidence, route.strategy,
+// route.reason and route.history (a JSON list of every decision on the ticket).
+type RouterAgent struct {
+	Agent
+	config  RouterConfig
+	manager *AgentManager
+}
+
+// NewRouter wraps base, which supplies the router's identity, provider and model,
+// as a router that dispatches to agents registered in manager.
+func NewRouter(base Agent, cfg RouterConfig, manager *AgentManager) *RouterAgent {
+	return &RouterAgent{Agent: base, config: cfg, manager: manager}
+}
+
+// Tags returns the tags of the agent the router wraps.
+func (r *RouterAgent) Tags() []string { return TagsOf(r.Agent) }
+
+// Capabilities returns the capabilities of the agent the router wraps.
+func (r *RouterAgent) Capabilities() []string { return CapabilitiesOf(r.Agent) }
+
+// Config returns the router's settings.
+func (r *RouterAgent) Config() RouterConfig { return r.config }
+
+// Handle routes input to the chosen agent and returns its response.
+func (r *RouterAgent) Handle(ctx context.Context, input string, t *tickets.Ticket) (string, error) {
+	return r.HandleStream(ctx, input, t, nil)
+}
+
+// HandleStream routes input and streams the chosen agent's response when it can stream.
+// The router's timeout and concurrency limits cover the chosen agent's run.
+func (r *RouterAgent) HandleStream(ctx context.Context, input string, t *tickets.Ticket, onChunk func(string)) (string, error) {
+	return limiterOf(r.Agent).Do(ctx, func(ctx context.Context) (string, error) {
+		return r.dispatch(ctx, input, t, onChunk)
+	})
+}
+
+// dispatch decides on an agent for input, hands the ticket off to it and runs it.
+func (r *RouterAgent) dispatch(ctx context.Context, input string, t *tickets.Ticket, onChunk func(string)) (string, error) {
+	ctx, err := enterRouter(ctx, r.ID())
+	if err != nil {
+		return "", err
+	}
+	d, err := r.Decide(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	target, err := r.manager.Get(d.Agent)
+	if err != nil {
+		return "", fmt.Errorf("router %s: %w", r.ID(), err)
+	}
+
+	logger.Info(fmt.Sprintf("Router %s -> %s (%s, confidence %.2f): %s", r.ID(), d.Agent, d.Strategy, d.Confidence, d.Reason), false)
+	if t != nil {
+		recordDecision(t, d)
+		if err := t.Handoff(d.Agent); err != nil {
+			return "", fmt.Errorf("router %s: handoff to %s: %w", r.ID(), d.Agent, err)
+		}
+	}
+
+	ctx, finalInput, err := PrepareInput(ctx, target, input, PromptVars{}, t)
+	if err != nil {
+		return "", err
+	}
+	if sa, ok := target.(StreamingAgent); ok && onChunk != nil {
+		return sa.HandleStream(ctx, finalInput, t, onChunk)
+	}
+	resp, err := target.Handle(ctx, finalInput, t)
+	if err == nil && onChunk != nil {
+		onChunk(resp)
+	}
+	return resp, err
+}
+
+// Decide picks the agent for input without running it.
+func (r *RouterAgent) Decide(ctx context.Context, input string) (RouteDecision, error) {
+	candidates := r.candidates()
+	if len(candidates) == 0 {
+		return RouteDecision{}, fmt.Errorf("router %s has no agents to route to", r.ID())
+	}
+
+	var (
+		d   RouteDecision
+		err error
+	)
+	switch r.config.Strategy {
+	case RouteByKeyword:
+		d = routeByKeyword(input, candidates)
+	case RouteBySimilarity:
+		d, err = r.routeBySimilarity(ctx, input, candidates)
+	case RouteByLLM:
+		d, err = r.routeByLLM(ctx, input, candidates)
+	default:
+		err = fmt.Errorf("unknown router strategy %q", r.config.Strategy)
+	}
+	if err != nil {
+		return RouteDecision{}, fmt.Errorf("router %s: %w", r.ID(), err)
+	}
+	d.Router, d.Strategy = r.ID(), r.config.Strategy
+
+	if d.Agent == "" || d.Confidence < r.config.MinConfidence {
+		if r.config.Default == "" {
+			return d, fmt.Errorf("router %s could not choose an agent: %s", r.ID(), d.Reason)
+		}
+		if d.Agent != "" {
+			d.Reason = fmt.Sprintf("%s; confidence %.2f for %s is below min_confidence %.2f", d.Reason, d.Confidence, d.Agent, r.config.MinConfidence)
+		}
+		d.Agent, d.Fallback = r.config.Default, true
+	}
+	return d, nil
+}
+
+// candidate is a routable agent and the text that describes it.
+type candidate struct {
+	id          string
+	keywords    []string
+	description string
+}
+
+// candidates returns the agents the configured routes resolve to, or every
+// registered agent other than routers when no routes are configured.
+// Capability routes expand to each non-router agent advertising the capability.
+func (r *RouterAgent) candidates() []candidate {
+	var out []candidate
+	seen := map[string]bool{}
+	add := func(a Agent, route Route) {
+		if seen[a.ID()] || a.ID() == r.ID() {
+			return
+		}
+		seen[a.ID()] = true
+		desc := route.Description
+		if desc == "" {
+			desc = a.Name() + ": " + a.Description()
+		}
+		out = append(out, candidate{id: a.ID(), keywords: route.Keywords, description: desc})
+	}
+
+	if len(r.config.Routes) == 0 {
+		agents := r.manager.List()
+		sortAgents(agents)
+		for _, a := range agents {
+			if _, isRouter := a.(*RouterAgent); !isRouter {
+				add(a, Route{})
+			}
+		}
+		return out
+	}
+	for _, route := range r.config.Routes {
+		if route.Capability != "" {
+			for _, a := range r.manager.WithCapability(route.Capability) {
+				if _, isRouter := a.(*RouterAgent); !isRouter {
+					add(a, route)
+				}
+			}
+			continue
+		}
+		a, err := r.manager.Get(route.Agent)
+		if err != nil || route.Agent == r.ID() {
+			logger.Warn(fmt.Sprintf("Router %s skipping route to unavailable agent %s", r.ID(), route.Agent), false)
+			continue
+		}
+		add(a, route)
+	}
+	return out
+}
+
+// routeByKeyword picks the route whose keywords appear most often in input, as whole
+// words, so "count" does not match "account". Confidence is that route's share of all
+// keyword hits; ties go to the route listed first.
+func routeByKeyword(input string, candidates []candidate) RouteDecision {
+	best, bestHits, total := -1, 0, 0
+	var matched []string
+	for i, c := range candidates {
+		var hits []string
+		for _, kw := range c.keywords {
+			if kw != "" && keywordPattern(kw).MatchString(input) {
+				hits = append(hits, kw)
+			}
+		}
+		total += len(hits)
+		if len(hits) > bestHits {
+			best, bestHits, matched = i, len(hits), hits
+		}
+	}
+	if best < 0 {
+		return RouteDecision{Reason: "no route keywords matched"}
+	}
+	return RouteDecision{
+		Agent:      candidates[best].id,
+		Confidence: float64(bestHits) / float64(total),
+		Reason:     "matched keywords: " + strings.Join(matched, ", "),
+	}
+}
+
+// keywordPattern matches kw case-insensitively as a whole word. Word boundaries are
+// only required next to word characters, so keywords such as "c++" still match.
+func keywordPattern(kw string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(kw)
+	if isWordRune(rune(kw[0])) {
+		pattern = `\b` + pattern
+	}
+	if isWordRune(rune(kw[len(kw)-1])) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile(`(?i)` + pattern)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// routeBySimilarity picks the candidate whose description is most similar to input.
+// Providers implementing providers.Embedder supply the vectors; otherwise a
+// bag-of-words vector is used. Confidence is the cosine similarity.
+func (r *RouterAgent) routeBySimilarity(ctx context.Context, input string, candidates []candidate) (RouteDecision, error) {
+	texts := make([]string, 0, len(candidates)+1)
+	texts = append(texts, input)
+	for _, c := range candidates {
+		texts = append(texts, c.description)
+	}
+
+	var scores []float64
+	if e, ok := r.Provider().(providers.Embedder); ok {
+		vecs, err := e.Embed(ctx, texts, ModelFromContext(ctx, r.DefaultModel()))
+		if err != nil {
+			return RouteDecision{}, fmt.Errorf("embedding route descriptions: %w", err)
+		}
+		if len(vecs) != len(texts) {
+			return RouteDecision{}, fmt.Errorf("embedding returned %d vectors for %d texts", len(vecs), len(texts))
+		}
+		for _, v := range vecs[1:] {
+			scores = append(scores, cosine(vecs[0], v))
+		}
+	} else {
+		q := termVector(input)
+		for _, c := range candidates {
+			scores = append(scores, sparseCosine(q, termVector(c.description)))
+		}
+	}
+
+	best := 0
+	for i, s := range scores {
+		if s > scores[best] {
+			best = i
+		}
+	}
+	if scores[best] <= 0 {
+		return RouteDecision{Reason: "input is not similar to any agent description"}, nil
+	}
+	return RouteDecision{
+		Agent:      candidates[best].id,
+		Confidence: math.Min(scores[best], 1),
+		Reason:     fmt.Sprintf("description similarity %.2f", scores[best]),
+	}, nil
+}
+
+// routeByLLM asks the router's provider to classify input. The reply should name an
+// agent ID and may give a confidence between 0 and 1; a bare ID counts as certain.
+func (r *RouterAgent) routeByLLM(ctx context.Context, input string, candidates []candidate) (RouteDecision, error) {
+	var b strings.Builder
+	b.WriteString("Choose the agent best suited to handle the request below.\nAgents:\n")
+	for _, c := range candidates {
+		fmt.Fprintf(&b, "- %s: %s\n", c.id, c.description)
+	}
+	fmt.Fprintf(&b, "Request: %s\n", input)
+	b.WriteString("Reply with only the agent ID followed by your confidence between 0 and 1, for example: support 0.8")
+
+	reply, err := r.Provider().GenerateResponse(ctx, b.String(), ModelFromContext(ctx, r.DefaultModel()))
+	if err != nil {
+		return RouteDecision{}, fmt.Errorf("classifying input: %w", err)
+	}
+
+	d := RouteDecision{Confidence: -1}
+	for _, field := range strings.Fields(reply) {
+		word := strings.Trim(field, "\"'`.,:;()[]")
+		if d.Agent == "" {
+			for _, c := range candidates {
+				if strings.EqualFold(word, c.id) {
+					d.Agent = c.id
+					break
+				}
+			}
+			continue
+		}
+		if f, err := strconv.ParseFloat(word, 64); err == nil && f >= 0 && f <= 1 {
+			d.Confidence = f
+			break
+		}
+	}
+	if d.Agent == "" {
+		return RouteDecision{Reason: fmt.Sprintf("model reply named no known agent: %q", truncateReason(reply))}, nil
+	}
+	if d.Confidence < 0 {
+		d.Confidence = 1
+	}
+	d.Reason = fmt.Sprintf("model reply: %q", truncateReason(reply))
+	return d, nil
+}
+
+// recordDecision stores d in the router's namespace on t.
+func recordDecision(t *tickets.Ticket, d RouteDecision) {
+	t.SetNamespaced(d.Router, "route.agent", d.Agent)
+	t.SetNamespaced(d.Router, "route.strategy", d.Strategy)
+	t.SetNamespaced(d.Router, "route.confidence", strconv.FormatFloat(d.Confidence, 'f', 2, 64))
+	t.SetNamespaced(d.Router, "route.reason", d.Reason)
+
+	var history []RouteDecision
+	if prev, ok := t.GetNamespaced(d.Router, "route.history"); ok {
+		_ = json.Unmarshal([]byte(prev), &history)
+	}
+	history = append(history, d)
+	if data, err := json.Marshal(history); err == nil {
+		t.SetNamespaced(d.Router, "route.history", string(data))
+	}
+}
+
+type routerPathKey struct{}
+
+// enterRouter records id on the routing path in ctx, failing if it is already there.
+func enterRouter(ctx context.Context, id string) (context.Context, error) {
+	path, _ := ctx.Value(routerPathKey{}).([]string)
+	for _, p := range path {
+		if p == id {
+			return ctx, fmt.Errorf("routing loop: %s -> %s", strings.Join(path, " -> "), id)
+		}
+	}
+	next := append(append([]string(nil), path...), id)
+	return context.WithValue(ctx, routerPathKey{}, next), nil
+}
+
+func truncateReason(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 120 {
+		return s[:120] + "..."
+	}
+	return s
+}
+
+// stopWords are ignored when building term vectors.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true,
+	"from": true, "are": true, "was": true, "you": true, "your": true, "can": true,
+	"how": true, "what": true, "into": true, "about": true, "agent": true,
+}
+
+// TextSimilarity scores how alike two texts are by the cosine of their term vectors,
+// from 0 (no shared terms) to 1.
+func TextSimilarity(a, b string) float64 {
+	return sparseCosine(termVector(a), termVector(b))
+}
+
+// termVector counts the words of text, ignoring case, short words and stop words.
+func termVector(text string) map[string]float64 {
+	v := map[string]float64{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) < 3 || stopWords[w] {
+			continue
+		}
+		v[strings.TrimSuffix(w, "s")]++
+	}
+	return v
+}
+
+func sparseCosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for k, x := range a {
+		dot += x * b[k]
+		na += x * x
+	}
+	for _, y := range b {
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"keystone/internal/providers"
+	"keystone/internal/tickets"
+
+	"github.com/stretchr/testify/require"
+)
+
+// replyProvider answers every prompt with a fixed reply and remembers the last prompt.
+type replyProvider struct {
+	reply  string
+	prompt string
+}
+
+func (p *replyProvider) GenerateResponse(_ context.Context, prompt, _ string) (string, error) {
+	p.prompt = prompt
+	return p.reply, nil
+}
+
+func (p *replyProvider) UsageInfo() (providers.Usage, error) { return providers.Usage{}, nil }
+
+// axisEmbedder embeds text as a vector with one axis per known word.
+type axisEmbedder struct{ replyProvider }
+
+func (e *axisEmbedder) Embed(_ context.Context, texts []string, _ string) ([][]float64, error) {
+	axes := []string{"money", "code"}
+	out := make([][]float64, len(texts))
+	for i, t := range texts {
+		out[i] = make([]float64, len(axes))
+		for j, a := range axes {
+			out[i][j] = float64(strings.Count(strings.ToLower(t), a))
+		}
+	}
+	return out, nil
+}
+
+func newRoutingManager(t *testing.T) *AgentManager {
+	t.Helper()
+	m := NewManager()
+	for _, a := range []Agent{
+		NewAgent("billing", "Billing", "Answers questions about invoices, payments and refunds", &MockProvider{}, "m", "none",
+			WithPromptTemplate("[billing] {{input}}")),
+		NewAgent("coder", "Coder", "Writes and reviews source code and programs", &MockProvider{}, "m", "none"),
+		NewAgent("general", "General", "Handles anything else", &MockProvider{}, "m", "none"),
+	} {
+		require.NoError(t, m.Register(a))
+	}
+	return m
+}
+
+func newTestRouter(m *AgentManager, provider providers.Provider, cfg RouterConfig) *RouterAgent {
+	r := NewRouter(NewAgent("router", "Router", "", provider, "router-model", "none"), cfg, m)
+	_ = m.Register(r)
+	return r
+}
+
+func TestRouter_KeywordRoutingRecordsDecision(t *testing.T) {
+	m := newRoutingManager(t)
+	r := newTestRouter(m, &MockProvider{}, RouterConfig{
+		Strategy: RouteByKeyword,
+		Routes: []Route{
+			{Agent: "billing", Keywords: []string{"invoice", "refund"}},
+			{Agent: "coder", Keywords: []string{"bug", "golang"}},
+		},
+	})
+
+	ticket := tickets.NewTicket("t1", "u1", nil)
+	resp, err := r.Handle(context.Background(), "I need a refund for this invoice, not a bug fix", ticket)
+	require.NoError(t, err)
+	require.Equal(t, "mock response: [billing] I need a refund for this invoice, not a bug fix", resp)
+
+	ctx := ticket.GetAllNamespaced("router")
+	require.Equal(t, "billing", ctx["route.agent"])
+	require.Equal(t, "keyword", ctx["route.strategy"])
+	require.Equal(t, "0.67", ctx["route.confidence"])
+	require.Equal(t, "matched keywords: invoice, refund", ctx["route.reason"])
+	require.Equal(t, 1, ticket.Hops, "routing hands the ticket off to the chosen agent")
+	_, handedOff := ticket.Context[tickets.Namespaced("billing", "_")]
+	require.True(t, handedOff)
+
+	_, err = r.Handle(context.Background(), "golang bug", ticket)
+	require.NoError(t, err)
+	var history []RouteDecision
+	require.NoError(t, json.Unmarshal([]byte(ticket.GetAllNamespaced("router")["route.history"]), &history))
+	require.Len(t, history, 2)
+	require.Equal(t, "coder", history[1].Agent)
+}
+
+func TestRouter_KeywordsMatchWholeWords(t *testing.T) {
+	candidates := []candidate{
+		{id: "stats", keywords: []string{"count"}},
+		{id: "coder", keywords: []string{"C++"}},
+	}
+
+	d := routeByKeyword("I cannot log into my account", candidates)
+	require.Empty(t, d.Agent, "count must not match inside account")
+	require.Equal(t, "no route keywords matched", d.Reason)
+
+	require.Equal(t, "stats", routeByKeyword("Count the rows, please", candidates).Agent)
+	require.Equal(t, "coder", routeByKeyword("a segfault in my c++ code", candidates).Agent)
+}
+
+func TestRouter_FallbackAndMinConfidence(t *testing.T) {
+	m := newRoutingManager(t)
+	cfg := RouterConfig{
+		Strategy:      RouteByKeyword,
+		Routes:        []Route{{Agent: "billing", Keywords: []string{"refund"}}, {Agent: "coder", Keywords: []string{"code"}}},
+		MinConfidence: 0.6,
+	}
+	r := newTestRouter(m, &MockProvider{}, cfg)
+
+	_, err := r.Decide(context.Background(), "hello there")
+	require.ErrorContains(t, err, "could not choose an agent: no route keywords matched")
+
+	r.config.Default = "general"
+	d, err := r.Decide(context.Background(), "refund my code")
+	require.NoError(t, err)
+	require.Equal(t, "general", d.Agent)
+	require.True(t, d.Fallback)
+	require.Contains(t, d.Reason, "confidence 0.50 for billing is below min_confidence 0.60")
+}
+
+func TestRouter_SimilarityRouting(t *testing.T) {
+	m := newRoutingManager(t)
+
+	// Without an embedding provider, descriptions are compared word by word.
+	r := newTestRouter(m, &MockProvider{}, RouterConfig{Strategy: RouteBySimilarity})
+	d, err := r.Decide(context.Background(), "Can you review my source code?")
+	require.NoError(t, err)
+	require.Equal(t, "coder", d.Agent)
+	require.Greater(t, d.Confidence, 0.0)
+
+	// Providers that embed text supply the vectors instead.
+	m2 := NewManager()
+	emb := &axisEmbedder{}
+	r2 := newTestRouter(m2, emb, RouterConfig{Strategy: RouteBySimilarity, Routes: []Route{
+		{Agent: "payments", Description: "money money"},
+		{Agent: "dev", Description: "code"},
+	}})
+	require.NoError(t, m2.Register(NewAgent("payments", "Payments", "", &MockProvider{}, "m", "none")))
+	require.NoError(t, m2.Register(NewAgent("dev", "Dev", "", &MockProvider{}, "m", "none")))
+	d, err = r2.Decide(context.Background(), "where did my money go")
+	require.NoError(t, err)
+	require.Equal(t, "payments", d.Agent)
+	require.InDelta(t, 1.0, d.Confidence, 1e-9)
+}
+
+func TestRouter_LLMRouting(t *testing.T) {
+	m := newRoutingManager(t)
+	p := &replyProvider{reply: "Coder 0.9"}
+	r := newTestRouter(m, p, RouterConfig{Strategy: RouteByLLM, Routes: []Route{{Agent: "billing"}, {Agent: "coder"}}})
+
+	d, err := r.Decide(context.Background(), "fix my build")
+	require.NoError(t, err)
+	require.Equal(t, "coder", d.Agent)
+	require.Equal(t, 0.9, d.Confidence)
+	require.Contains(t, p.prompt, "- billing: Billing: Answers questions")
+	require.Contains(t, p.prompt, "Request: fix my build")
+
+	p.reply = "billing"
+	d, err = r.Decide(context.Background(), "refund")
+	require.NoError(t, err)
+	require.Equal(t, "billing", d.Agent)
+	require.Equal(t, 1.0, d.Confidence)
+
+	p.reply = "no idea"
+	_, err = r.Decide(context.Background(), "?")
+	require.ErrorContains(t, err, "model reply named no known agent")
+}
+
+func TestRouter_DetectsLoops(t *testing.T) {
+	m := NewManager()
+	a := NewRouter(NewAgent("a", "A", "", &MockProvider{}, "m", "none"), RouterConfig{Strategy: RouteByKeyword, Routes: []Route{{Agent: "b", Keywords: []string{"x"}}}}, m)
+	b := NewRouter(NewAgent("b", "B", "", &MockProvider{}, "m", "none"), RouterConfig{Strategy: RouteByKeyword, Routes: []Route{{Agent: "a", Keywords: []string{"x"}}}}, m)
+	require.NoError(t, m.Register(a))
+	require.NoError(t, m.Register(b))
+
+	_, err := a.Handle(context.Background(), "x", nil)
+	require.ErrorContains(t, err, "routing loop: a -> b -> a")
+}
+
+func TestRouterConfig_Validate(t *testing.T) {
+	require.ErrorContains(t, RouterConfig{}.Validate(), "router strategy is required")
+	require.ErrorContains(t, RouterConfig{Strategy: "dice"}.Validate(), `unknown router strategy "dice"`)
+	require.ErrorContains(t, RouterConfig{Strategy: RouteByKeyword}.Validate(), "needs routes with keywords")
+	require.ErrorContains(t, RouterConfig{Strategy: RouteByKeyword, Routes: []Route{{Agent: "a"}}}.Validate(), "route a has no keywords")
+	require.ErrorContains(t, RouterConfig{Strategy: RouteByLLM, MinConfidence: 2}.Validate(), "min_confidence")
+	require.NoError(t, RouterConfig{Strategy: RouteBySimilarity}.Validate())
+
+	cfg := AgentConfig{ID: "r", Name: "R", Provider: "mock", Router: RouterConfig{Strategy: RouteByLLM}}
+	require.ErrorContains(t, cfg.Validate(), "router settings require kind: router")
+	cfg.Kind = "robot"
+	require.ErrorContains(t, cfg.Validate(), `unknown kind "robot"`)
+	cfg.Kind = KindRouter
+	require.NoError(t, cfg.Validate())
+	cfg.Guardrails.Input.MaxLength = 10
+	require.ErrorContains(t, cfg.Validate(), "routers do not apply guardrails")
+}
+
+func TestLoader_BuildsRouters(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"echo.yaml": "id: echo\nname: Echo\nprovider: mock\n",
+		"router.yaml": `id: router
+name: Router
+kind: router
+provider: mock
+router:
+  strategy: keyword
+  routes:
+    - agent: echo
+      keywords: [echo]
+`,
+	}
+	for name, body := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+	}
+
+	m := NewManager()
+	report, err := NewLoader(dir, nil).Load(m)
+	require.NoError(t, err)
+	require.NoError(t, report.Err())
+
+	a, err := m.Get("router")
+	require.NoError(t, err)
+	require.IsType(t, &RouterAgent{}, a)
+	resp, err := a.Handle(context.Background(), "echo this", nil)
+	require.NoError(t, err)
+	require.Equal(t, "mock response: echo this", resp)
+}
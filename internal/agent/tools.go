@@ -0,0 +1,226 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"keystone/internal/logger"
+	"keystone/internal/tickets"
+)
+
+// DefaultMaxToolCalls caps how many tools an agent may call while answering one input.
+const DefaultMaxToolCalls = 5
+
+// ToolCall is one node in the tree of agent-as-tool calls made while handling an input.
+// The root is the agent that was asked; each child is a sub-agent it called.
+type ToolCall struct {
+	Agent  string      `json:"agent"`
+	Input  string      `json:"input,omitempty"`
+	Output string      `json:"output,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	Calls  []*ToolCall `json:"calls,omitempty"`
+}
+
+// WithTools lets the agent call the agents with the given IDs, looked up in manager,
+// as tools. maxCalls limits calls per input; zero uses DefaultMaxToolCalls.
+func WithTools(manager *AgentManager, maxCalls int, ids ...string) AgentOption {
+	return func(a *AgentBase) {
+		a.tools = ids
+		a.toolManager = manager
+		a.maxToolCalls = maxCalls
+	}
+}
+
+// Tools returns the IDs of the agents this agent may call.
+func (a *AgentBase) Tools() []string { return a.tools }
+
+// toolCallRe matches a model's request to call a tool: "CALL <agent_id>: <input>".
+var toolCallRe = regexp.MustCompile(`(?m)^\s*CALL\s+([\w.-]+)\s*:[ \t]*`)
+
+type toolCallKey struct{}
+
+// respond generates a reply to text, letting the model call tools first when the agent has any.
+func (a *AgentBase) respond(ctx context.Context, text string, t *tickets.Ticket, onChunk func(string)) (string, error) {
+	if len(a.tools) == 0 || a.toolManager == nil {
+		return a.generate(ctx, text, onChunk)
+	}
+
+	node, nested := ctx.Value(toolCallKey{}).(*ToolCall)
+	if !nested {
+		node = &ToolCall{Agent: a.id}
+		ctx = context.WithValue(ctx, toolCallKey{}, node)
+	}
+
+	resp, err := a.toolLoop(ctx, node, text, t)
+	if !nested && t != nil && len(node.Calls) > 0 {
+		node.Output = resp
+		if err != nil {
+			node.Error = err.Error()
+		}
+		recordToolCalls(t, node)
+	}
+	if err != nil {
+		return "", err
+	}
+	if onChunk != nil {
+		onChunk(resp)
+	}
+	return resp, nil
+}
+
+// toolLoop asks the model for a reply, running each tool it requests and feeding the
+// result back, until it answers without calling a tool.
+func (a *AgentBase) toolLoop(ctx context.Context, node *ToolCall, text string, t *tickets.Ticket) (string, error) {
+	limit := a.maxToolCalls
+	if limit <= 0 {
+		limit = DefaultMaxToolCalls
+	}
+
+	conversation := a.toolPreamble() + "\n\n" + text
+	for calls := 0; ; calls++ {
+		resp, err := a.generate(ctx, conversation, nil)
+		if err != nil {
+			return "", err
+		}
+		id, input, ok := parseToolCall(resp)
+		if !ok {
+			return resp, nil
+		}
+		if calls >= limit {
+			return "", fmt.Errorf("agent %s exceeded its limit of %d tool calls", a.id, limit)
+		}
+
+		result, err := a.callTool(ctx, node, id, input, t)
+		if err != nil {
+			return "", err
+		}
+		conversation += fmt.Sprintf("\n\n%s\n\nRESULT from %s:\n%s\n\nAnswer the original request, or CALL another agent.", strings.TrimSpace(resp), id, result)
+	}
+}
+
+// callTool runs one sub-agent on the caller's ticket and returns the text to give back
+// to the model. Tool failures are reported to the model; ticket limits abort the request.
+func (a *AgentBase) callTool(ctx context.Context, parent *ToolCall, id, input string, t *tickets.Ticket) (string, error) {
+	call := &ToolCall{Agent: id, Input: input}
+	parent.Calls = append(parent.Calls, call)
+
+	fail := func(err error) (string, error) {
+		call.Error = err.Error()
+		logger.Warn(fmt.Sprintf("Agent %s tool call to %s failed: %v", a.id, id, err), false)
+		return "ERROR: " + err.Error(), nil
+	}
+
+	if !a.allowsTool(id) {
+		return fail(fmt.Errorf("%s is not one of the available agents (%s)", id, strings.Join(a.tools, ", ")))
+	}
+	if depth := toolDepth(ctx); depth >= tickets.DefaultMaxHops {
+		err := fmt.Errorf("agent %s: tool calls nested more than %d deep", a.id, depth)
+		call.Error = err.Error()
+		return "", err
+	}
+	sub, err := a.toolManager.Get(id)
+	if err != nil {
+		return fail(err)
+	}
+	if t != nil {
+		if err := t.Handoff(id); err != nil {
+			call.Error = err.Error()
+			return "", fmt.Errorf("agent %s calling %s: %w", a.id, id, err)
+		}
+	}
+
+	subCtx := context.WithValue(context.WithValue(ctx, toolCallKey{}, call), toolDepthKey{}, toolDepth(ctx)+1)
+	subCtx, subInput, err := PrepareInput(subCtx, sub, input, PromptVars{}, t)
+	if err != nil {
+		return fail(err)
+	}
+	logger.Info(fmt.Sprintf("Agent %s calling tool %s", a.id, id), false)
+	out, err := sub.Handle(subCtx, subInput, t)
+	if err != nil {
+		return fail(err)
+	}
+	call.Output = out
+	return out, nil
+}
+
+func (a *AgentBase) allowsTool(id string) bool {
+	for _, tool := range a.tools {
+		if tool == id {
+			return true
+		}
+	}
+	return false
+}
+
+// toolPreamble tells the model which agents it may call and how.
+func (a *AgentBase) toolPreamble() string {
+	var b strings.Builder
+	b.WriteString("You can call these agents as tools:\n")
+	for _, id := range a.tools {
+		desc := "(unavailable)"
+		if sub, err := a.toolManager.Get(id); err == nil {
+			desc = sub.Name()
+			if sub.Description() != "" {
+				desc += ": " + sub.Description()
+			}
+		}
+		fmt.Fprintf(&b, "- %s: %s\n", id, desc)
+	}
+	b.WriteString("To call one, reply with a single line of the form\nCALL <agent_id>: <input for the agent>\n")
+	b.WriteString("and nothing else. You will be sent its result. When you have what you need, reply with your answer.")
+	return b.String()
+}
+
+// parseToolCall extracts the agent ID and input from a "CALL id: input" reply.
+// The input runs to the end of the reply.
+func parseToolCall(resp string) (string, string, bool) {
+	loc := toolCallRe.FindStringSubmatchIndex(resp)
+	if loc == nil {
+		return "", "", false
+	}
+	return resp[loc[2]:loc[3]], strings.TrimSpace(resp[loc[1]:]), true
+}
+
+// toolDepth counts the tool calls enclosing ctx.
+func toolDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(toolDepthKey{}).(int)
+	return depth
+}
+
+type toolDepthKey struct{}
+
+// validateTools rejects empty, duplicate and self-referencing tool IDs and a negative call limit.
+func validateTools(id string, tools []string, maxCalls int) error {
+	if maxCalls < 0 {
+		return fmt.Errorf("max_tool_calls must not be negative")
+	}
+	seen := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		switch {
+		case tool == "":
+			return fmt.Errorf("tools must not contain empty entries")
+		case tool == id:
+			return fmt.Errorf("tools must not include the agent itself")
+		case seen[tool]:
+			return fmt.Errorf("tools lists %q more than once", tool)
+		}
+		seen[tool] = true
+	}
+	return nil
+}
+
+// recordToolCalls appends a call tree to tools.calls in the root agent's ticket namespace.
+func recordToolCalls(t *tickets.Ticket, root *ToolCall) {
+	var history []*ToolCall
+	if prev, ok := t.GetNamespaced(root.Agent, "tools.calls"); ok {
+		_ = json.Unmarshal([]byte(prev), &history)
+	}
+	history = append(history, root)
+	if data, err := json.Marshal(history); err == nil {
+		t.SetNamespaced(root.Agent, "tools.calls", string(data))
+	}
+}
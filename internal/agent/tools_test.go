@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"keystone/internal/providers"
+	"keystone/internal/tickets"
+
+	"github.com/stretchr/testify/require"
+)
+
+// scriptProvider replies with each scripted answer in turn, repeating the last one,
+// and records every prompt it was sent.
+type scriptProvider struct {
+	replies []string
+	prompts []string
+}
+
+func (p *scriptProvider) GenerateResponse(_ context.Context, prompt, _ string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	i := len(p.prompts) - 1
+	if i >= len(p.replies) {
+		i = len(p.replies) - 1
+	}
+	return p.replies[i], nil
+}
+
+func (p *scriptProvider) UsageInfo() (providers.Usage, error) { return providers.Usage{}, nil }
+
+func TestTools_CallsSubAgentOnSameTicket(t *testing.T) {
+	m := NewManager()
+	require.NoError(t, m.Register(NewAgent("summarizer", "Summarizer", "Condenses text", &MockProvider{}, "m", "none",
+		WithPromptTemplate("Summarize: {{input}}"))))
+	p := &scriptProvider{replies: []string{"Let me check.\nCALL summarizer: a long report", "The summary is in."}}
+	lead := NewAgent("lead", "Lead", "", p, "m", "none", WithTools(m, 0, "summarizer"))
+	require.NoError(t, m.Register(lead))
+
+	ticket := tickets.NewTicket("t1", "u1", nil)
+	resp, err := lead.Handle(context.Background(), "report on Q3", ticket)
+	require.NoError(t, err)
+	require.Equal(t, "The summary is in.", resp)
+
+	require.Len(t, p.prompts, 2)
+	require.Contains(t, p.prompts[0], "- summarizer: Summarizer: Condenses text")
+	require.Contains(t, p.prompts[0], "report on Q3")
+	require.Contains(t, p.prompts[1], "RESULT from summarizer:\nmock response: Summarize: a long report")
+	require.Equal(t, 1, ticket.Hops, "tool calls hand the ticket off")
+
+	var trees []ToolCall
+	require.NoError(t, json.Unmarshal([]byte(ticket.GetAllNamespaced("lead")["tools.calls"]), &trees))
+	require.Len(t, trees, 1)
+	require.Equal(t, "lead", trees[0].Agent)
+	require.Equal(t, "The summary is in.", trees[0].Output)
+	require.Len(t, trees[0].Calls, 1)
+	require.Equal(t, "summarizer", trees[0].Calls[0].Agent)
+	require.Equal(t, "a long report", trees[0].Calls[0].Input)
+}
+
+func TestTools_RecordsNestedCallTree(t *testing.T) {
+	m := NewManager()
+	require.NoError(t, m.Register(NewAgent("leaf", "Leaf", "", &MockProvider{}, "m", "none")))
+	mid := NewAgent("mid", "Mid", "", &scriptProvider{replies: []string{"CALL leaf: deep", "mid done"}}, "m", "none", WithTools(m, 0, "leaf"))
+	require.NoError(t, m.Register(mid))
+	top := NewAgent("top", "Top", "", &scriptProvider{replies: []string{"CALL mid: go", "CALL ghost: boo", "top done"}}, "m", "none", WithTools(m, 0, "mid"))
+	require.NoError(t, m.Register(top))
+
+	ticket := tickets.NewTicket("t1", "u1", nil)
+	resp, err := top.Handle(context.Background(), "start", ticket)
+	require.NoError(t, err)
+	require.Equal(t, "top done", resp)
+	require.Equal(t, 2, ticket.Hops)
+	_, ok := ticket.GetNamespaced("mid", "tools.calls")
+	require.False(t, ok, "only the outermost agent records the tree")
+
+	var trees []ToolCall
+	require.NoError(t, json.Unmarshal([]byte(ticket.GetAllNamespaced("top")["tools.calls"]), &trees))
+	root := trees[0]
+	require.Len(t, root.Calls, 2)
+	require.Equal(t, "mid", root.Calls[0].Agent)
+	require.Equal(t, "mid done", root.Calls[0].Output)
+	require.Len(t, root.Calls[0].Calls, 1)
+	require.Equal(t, "leaf", root.Calls[0].Calls[0].Agent)
+	require.Equal(t, "mock response: deep", root.Calls[0].Calls[0].Output)
+	require.Equal(t, "ghost", root.Calls[1].Agent)
+	require.Contains(t, root.Calls[1].Error, "not one of the available agents")
+}
+
+func TestTools_LimitsApply(t *testing.T) {
+	m := NewManager()
+	require.NoError(t, m.Register(NewAgent("echo", "Echo", "", &MockProvider{}, "m", "none")))
+	greedy := NewAgent("greedy", "Greedy", "", &scriptProvider{replies: []string{"CALL echo: again"}}, "m", "none", WithTools(m, 2, "echo"))
+	require.NoError(t, m.Register(greedy))
+
+	_, err := greedy.Handle(context.Background(), "go", nil)
+	require.ErrorContains(t, err, "exceeded its limit of 2 tool calls")
+
+	ticket := tickets.NewTicket("t1", "u1", nil)
+	ticket.MaxHops = 1
+	_, err = greedy.Handle(context.Background(), "go", ticket)
+	require.ErrorContains(t, err, "agent greedy calling echo")
+	require.Equal(t, 1, ticket.Hops)
+}
+
+func TestTools_ConfigValidationAndLoading(t *testing.T) {
+	cfg := AgentConfig{ID: "lead", Name: "Lead", Provider: "mock", Tools: []string{"echo", "echo"}}
+	require.ErrorContains(t, cfg.Validate(), `tools lists "echo" more than once`)
+	cfg.Tools = []string{"lead"}
+	require.ErrorContains(t, cfg.Validate(), "must not include the agent itself")
+	cfg.Tools, cfg.MaxToolCalls = []string{"echo"}, -1
+	require.ErrorContains(t, cfg.Validate(), "max_tool_calls must not be negative")
+
+	v := newTestValidator(t)
+	v.Index["echo"] = ConfigFile{Path: "echo.yaml", Config: AgentConfig{ID: "echo"}}
+	var got []string
+	for _, i := range v.Validate("lead.yaml", []byte("id: lead\nname: Lead\nprovider: mock\ntools: [echo, ghost]\n")) {
+		got = append(got, i.String())
+	}
+	require.Equal(t, []string{`lead.yaml:4: error: tool refers to unknown agent "ghost"`}, got)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "echo.yaml"), []byte("id: echo\nname: Echo\nprovider: mock\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lead.yaml"), []byte("id: lead\nname: Lead\nprovider: mock\ntools: [echo]\nmax_tool_calls: 3\n"), 0o644))
+	m := NewManager()
+	report, err := NewLoader(dir, nil).Load(m)
+	require.NoError(t, err)
+	require.NoError(t, report.Err())
+	lead, err := m.Get("lead")
+	require.NoError(t, err)
+	require.Equal(t, []string{"echo"}, lead.(*AgentBase).Tools())
+}
@@ -0,0 +1,604 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"keystone/internal/prompt"
+	"keystone/internal/providers"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity classifies a validation issue.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single problem found while validating an agent config file.
+type Issue struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Field    string   `json:"field,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// String formats the issue as "file:line: severity: message".
+func (i Issue) String() string {
+	loc := i.File
+	if i.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", i.File, i.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s", loc, i.Severity, i.Message)
+}
+
+// Validator checks agent config files against the AgentConfig schema and
+// against the providers, prompts and parent configs they refer to. Secrets are
+// the values ${secret:name} references must resolve to.
+type Validator struct {
+	Providers map[string]providers.Provider
+	Prompts   *prompt.Library
+	Index     ConfigIndex
+	Secrets   map[string]string
+}
+
+// NewValidator returns a validator using the built-in providers plus the
+// prompt library and agent configs that live alongside agentsDir.
+func NewValidator(agentsDir string) (*Validator, error) {
+	lib, err := prompt.LoadLibrary(PromptsDir(agentsDir))
+	if err != nil {
+		return nil, fmt.Errorf("loading prompt library: %w", err)
+	}
+	idx := ConfigIndex{}
+	if info, err := os.Stat(agentsDir); err == nil && info.IsDir() {
+		if idx, err = IndexConfigDir(agentsDir); err != nil {
+			return nil, err
+		}
+	}
+	return &Validator{Providers: DefaultProviders(), Prompts: lib, Index: idx}, nil
+}
+
+// ValidatePaths validates every agent config file in paths, descending into
+// directories. It also reports agent IDs defined by more than one file.
+// The returned count is the number of files checked.
+func (v *Validator) ValidatePaths(paths []string) ([]Issue, int, error) {
+	var files []string
+	for _, p := range paths {
+		found, err := configFiles(p)
+		if err != nil {
+			return nil, 0, err
+		}
+		files = append(files, found...)
+	}
+
+	// Index the files being checked too, so they can extend each other.
+	if v.Index == nil {
+		v.Index = ConfigIndex{}
+	}
+	for _, f := range files {
+		if cfg, err := ReadConfigFile(f); err == nil && cfg.ID != "" {
+			if _, ok := v.Index[cfg.ID]; !ok {
+				v.Index[cfg.ID] = ConfigFile{Path: f, Config: cfg}
+			}
+		}
+	}
+
+	var issues []Issue
+	seen := map[string]string{}
+	for _, f := range files {
+		cfg, fileIssues := v.validateFile(f)
+		issues = append(issues, fileIssues...)
+		if cfg.ID == "" {
+			continue
+		}
+		if first, dup := seen[cfg.ID]; dup {
+			issues = append(issues, Issue{
+				File: f, Line: 1, Field: "id", Severity: SeverityError,
+				Message: fmt.Sprintf("duplicate agent id %q (already defined in %s)", cfg.ID, first),
+			})
+			continue
+		}
+		seen[cfg.ID] = f
+	}
+	return issues, len(files), nil
+}
+
+// ValidateFile reads and validates the agent config at path.
+func (v *Validator) ValidateFile(path string) []Issue {
+	_, issues := v.validateFile(path)
+	return issues
+}
+
+// Validate checks a single agent config document. file is only used for reporting.
+func (v *Validator) Validate(file string, data []byte) []Issue {
+	_, issues := v.validate(file, data)
+	return issues
+}
+
+func (v *Validator) validateFile(path string) (AgentConfig, []Issue) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return AgentConfig{}, []Issue{{File: path, Severity: SeverityError, Message: err.Error()}}
+	}
+	return v.validate(path, data)
+}
+
+func (v *Validator) validate(file string, data []byte) (AgentConfig, []Issue) {
+	r := &report{file: file}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		r.yamlError(err)
+		return AgentConfig{}, r.sorted()
+	}
+	if len(doc.Content) == 0 {
+		r.errorf(0, "", "empty agent config")
+		return AgentConfig{}, r.sorted()
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		r.errorf(root.Line, "", "agent config must be a YAML mapping")
+		return AgentConfig{}, r.sorted()
+	}
+
+	r.lines = keyLines(root, "")
+	checkKeys(r, root, "", reflect.TypeOf(AgentConfig{}))
+
+	// Type errors still decode the remaining fields, so keep checking after reporting them.
+	var cfg AgentConfig
+	if err := root.Decode(&cfg); err != nil {
+		r.yamlError(err)
+		if _, ok := err.(*yaml.TypeError); !ok {
+			return cfg, r.sorted()
+		}
+	}
+
+	resolved := cfg
+	if cfg.Extends != "" {
+		var err error
+		if resolved, err = v.Index.Resolve(cfg); err != nil {
+			r.errorf(r.line("extends"), "extends", "%v", err)
+			resolved = cfg
+		}
+	}
+
+	_, missing := Vars{Secrets: v.Secrets}.Interpolate(&resolved)
+	for _, m := range missing {
+		top, _, _ := strings.Cut(m.Field, ".")
+		r.errorf(r.line(m.Field, top), m.Field, "%s %s", m.Ref, m.Reason)
+	}
+
+	for _, f := range []struct{ name, value string }{
+		{"id", resolved.ID},
+		{"name", resolved.Name},
+		{"provider", resolved.Provider},
+	} {
+		if f.value == "" {
+			r.errorf(r.line(f.name), f.name, "missing required field %q", f.name)
+		}
+	}
+
+	v.checkProvider(r, resolved)
+	v.checkPrompt(r, resolved)
+	checkParams(r, resolved)
+	v.checkRouter(r, resolved)
+	v.checkTools(r, resolved)
+	checkExamples(r, resolved)
+	v.checkGuardrails(r, resolved)
+	v.checkVoting(r, resolved)
+	checkLimits(r, resolved)
+	for _, f := range []struct {
+		name   string
+		labels []string
+	}{{"tags", resolved.Tags}, {"capabilities", resolved.Capabilities}} {
+		if err := validateLabels(f.name, f.labels); err != nil {
+			r.errorf(r.line(f.name), f.name, "%v", err)
+		}
+	}
+
+	if err := resolved.MemoryOptions.Validate(); err != nil {
+		r.errorf(r.line("memory_options.strategy", "memory_options"), "memory_options", "%v", err)
+	} else if resolved.MemoryOptions.Enabled() && resolved.Kind == KindReAct {
+		r.errorf(r.line("memory_options"), "memory_options", "react agents do not use memory_options")
+	}
+	return cfg, r.sorted()
+}
+
+// checkProvider verifies the provider is known and, when it publishes a catalog, that it serves the model.
+func (v *Validator) checkProvider(r *report, cfg AgentConfig) {
+	if cfg.Provider == "" {
+		return
+	}
+	p, ok := v.Providers[cfg.Provider]
+	if !ok {
+		r.errorf(r.line("provider"), "provider", "unknown provider %q (known: %s)",
+			cfg.Provider, strings.Join(providerNames(v.Providers), ", "))
+		return
+	}
+	catalog, ok := p.(providers.ModelCatalog)
+	if !ok || cfg.Model == "" {
+		return
+	}
+	for _, m := range catalog.Models() {
+		if m == cfg.Model {
+			return
+		}
+	}
+	r.warnf(r.line("model"), "model", "model %q is not in the %s catalog (%s)",
+		cfg.Model, cfg.Provider, strings.Join(catalog.Models(), ", "))
+}
+
+// checkPrompt resolves the prompt and ensures every required template variable has a parameter.
+func (v *Validator) checkPrompt(r *report, cfg AgentConfig) {
+	text, field := cfg.PromptTemplate, "prompt_template"
+	if cfg.PromptRef != "" {
+		field = "prompt_ref"
+		if v.Prompts == nil {
+			r.errorf(r.line(field), field, "prompt_ref %q set but no prompt library is loaded", cfg.PromptRef)
+			return
+		}
+		resolved, err := v.Prompts.Resolve(cfg.PromptRef)
+		if err != nil {
+			r.errorf(r.line(field), field, "%v", err)
+			return
+		}
+		text = resolved
+	}
+	if text == "" {
+		return
+	}
+
+	keys := append(sortedParamNames(cfg.Parameters), cfg.ParamSchema.Names()...)
+	vars, err := prompt.Inspect(text, keys...)
+	if err != nil {
+		r.errorf(r.line(field), field, "invalid prompt template: %v", err)
+		return
+	}
+	for _, name := range vars.Required {
+		_, set := cfg.Parameters[name]
+		_, declared := cfg.ParamSchema[name]
+		if !set && !declared {
+			r.errorf(r.line(field), field, "template variable %q is not set in parameters", name)
+		}
+	}
+}
+
+// checkParams validates the parameter schema and the configured parameter values against it.
+func checkParams(r *report, cfg AgentConfig) {
+	for _, name := range cfg.ParamSchema.Names() {
+		if err := cfg.ParamSchema[name].Validate(); err != nil {
+			path := "parameter_schema." + name
+			r.errorf(r.line(path, "parameter_schema"), path, "parameter %s: %v", name, err)
+		}
+	}
+	if len(cfg.ParamSchema) == 0 {
+		return
+	}
+	for _, name := range sortedParamNames(cfg.Parameters) {
+		path := "parameters." + name
+		spec, ok := cfg.ParamSchema[name]
+		if !ok {
+			r.errorf(r.line(path, "parameters"), path, "parameter %q is not declared in parameter_schema", name)
+			continue
+		}
+		if _, err := spec.Normalize(cfg.Parameters[name]); err != nil {
+			r.errorf(r.line(path, "parameters"), path, "parameter %s: %v", name, err)
+		}
+	}
+}
+
+// checkRouter validates the agent kind and its settings; for routers, every route must target a known agent.
+func (v *Validator) checkRouter(r *report, cfg AgentConfig) {
+	if !cfg.Router.IsZero() && cfg.Kind != KindRouter {
+		r.errorf(r.line("router"), "router", "router settings require kind: %s", KindRouter)
+	}
+	if !cfg.ReAct.IsZero() && cfg.Kind != KindReAct {
+		r.errorf(r.line("react"), "react", "react settings require kind: %s", KindReAct)
+	}
+	switch cfg.Kind {
+	case "":
+		return
+	case KindRouter:
+	case KindReAct:
+		checkReAct(r, cfg)
+		return
+	default:
+		r.errorf(r.line("kind"), "kind", "unknown kind %q", cfg.Kind)
+		return
+	}
+
+	if err := cfg.Router.Validate(); err != nil {
+		r.errorf(r.line("router.strategy", "router"), "router", "%v", err)
+	}
+	for _, route := range cfg.Router.Routes {
+		if route.Capability != "" && !v.capabilityDeclared(route.Capability) {
+			r.warnf(r.line("router.routes", "router"), "router.routes", "no known agent has capability %q", route.Capability)
+		}
+		if route.Agent == "" {
+			continue
+		}
+		if route.Agent == cfg.ID {
+			r.errorf(r.line("router.routes", "router"), "router.routes", "router %s cannot route to itself", cfg.ID)
+		} else if _, ok := v.Index[route.Agent]; !ok {
+			r.errorf(r.line("router.routes", "router"), "router.routes", "route to unknown agent %q", route.Agent)
+		}
+	}
+	if d := cfg.Router.Default; d != "" {
+		if _, ok := v.Index[d]; !ok {
+			r.errorf(r.line("router.default", "router"), "router.default", "default route to unknown agent %q", d)
+		}
+	}
+}
+
+// checkReAct validates the ReAct settings and warns when allowed_dir does not exist.
+func checkReAct(r *report, cfg AgentConfig) {
+	if err := cfg.ReAct.Validate(); err != nil {
+		r.errorf(r.line("react.tools", "react"), "react", "%v", err)
+	}
+	if dir := cfg.ReAct.AllowedDir; dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			r.warnf(r.line("react.allowed_dir", "react"), "react.allowed_dir", "allowed_dir %q is not a directory here", dir)
+		}
+	}
+}
+
+// checkTools ensures every agent listed in tools is known.
+func (v *Validator) checkTools(r *report, cfg AgentConfig) {
+	if len(cfg.Tools) == 0 && cfg.MaxToolCalls == 0 {
+		return
+	}
+	switch cfg.Kind {
+	case KindRouter:
+		r.errorf(r.line("tools"), "tools", "routers cannot declare tools")
+		return
+	case KindReAct:
+		r.errorf(r.line("tools"), "tools", "react agents use react.tools, not tools")
+		return
+	}
+	if err := validateTools(cfg.ID, cfg.Tools, cfg.MaxToolCalls); err != nil {
+		r.errorf(r.line("tools", "max_tool_calls"), "tools", "%v", err)
+		return
+	}
+	for _, tool := range cfg.Tools {
+		if _, ok := v.Index[tool]; !ok {
+			r.errorf(r.line("tools"), "tools", "tool refers to unknown agent %q", tool)
+		}
+	}
+}
+
+// checkExamples validates the few-shot examples and rejects a system prompt or
+// examples on routers and ReAct agents, which build their own prompts.
+func checkExamples(r *report, cfg AgentConfig) {
+	if cfg.SystemPrompt == "" && len(cfg.Examples) == 0 {
+		return
+	}
+	line := r.line("system_prompt", "examples")
+	switch cfg.Kind {
+	case KindRouter:
+		r.errorf(line, "system_prompt", "routers do not use system_prompt or examples")
+		return
+	case KindReAct:
+		r.errorf(line, "system_prompt", "react agents do not use system_prompt or examples")
+		return
+	}
+	if err := validateExamples(cfg.Examples); err != nil {
+		r.errorf(r.line("examples"), "examples", "%v", err)
+	}
+}
+
+// checkGuardrails validates the guardrail policies and that the classifier agent is
+// known, and rejects guardrails on routers, which hand every input to another agent,
+// and on ReAct agents, which run their own loop.
+func (v *Validator) checkGuardrails(r *report, cfg AgentConfig) {
+	g := cfg.Guardrails
+	if !g.IsZero() {
+		switch cfg.Kind {
+		case KindRouter:
+			r.errorf(r.line("guardrails"), "guardrails", "routers do not apply guardrails; set them on the routed agents")
+			return
+		case KindReAct:
+			r.errorf(r.line("guardrails"), "guardrails", "react agents do not apply guardrails")
+			return
+		}
+	}
+	if err := g.Validate(); err != nil {
+		r.errorf(r.line("guardrails"), "guardrails", "%v", err)
+	}
+	if g.Classifier == "" {
+		return
+	}
+	line := r.line("guardrails.classifier", "guardrails")
+	if g.Classifier == cfg.ID {
+		r.errorf(line, "guardrails.classifier", "agent %s cannot classify its own input", cfg.ID)
+	} else if _, ok := v.Index[g.Classifier]; !ok {
+		r.errorf(line, "guardrails.classifier", "classifier refers to unknown agent %q", g.Classifier)
+	}
+}
+
+// checkVoting validates the voting settings and that the scorer agent is known.
+func (v *Validator) checkVoting(r *report, cfg AgentConfig) {
+	vc := cfg.Voting
+	if vc.IsZero() {
+		return
+	}
+	line := r.line("voting")
+	switch cfg.Kind {
+	case KindRouter:
+		r.errorf(line, "voting", "routers do not vote")
+		return
+	case KindReAct:
+		r.errorf(line, "voting", "react agents do not vote")
+		return
+	}
+	if err := vc.Validate(); err != nil {
+		r.errorf(line, "voting", "%v", err)
+	}
+	if vc.Scorer == "" {
+		return
+	}
+	line = r.line("voting.scorer", "voting")
+	if vc.Scorer == cfg.ID {
+		r.errorf(line, "voting.scorer", "agent %s cannot score its own votes", cfg.ID)
+	} else if _, ok := v.Index[vc.Scorer]; !ok {
+		r.errorf(line, "voting.scorer", "scorer refers to unknown agent %q", vc.Scorer)
+	}
+}
+
+// checkLimits validates the timeout and max_concurrency settings.
+func checkLimits(r *report, cfg AgentConfig) {
+	if _, err := parseTimeout(cfg.Timeout); err != nil {
+		r.errorf(r.line("timeout"), "timeout", "%v", err)
+	}
+	if cfg.MaxConcurrency < 0 {
+		r.errorf(r.line("max_concurrency"), "max_concurrency", "max_concurrency must not be negative")
+	}
+}
+
+// capabilityDeclared reports whether any indexed agent config advertises capability.
+func (v *Validator) capabilityDeclared(capability string) bool {
+	for _, f := range v.Index {
+		cfg, err := v.Index.Resolve(f.Config)
+		if err == nil && containsAll(cfg.Capabilities, []string{capability}) {
+			return true
+		}
+	}
+	return false
+}
+
+// report accumulates issues for one file.
+type report struct {
+	file   string
+	lines  map[string]int
+	issues []Issue
+}
+
+func (r *report) add(line int, field string, sev Severity, msg string) {
+	r.issues = append(r.issues, Issue{File: r.file, Line: line, Field: field, Severity: sev, Message: msg})
+}
+
+func (r *report) errorf(line int, field, format string, args ...interface{}) {
+	r.add(line, field, SeverityError, fmt.Sprintf(format, args...))
+}
+
+func (r *report) warnf(line int, field, format string, args ...interface{}) {
+	r.add(line, field, SeverityWarning, fmt.Sprintf(format, args...))
+}
+
+// line returns the line of the first key path that is present. Inherited fields
+// point at the extends key, and anything else at the top of the file.
+func (r *report) line(paths ...string) int {
+	for _, p := range append(paths, "extends") {
+		if l, ok := r.lines[p]; ok {
+			return l
+		}
+	}
+	return 1
+}
+
+var yamlLineRe = regexp.MustCompile(`line (\d+): (.*)`)
+
+// yamlError converts a yaml.v3 parse or type error into issues, one per reported line.
+func (r *report) yamlError(err error) {
+	matches := yamlLineRe.FindAllStringSubmatch(err.Error(), -1)
+	if len(matches) == 0 {
+		r.errorf(0, "", "%v", err)
+		return
+	}
+	for _, m := range matches {
+		line, _ := strconv.Atoi(m[1])
+		r.errorf(line, "", "%s", m[2])
+	}
+}
+
+func (r *report) sorted() []Issue {
+	sort.SliceStable(r.issues, func(i, j int) bool { return r.issues[i].Line < r.issues[j].Line })
+	return r.issues
+}
+
+// keyLines maps dotted key paths in a mapping node to their line numbers.
+func keyLines(n *yaml.Node, prefix string) map[string]int {
+	lines := map[string]int{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		path := prefix + key.Value
+		lines[path] = key.Line
+		if val.Kind == yaml.MappingNode {
+			for k, l := range keyLines(val, path+".") {
+				lines[k] = l
+			}
+		}
+	}
+	return lines
+}
+
+// checkKeys reports keys in n that have no matching yaml field in t.
+// Nested structs, and lists and maps of structs such as router.routes and
+// parameter_schema, are checked recursively;
+// other maps such as parameters accept any key.
+func checkKeys(r *report, n *yaml.Node, prefix string, t reflect.Type) {
+	fields := yamlFields(t)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		ft, ok := fields[key.Value]
+		if !ok {
+			r.errorf(key.Line, prefix+key.Value, "unknown key %q", prefix+key.Value)
+			continue
+		}
+		if ft.Kind() == reflect.Struct && val.Kind == yaml.MappingNode {
+			checkKeys(r, val, prefix+key.Value+".", ft)
+		}
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct && val.Kind == yaml.SequenceNode {
+			for _, item := range val.Content {
+				if item.Kind == yaml.MappingNode {
+					checkKeys(r, item, prefix+key.Value+".", ft.Elem())
+				}
+			}
+		}
+		if ft.Kind() == reflect.Map && ft.Elem().Kind() == reflect.Struct && val.Kind == yaml.MappingNode {
+			for j := 0; j+1 < len(val.Content); j += 2 {
+				if val.Content[j+1].Kind == yaml.MappingNode {
+					checkKeys(r, val.Content[j+1], prefix+key.Value+"."+val.Content[j].Value+".", ft.Elem())
+				}
+			}
+		}
+	}
+}
+
+// yamlFields returns the YAML key names of t's fields mapped to their types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// configFiles returns path itself or, for a directory, every agent config file beneath it.
+func configFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	return discoverConfigFiles(path)
+}
@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"keystone/internal/prompt"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestValidator(t *testing.T) *Validator {
+	t.Helper()
+	lib, err := prompt.LoadLibrary(t.TempDir())
+	require.NoError(t, err)
+	return &Validator{Providers: DefaultProviders(), Prompts: lib, Index: ConfigIndex{}}
+}
+
+func TestValidator_ReportsEveryProblemWithLines(t *testing.T) {
+	v := newTestValidator(t)
+	doc := `id: bad
+name: Bad
+provdier: venice
+provider: openai
+prompt_template: "Hi {{who}} {{default \"x\" .Params.tone}}"
+memory_options:
+  strategy: forever
+  size: 3
+logging: maybe
+`
+	issues := v.Validate("bad.yaml", []byte(doc))
+
+	type loc struct {
+		line  int
+		field string
+	}
+	var got []loc
+	for _, i := range issues {
+		require.Equal(t, SeverityError, i.Severity, i.String())
+		got = append(got, loc{i.Line, i.Field})
+	}
+	require.Equal(t, []loc{
+		{3, "provdier"},
+		{4, "provider"},
+		{5, "prompt_template"},
+		{7, "memory_options"},
+		{8, "memory_options.size"},
+		{9, ""},
+	}, got)
+	require.Equal(t, `bad.yaml:5: error: template variable "who" is not set in parameters`, issues[2].String())
+}
+
+func TestValidator_ModelCatalogAndExtends(t *testing.T) {
+	v := newTestValidator(t)
+	v.Index["base"] = ConfigFile{Config: AgentConfig{ID: "base", Name: "Base", Provider: "venice", Model: "llama-3.3-70b"}}
+
+	issues := v.Validate("child.yaml", []byte("id: child\nextends: base\nprompt_template: \"{{topic}}\"\nparameters:\n  topic: go\n"))
+	require.Empty(t, issues)
+
+	issues = v.Validate("odd.yaml", []byte("id: odd\nextends: base\nmodel: gpt-9\n"))
+	require.Len(t, issues, 1)
+	require.Equal(t, SeverityWarning, issues[0].Severity)
+	require.Equal(t, 3, issues[0].Line)
+
+	issues = v.Validate("orphan.yaml", []byte("id: orphan\nextends: nobody\n"))
+	require.NotEmpty(t, issues)
+	require.Equal(t, "extends", issues[0].Field)
+	require.Equal(t, 2, issues[0].Line)
+}
+
+func TestValidator_SampleAgents(t *testing.T) {
+	// Sample models outside a provider's catalog, such as lookup-model, only warn.
+	issues, files, err := newTestValidator(t).ValidatePaths([]string{filepath.Join("..", "..", "agents")})
+	require.NoError(t, err)
+	require.NotZero(t, files)
+	for _, i := range issues {
+		require.Equal(t, SeverityWarning, i.Severity, i.String())
+	}
+}
+
+func TestValidator_ValidatePathsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	WriteTempAgentConfig(t, dir, "a1", "mock")
+	cfg := AgentConfig{ID: "a1", Name: "copy", Provider: "mock"}
+	WriteYAML(t, filepath.Join(dir, "copy.yaml"), cfg)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+	issues, files, err := newTestValidator(t).ValidatePaths([]string{dir})
+	require.NoError(t, err)
+	require.Equal(t, 2, files)
+	require.Len(t, issues, 1)
+	require.Contains(t, issues[0].Message, `duplicate agent id "a1"`)
+}
+
+func TestValidator_ParameterSchema(t *testing.T) {
+	v := newTestValidator(t)
+	doc := `id: typed
+name: Typed
+provider: mock
+prompt_template: "{{query}} x{{limit}}"
+parameters:
+  limit: lots
+  colour: red
+parameter_schema:
+  query:
+    required: true
+  limit:
+    type: int
+    maximum: 5
+  mode:
+    type: enum
+`
+	var got []string
+	for _, i := range v.Validate("typed.yaml", []byte(doc)) {
+		got = append(got, i.String())
+	}
+	require.Equal(t, []string{
+		`typed.yaml:6: error: parameter limit: "lots" is not an int`,
+		`typed.yaml:7: error: parameter "colour" is not declared in parameter_schema`,
+		`typed.yaml:13: error: unknown key "parameter_schema.limit.maximum"`,
+		`typed.yaml:14: error: parameter mode: enum must list its values`,
+	}, got)
+}
+
+func TestValidator_Router(t *testing.T) {
+	v := newTestValidator(t)
+	v.Index["echo"] = ConfigFile{Path: "echo.yaml", Config: AgentConfig{ID: "echo"}}
+	doc := `id: router
+name: Router
+kind: router
+provider: mock
+router:
+  strategy: keyword
+  default: nowhere
+  routes:
+    - agent: echo
+      keywords: [echo]
+    - agent: ghost
+      keyword: [boo]
+`
+	var got []string
+	for _, i := range v.Validate("router.yaml", []byte(doc)) {
+		got = append(got, i.String())
+	}
+	require.Equal(t, []string{
+		`router.yaml:6: error: router route ghost has no keywords`,
+		`router.yaml:7: error: default route to unknown agent "nowhere"`,
+		`router.yaml:8: error: route to unknown agent "ghost"`,
+		`router.yaml:12: error: unknown key "router.routes.keyword"`,
+	}, got)
+
+	doc = "id: guarded\nname: Guarded\nkind: router\nprovider: mock\nrouter:\n  strategy: llm\nguardrails:\n  input:\n    max_length: 10\n"
+	issues := v.Validate("guarded.yaml", []byte(doc))
+	require.Len(t, issues, 1)
+	require.Equal(t, `guarded.yaml:7: error: routers do not apply guardrails; set them on the routed agents`, issues[0].String())
+}
+
+func TestValidator_ReAct(t *testing.T) {
+	v := newTestValidator(t)
+	doc := `id: researcher
+name: Researcher
+kind: react
+provider: mock
+tools: [echo]
+react:
+  tools: [read_file, calculator]
+  allowed_dir: ./does-not-exist
+`
+	var got []string
+	for _, i := range v.Validate("researcher.yaml", []byte(doc)) {
+		got = append(got, i.String())
+	}
+	require.Equal(t, []string{
+		`researcher.yaml:5: error: react agents use react.tools, not tools`,
+		`researcher.yaml:8: warning: allowed_dir "./does-not-exist" is not a directory here`,
+	}, got)
+
+	doc = "id: guarded\nname: Guarded\nkind: react\nprovider: mock\nreact:\n  tools: [calculator]\nguardrails:\n  input:\n    max_length: 10\nmemory_options:\n  strategy: window\n"
+	got = nil
+	for _, i := range v.Validate("guarded.yaml", []byte(doc)) {
+		got = append(got, i.String())
+	}
+	require.Equal(t, []string{
+		`guarded.yaml:7: error: react agents do not apply guardrails`,
+		`guarded.yaml:10: error: react agents do not use memory_options`,
+	}, got)
+
+	doc = "id: plain\nname: Plain\nprovider: mock\nreact:\n  tools: [calculator]\n"
+	issues := v.Validate("plain.yaml", []byte(doc))
+	require.Len(t, issues, 1)
+	require.Equal(t, `plain.yaml:4: error: react settings require kind: react`, issues[0].String())
+}
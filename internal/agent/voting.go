@@ -0,0 +1,73 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"keystone/internal/tickets"
+	"keystone/internal/voting"
+)
+
+// WithVoting makes the agent sample several responses to each input and answer with
+// the winner of a vote among them. score rates answers when cfg picks by score.
+func WithVoting(cfg voting.Config, score voting.Scorer) AgentOption {
+	return func(a *AgentBase) {
+		a.voting = cfg
+		a.voteScorer = score
+	}
+}
+
+// Voting returns the agent's voting settings; they are zero when it does not vote.
+func (a *AgentBase) Voting() voting.Config { return a.voting }
+
+// VoteScorer asks the agent with the given ID, looked up in manager when an answer is
+// scored, to rate answers from 0 to 10. Like guardrail classifiers, scoring runs
+// without a ticket so it does not spend the caller's hops.
+func VoteScorer(manager *AgentManager, id string) voting.Scorer {
+	return func(ctx context.Context, input, answer string) (float64, error) {
+		scorer, err := manager.Get(id)
+		if err != nil {
+			return 0, err
+		}
+		question := fmt.Sprintf("Rate how well the answer below responds to the input, from 0 to 10.\n"+
+			"Reply with the number only.\n\nInput:\n%s\n\nAnswer:\n%s", input, answer)
+		reply, err := scorer.Handle(ctx, question, nil)
+		if err != nil {
+			return 0, err
+		}
+		return voting.ParseScore(reply)
+	}
+}
+
+// answer responds to text, or, when the agent votes, samples several responses in
+// parallel and returns the winner. Each sample works on its own branch of the ticket
+// and traces its tool calls separately; every sample's hops are charged to the ticket,
+// but only the winner's calls and context are kept. Each vote is recorded on the ticket.
+func (a *AgentBase) answer(ctx context.Context, text string, t *tickets.Ticket, onChunk func(string)) (string, error) {
+	if a.voting.IsZero() {
+		return a.respond(ctx, text, t, onChunk)
+	}
+	parent, nested := ctx.Value(toolCallKey{}).(*ToolCall)
+	nodes := make([]*ToolCall, a.voting.SampleCount())
+	res, err := voting.RunTicket(ctx, a.voting, text, t, func(ctx context.Context, i int, model string, t *tickets.Ticket) (string, error) {
+		if nested {
+			nodes[i] = &ToolCall{Agent: parent.Agent}
+			ctx = context.WithValue(ctx, toolCallKey{}, nodes[i])
+		}
+		return a.respond(WithModelOverride(ctx, model), text, t, nil)
+	}, a.voteScorer)
+	if t != nil && res != nil {
+		res.Record(t, a.id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("agent %s voting: %w", a.id, err)
+	}
+	if nested {
+		parent.Calls = append(parent.Calls, nodes[res.AnswerIndex()].Calls...)
+	}
+	if onChunk != nil {
+		onChunk(res.Answer)
+	}
+	return res.Answer, nil
+}
@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"keystone/internal/providers"
+	"keystone/internal/tickets"
+	"keystone/internal/voting"
+
+	"github.com/stretchr/testify/require"
+)
+
+// modelProvider replies with the answer scripted for the requested model.
+type modelProvider struct{ replies map[string]string }
+
+func (p *modelProvider) GenerateResponse(_ context.Context, _, model string) (string, error) {
+	return p.replies[model], nil
+}
+
+func (p *modelProvider) UsageInfo() (providers.Usage, error) { return providers.Usage{}, nil }
+
+func TestVoting_AroundHandle(t *testing.T) {
+	p := &modelProvider{replies: map[string]string{"small": "negative", "large": "Positive", "base": "positive"}}
+	a := NewAgent("sentiment", "Sentiment", "", p, "base", "none",
+		WithVoting(voting.Config{Models: []string{"small", "large", "", "small"}}, nil))
+
+	tk := tickets.NewTicket("t1", "default", nil)
+	var chunks []string
+	resp, err := a.(*AgentBase).HandleStream(context.Background(), "great product", tk, func(c string) { chunks = append(chunks, c) })
+	require.NoError(t, err)
+	// Two positive votes (one from the default model) tie two negative ones; the first answer given wins.
+	require.Equal(t, "negative", resp)
+	require.Equal(t, []string{"negative"}, chunks)
+
+	raw, ok := tk.GetNamespaced("sentiment", "voting.votes")
+	require.True(t, ok)
+	var votes []voting.Result
+	require.NoError(t, json.Unmarshal([]byte(raw), &votes))
+	require.Len(t, votes, 1)
+	require.Equal(t, map[string]int{"positive": 2, "negative": 2}, votes[0].Votes)
+}
+
+func TestVoting_LoaderWiresScorer(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"judge.yaml": "id: judge\nname: Judge\nprovider: mock\n",
+		"bot.yaml": `id: bot
+name: Bot
+provider: mock
+voting:
+  samples: 2
+  pick: score
+  scorer: judge
+`,
+	}
+	for name, body := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+	}
+	m := NewManager()
+	report, err := NewLoader(dir, nil).Load(m)
+	require.NoError(t, err)
+	require.NoError(t, report.Err())
+
+	judge := &replyProvider{reply: "8"}
+	m.Replace(NewAgent("judge", "Judge", "", judge, "m", "none"))
+	bot, err := m.Get("bot")
+	require.NoError(t, err)
+	tk := tickets.NewTicket("t1", "default", nil)
+	resp, err := bot.Handle(context.Background(), "hello", tk)
+	require.NoError(t, err)
+	require.Equal(t, "mock response: hello", resp)
+	require.Contains(t, judge.prompt, "Answer:\nmock response: hello")
+	require.Zero(t, tk.Hops, "scoring should not spend ticket hops")
+}
+
+func TestVoting_Validate(t *testing.T) {
+	cfg := AgentConfig{ID: "bot", Name: "Bot", Provider: "mock", Voting: voting.Config{Pick: voting.PickScore, Scorer: "bot"}}
+	require.ErrorContains(t, cfg.Validate(), "cannot score its own votes")
+
+	cfg = AgentConfig{ID: "bot", Name: "Bot", Provider: "mock", Voting: voting.Config{Samples: 1}}
+	require.ErrorContains(t, cfg.Validate(), "at least two samples")
+
+	v := newTestValidator(t)
+	doc := `id: bot
+name: Bot
+provider: mock
+voting:
+  match: json
+  scorer: ghost
+`
+	var got []string
+	for _, i := range v.Validate("bot.yaml", []byte(doc)) {
+		got = append(got, i.String())
+	}
+	require.Equal(t, []string{
+		`bot.yaml:4: error: voting match: json requires a field`,
+		`bot.yaml:6: error: scorer refers to unknown agent "ghost"`,
+	}, got)
+}
+
+// toolVoteProvider calls the lookup tool once, then answers by model. It keeps no
+// state, so samples can share it.
+type toolVoteProvider struct{}
+
+func (toolVoteProvider) GenerateResponse(_ context.Context, prompt, model string) (string, error) {
+	if strings.Contains(prompt, "RESULT from lookup") {
+		return map[string]string{"a": "yes", "b": "no"}[model], nil
+	}
+	return "CALL lookup: " + model, nil
+}
+
+func (toolVoteProvider) UsageInfo() (providers.Usage, error) { return providers.Usage{}, nil }
+
+func TestVoting_SamplesWithToolsAreIsolated(t *testing.T) {
+	m := NewManager()
+	require.NoError(t, m.Register(NewAgent("lookup", "Lookup", "", &modelProvider{replies: map[string]string{"a": "fact a", "b": "fact b"}}, "m", "none")))
+	require.NoError(t, m.Register(NewAgent("voter", "Voter", "", toolVoteProvider{}, "m", "none",
+		WithTools(m, 0, "lookup"), WithVoting(voting.Config{Models: []string{"a", "b", "a"}}, nil))))
+	top := NewAgent("top", "Top", "", &scriptProvider{replies: []string{"CALL voter: decide", "top done"}}, "m", "none", WithTools(m, 0, "voter"))
+
+	voter, err := m.Get("voter")
+	require.NoError(t, err)
+	tk := tickets.NewTicket("t1", "default", nil)
+	resp, err := voter.Handle(context.Background(), "decide", tk)
+	require.NoError(t, err)
+	require.Equal(t, "yes", resp)
+	require.Equal(t, 3, tk.Hops, "every sample's tool call should spend a hop")
+	var trees []ToolCall
+	require.NoError(t, json.Unmarshal([]byte(tk.GetAllNamespaced("voter")["tools.calls"]), &trees))
+	require.Len(t, trees, 1)
+	require.Len(t, trees[0].Calls, 1)
+	require.Equal(t, "a", trees[0].Calls[0].Input)
+
+	// Voting inside a tool call traces only the winner's calls under the caller's node.
+	tk = tickets.NewTicket("t2", "default", nil)
+	resp, err = top.Handle(context.Background(), "go", tk)
+	require.NoError(t, err)
+	require.Equal(t, "top done", resp)
+	require.Equal(t, 4, tk.Hops)
+	trees = nil
+	require.NoError(t, json.Unmarshal([]byte(tk.GetAllNamespaced("top")["tools.calls"]), &trees))
+	require.Len(t, trees, 1)
+	require.Len(t, trees[0].Calls, 1)
+	require.Equal(t, "voter", trees[0].Calls[0].Agent)
+	require.Len(t, trees[0].Calls[0].Calls, 1)
+	require.Equal(t, "lookup", trees[0].Calls[0].Calls[0].Agent)
+}
@@ -0,0 +1,247 @@
+// Package agent provides base implementations and helpers for AI agents.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"keystone/internal/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultReloadDebounce is how long a Watcher waits for file changes to settle before reloading.
+const DefaultReloadDebounce = 200 * time.Millisecond
+
+// Reload actions reported in ReloadEvents.
+const (
+	ReloadAdded   = "added"
+	ReloadUpdated = "updated"
+	ReloadRemoved = "removed"
+	ReloadFailed  = "failed"
+)
+
+// ReloadEvent describes one change a Watcher applied to, or rejected for, the manager.
+type ReloadEvent struct {
+	Action string `json:"action"`
+	ID     string `json:"id,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Err    error  `json:"-"`
+}
+
+// String formats the event for logs and CLI output.
+func (e ReloadEvent) String() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Action, e.Err)
+	}
+	return fmt.Sprintf("%s agent %s (%s)", e.Action, e.ID, e.Path)
+}
+
+// Watcher keeps an AgentManager in sync with the agent configs on disk.
+// Changed agents are swapped in atomically with AgentManager.Replace, deleted
+// ones are unregistered, and an agent whose file becomes invalid keeps its last
+// good version. Only agents loaded by the watcher are ever replaced or removed.
+type Watcher struct {
+	loader   *Loader
+	manager  *AgentManager
+	Debounce time.Duration
+	OnEvent  func(ReloadEvent)
+
+	mu     sync.Mutex
+	loaded map[string]resolvedConfig
+}
+
+// NewWatcher creates a watcher that reloads loader's directory into manager.
+func NewWatcher(loader *Loader, manager *AgentManager) *Watcher {
+	return &Watcher{
+		loader:   loader,
+		manager:  manager,
+		Debounce: DefaultReloadDebounce,
+		loaded:   make(map[string]resolvedConfig),
+	}
+}
+
+// Reload re-reads the directory and applies any differences to the manager.
+// Events are logged and passed to OnEvent once the manager has been updated.
+func (w *Watcher) Reload() ([]ReloadEvent, error) {
+	events, err := w.apply()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		// Failures were already logged by the loader.
+		if e.Action != ReloadFailed {
+			logger.Info("Agent reload: "+e.String(), false)
+		}
+		if w.OnEvent != nil {
+			w.OnEvent(e)
+		}
+	}
+	return events, nil
+}
+
+// apply syncs the manager with the directory and returns what changed.
+func (w *Watcher) apply() ([]ReloadEvent, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	report := &LoadReport{Dir: w.loader.dir}
+	configs, err := w.loader.resolveDir(report)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []ReloadEvent
+	failedIDs, failedPaths := map[string]bool{}, map[string]bool{}
+	for _, e := range report.Errors {
+		events = append(events, ReloadEvent{Action: ReloadFailed, ID: e.ID, Path: e.Path, Err: errors.New(e.Reason)})
+		failedIDs[e.ID] = true
+		failedPaths[e.Path] = true
+	}
+
+	next := make(map[string]resolvedConfig, len(configs))
+	for _, rc := range configs {
+		id := rc.Config.ID
+		prev, known := w.loaded[id]
+		if known && reflect.DeepEqual(prev.Config, rc.Config) {
+			next[id] = prev
+			continue
+		}
+		a, err := w.loader.newAgent(rc, w.manager)
+		if err != nil {
+			events = append(events, ReloadEvent{Action: ReloadFailed, ID: id, Path: rc.Path, Err: err})
+			if known {
+				next[id] = prev
+			}
+			continue
+		}
+		w.manager.Replace(a)
+		next[id] = rc
+		action := ReloadAdded
+		if known {
+			action = ReloadUpdated
+		}
+		events = append(events, ReloadEvent{Action: action, ID: id, Path: rc.Path})
+	}
+
+	for _, id := range sortedKeys(w.loaded) {
+		prev := w.loaded[id]
+		if _, ok := next[id]; ok {
+			continue
+		}
+		if failedIDs[id] || failedPaths[prev.Path] {
+			next[id] = prev // keep serving the last good version
+			continue
+		}
+		_ = w.manager.Unregister(id)
+		events = append(events, ReloadEvent{Action: ReloadRemoved, ID: id, Path: prev.Path})
+	}
+	w.loaded = next
+	return events, nil
+}
+
+// Run loads the directory and then watches it, along with the prompt library,
+// until ctx is cancelled. Bursts of file events are coalesced by Debounce.
+func (w *Watcher) Run(ctx context.Context) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("starting agent watcher: %w", err)
+	}
+	defer fw.Close()
+
+	for _, dir := range []string{w.loader.dir, PromptsDir(w.loader.dir)} {
+		if err := watchTree(fw, dir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("watching %s: %w", dir, err)
+		}
+	}
+	// The initial load is not reported as a series of "added" events.
+	if _, err := w.apply(); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Watching %d agent(s) in %s for changes", w.Count(), w.loader.dir), false)
+
+	timer := time.NewTimer(w.Debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					_ = watchTree(fw, ev.Name)
+				}
+			}
+			if relevantChange(ev.Name) {
+				timer.Reset(w.Debounce)
+			}
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Warn(fmt.Sprintf("Agent watcher error: %v", err), false)
+		case <-timer.C:
+			if _, err := w.Reload(); err != nil {
+				logger.Error(fmt.Sprintf("Agent reload failed: %v", err), false)
+			}
+		}
+	}
+}
+
+// Count returns the number of agents the watcher currently manages.
+func (w *Watcher) Count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.loaded)
+}
+
+// watchTree adds dir and its non-hidden subdirectories to fw.
+func watchTree(fw *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return fw.Add(path)
+	})
+}
+
+// relevantChange filters out editor swap files and other noise.
+func relevantChange(path string) bool {
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, ".") || strings.HasSuffix(base, "~") {
+		return false
+	}
+	switch filepath.Ext(base) {
+	case ".yaml", ".yml", ".tmpl", "":
+		return true
+	}
+	return false
+}
+
+func sortedKeys(m map[string]resolvedConfig) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
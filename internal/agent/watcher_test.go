@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func actions(events []ReloadEvent) []string {
+	out := make([]string, len(events))
+	for i, e := range events {
+		out[i] = e.Action + ":" + e.ID
+	}
+	return out
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	dir := t.TempDir()
+	WriteYAML(t, filepath.Join(dir, "a.yaml"), AgentConfig{ID: "a", Name: "A", Provider: "mock"})
+	WriteYAML(t, filepath.Join(dir, "b.yaml"), AgentConfig{ID: "b", Name: "B", Provider: "mock"})
+
+	manager := NewManager()
+	require.NoError(t, manager.Register(BuildTestAgent("outside", "Outside")))
+	w := NewWatcher(NewLoader(dir, nil), manager)
+
+	events, err := w.Reload()
+	require.NoError(t, err)
+	require.Equal(t, []string{"added:a", "added:b"}, actions(events))
+
+	// Unchanged files produce no events; an agent held by a caller is not disturbed by a swap.
+	events, err = w.Reload()
+	require.NoError(t, err)
+	require.Empty(t, events)
+	held, err := manager.Get("a")
+	require.NoError(t, err)
+
+	WriteYAML(t, filepath.Join(dir, "a.yaml"), AgentConfig{ID: "a", Name: "A2", Provider: "mock"})
+	require.NoError(t, os.Remove(filepath.Join(dir, "b.yaml")))
+	events, err = w.Reload()
+	require.NoError(t, err)
+	require.Equal(t, []string{"updated:a", "removed:b"}, actions(events))
+
+	require.Equal(t, "A", held.Name())
+	current, err := manager.Get("a")
+	require.NoError(t, err)
+	require.Equal(t, "A2", current.Name())
+	_, err = manager.Get("b")
+	require.Error(t, err)
+	_, err = manager.Get("outside")
+	require.NoError(t, err, "agents not loaded by the watcher are left alone")
+
+	// A broken edit keeps the last good version registered.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("{ broken"), 0o644))
+	events, err = w.Reload()
+	require.NoError(t, err)
+	require.Equal(t, []string{"failed:"}, actions(events))
+	current, err = manager.Get("a")
+	require.NoError(t, err)
+	require.Equal(t, "A2", current.Name())
+}
+
+func TestWatcher_RunReloadsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	WriteYAML(t, filepath.Join(dir, "a.yaml"), AgentConfig{ID: "a", Name: "A", Provider: "mock"})
+
+	manager := NewManager()
+	w := NewWatcher(NewLoader(dir, nil), manager)
+	w.Debounce = 20 * time.Millisecond
+	events := make(chan ReloadEvent, 10)
+	w.OnEvent = func(e ReloadEvent) { events <- e }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return w.Count() == 1 }, 2*time.Second, 10*time.Millisecond)
+	WriteYAML(t, filepath.Join(dir, "b.yaml"), AgentConfig{ID: "b", Name: "B", Provider: "mock"})
+
+	select {
+	case e := <-events:
+		require.Equal(t, ReloadAdded, e.Action)
+		require.Equal(t, "b", e.ID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload event")
+	}
+	_, err := manager.Get("b")
+	require.NoError(t, err)
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,148 @@
+package agenttest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExpectCheck(t *testing.T) {
+	eq := "hello world"
+	e := Expect{
+		Equals:      &eq,
+		Contains:    []string{"hello", "moon"},
+		NotContains: []string{"world"},
+		Regex:       `^\d+$`,
+		MaxTokens:   1,
+	}
+	got := e.Check("hello world", nil)
+	want := []string{
+		`contains: "moon" not found`,
+		`not_contains: "world" found`,
+		`regex: "^\\d+$" does not match`,
+		"max_tokens: about 3 tokens, limit 1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("failures:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if got := (Expect{Error: "boom"}).Check("", errors.New("it went boom")); got != nil {
+		t.Errorf("expected error should pass, got %v", got)
+	}
+	if got := (Expect{Error: "boom"}).Check("fine", nil); len(got) != 1 {
+		t.Errorf("missing error should fail, got %v", got)
+	}
+	if got := (Expect{}).Check("", errors.New("down")); len(got) != 1 || got[0] != "agent failed: down" {
+		t.Errorf("unexpected error should fail, got %v", got)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	out := "```json\n{\"items\": [{\"name\": \"a\", \"n\": 2}], \"ok\": true}\n```"
+	e := Expect{JSONPath: map[string]string{
+		"$.items[0].name": "a",
+		"items.0.n":       "2",
+		"$.ok":            "true",
+	}}
+	if got := e.Check(out, nil); got != nil {
+		t.Errorf("unexpected failures: %v", got)
+	}
+
+	e.JSONPath = map[string]string{"$.items[3].name": "a", "$.missing": "x"}
+	got := e.Check(out, nil)
+	if len(got) != 2 || !strings.Contains(got[0], "index \"3\" out of range") || !strings.Contains(got[1], `key "missing" not found`) {
+		t.Errorf("unexpected failures: %v", got)
+	}
+	if got := e.Check("not json", nil); len(got) != 1 || !strings.Contains(got[0], "output is not JSON") {
+		t.Errorf("unexpected failures: %v", got)
+	}
+}
+
+func TestLoadSuiteValidates(t *testing.T) {
+	dir := t.TempDir()
+	s, err := LoadSuite(writeFile(t, dir, "echo.yaml", "cases:\n  - name: one\n    input: hi\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Agent != "echo" {
+		t.Errorf("agent should default to the file name, got %q", s.Agent)
+	}
+
+	for body, want := range map[string]string{
+		"cases: []\n":                                                   "no cases",
+		"cases:\n  - name: a\n  - name: a\n":                            `case "a" is defined more than once`,
+		"cases:\n  - name: a\n    expect: {regex: \"(\"}\n":             "regex:",
+		"cases:\n  - name: a\n    expect: {error: x, equals: y}\n":      "error cannot be combined",
+		"cases:\n  - name: a\n    expect: {equal: y}\n":                 "field equal not found",
+		"cases:\n  - name: a\n    expect: {json_path: {\"a..b\": x}}\n": "invalid json_path",
+	} {
+		if _, err := LoadSuite(writeFile(t, dir, "bad.yaml", body)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadSuite(%q) = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestRunnerMockAndJUnit(t *testing.T) {
+	agents := t.TempDir()
+	writeFile(t, agents, "shout.yaml", "id: shout\nname: Shout\nprovider: venice\nprompt_template: \"{{input | upper}}\"\n")
+
+	suite := &Suite{Agent: "shout", Cases: []Case{
+		{Name: "echo", Input: "hi", Expect: Expect{Contains: []string{"mock response: HI"}}},
+		{Name: "scripted", Input: "hi", MockResponses: []string{"HELLO"}, Expect: Expect{Contains: []string{"BYE"}}},
+	}}
+	res := (&Runner{AgentsDir: agents}).Run(context.Background(), suite)
+	if res.Failed() != 1 || !res.Cases[0].Passed || res.Cases[1].Output != "HELLO" {
+		t.Fatalf("unexpected results: %+v", res)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, []SuiteResult{res}); err != nil {
+		t.Fatal(err)
+	}
+	xml := buf.String()
+	for _, want := range []string{
+		`<testsuites tests="2" failures="1"`,
+		`<testsuite name="agent.shout" tests="2" failures="1"`,
+		`<failure message="contains: &#34;BYE&#34; not found">`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("JUnit report missing %q:\n%s", want, xml)
+		}
+	}
+
+	live := (&Runner{AgentsDir: agents, ForceLive: true}).Run(context.Background(), suite)
+	if live.Cases[0].Failures[0] != "agent failed: live agents are not available" {
+		t.Errorf("unexpected live failure: %v", live.Cases[0].Failures)
+	}
+}
+
+func TestRunnerIsolatesMemory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("KEYSTONE_MEMORY_DIR", home)
+	agents := t.TempDir()
+	writeFile(t, agents, "recall.yaml", "id: recall\nname: Recall\nprovider: venice\nmemory: chat\nmemory_options:\n  strategy: buffer\n")
+
+	suite := &Suite{Agent: "recall", Cases: []Case{{Name: "first", Input: "remember me"}}}
+	runner := &Runner{AgentsDir: agents}
+	first := runner.Run(context.Background(), suite)
+	second := runner.Run(context.Background(), suite)
+	if first.Cases[0].Output != "mock response: remember me" || second.Cases[0].Output != first.Cases[0].Output {
+		t.Fatalf("second run saw the first run's history: %q then %q", first.Cases[0].Output, second.Cases[0].Output)
+	}
+	if entries, _ := os.ReadDir(home); len(entries) != 0 {
+		t.Errorf("suite left memory in the user's memory dir: %v", entries)
+	}
+}
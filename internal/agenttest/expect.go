@@ -0,0 +1,133 @@
+package agenttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"keystone/internal/memory"
+)
+
+// Check returns a description of every assertion output or runErr fails.
+func (e Expect) Check(output string, runErr error) []string {
+	if e.Error != "" {
+		switch {
+		case runErr == nil:
+			return []string{fmt.Sprintf("expected an error containing %q, got output %q", e.Error, output)}
+		case !strings.Contains(runErr.Error(), e.Error):
+			return []string{fmt.Sprintf("expected an error containing %q, got %q", e.Error, runErr.Error())}
+		}
+		return nil
+	}
+	if runErr != nil {
+		return []string{"agent failed: " + runErr.Error()}
+	}
+
+	var failures []string
+	fail := func(format string, args ...interface{}) { failures = append(failures, fmt.Sprintf(format, args...)) }
+
+	if e.Equals != nil && strings.TrimSpace(output) != strings.TrimSpace(*e.Equals) {
+		fail("equals: expected %q, got %q", *e.Equals, output)
+	}
+	for _, s := range e.Contains {
+		if !strings.Contains(output, s) {
+			fail("contains: %q not found", s)
+		}
+	}
+	for _, s := range e.NotContains {
+		if strings.Contains(output, s) {
+			fail("not_contains: %q found", s)
+		}
+	}
+	if e.Regex != "" && !regexp.MustCompile(e.Regex).MatchString(output) {
+		fail("regex: %q does not match", e.Regex)
+	}
+	if len(e.JSONPath) > 0 {
+		failures = append(failures, checkJSONPaths(output, e.JSONPath)...)
+	}
+	if n := memory.EstimateTokens(output); e.MaxTokens > 0 && n > e.MaxTokens {
+		fail("max_tokens: about %d tokens, limit %d", n, e.MaxTokens)
+	}
+	return failures
+}
+
+func checkJSONPaths(output string, want map[string]string) []string {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(stripFence(output)), &doc); err != nil {
+		return []string{fmt.Sprintf("json_path: output is not JSON: %v", err)}
+	}
+	var failures []string
+	for _, path := range sortedKeys(want) {
+		got, err := lookup(doc, path)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("json_path %s: %v", path, err))
+		} else if got != want[path] {
+			failures = append(failures, fmt.Sprintf("json_path %s: expected %q, got %q", path, want[path], got))
+		}
+	}
+	return failures
+}
+
+// stripFence removes a surrounding ``` code fence, which models often add around JSON.
+func stripFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
+
+// parsePath splits a path such as "$.items[0].name" or "items.0.name" into keys and indexes.
+func parsePath(path string) ([]string, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	p = strings.NewReplacer("[", ".", "]", "").Replace(p)
+	if p == "" {
+		return nil, nil
+	}
+	parts := strings.Split(p, ".")
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid json_path %q", path)
+		}
+	}
+	return parts, nil
+}
+
+// lookup walks doc along path and renders the value found: strings as-is,
+// anything else as compact JSON.
+func lookup(doc interface{}, path string) (string, error) {
+	parts, err := parsePath(path)
+	if err != nil {
+		return "", err
+	}
+	cur := doc
+	for _, part := range parts {
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			v, ok := node[part]
+			if !ok {
+				return "", fmt.Errorf("key %q not found", part)
+			}
+			cur = v
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", fmt.Errorf("index %q out of range (length %d)", part, len(node))
+			}
+			cur = node[i]
+		default:
+			return "", fmt.Errorf("cannot descend into %v at %q", cur, part)
+		}
+	}
+	if s, ok := cur.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(cur)
+	return string(data), err
+}
@@ -0,0 +1,76 @@
+package agenttest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes results as JUnit XML, one testsuite per agent, for CI test reports.
+func WriteJUnit(w io.Writer, results []SuiteResult) error {
+	doc := junitSuites{}
+	var total float64
+	for _, r := range results {
+		s := junitSuite{
+			Name:     "agent." + r.Agent,
+			Tests:    len(r.Cases),
+			Failures: r.Failed(),
+			Time:     seconds(r.Duration.Seconds()),
+		}
+		for _, c := range r.Cases {
+			jc := junitCase{Name: c.Name, Classname: s.Name, Time: seconds(c.Duration.Seconds()), SystemOut: c.Output}
+			if !c.Passed {
+				jc.Failure = &junitFailure{Message: c.Failures[0], Text: strings.Join(c.Failures, "\n")}
+			}
+			s.Cases = append(s.Cases, jc)
+		}
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+		total += r.Duration.Seconds()
+		doc.Suites = append(doc.Suites, s)
+	}
+	doc.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(s float64) string { return fmt.Sprintf("%.3f", s) }
@@ -0,0 +1,162 @@
+package agenttest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"keystone/internal/agent"
+	"keystone/internal/memory"
+	"keystone/internal/providers"
+	"keystone/internal/tickets"
+)
+
+// Runner executes suites. Mock suites load every agent in AgentsDir with each
+// provider replaced by a scripted mock, fresh for every case; live suites use
+// the agents registered in Live. Secrets resolve ${secret:name} references when
+// loading mock agents.
+type Runner struct {
+	AgentsDir string
+	Live      *agent.AgentManager
+	ForceLive bool
+	Secrets   map[string]string
+}
+
+// CaseResult is the outcome of one case.
+type CaseResult struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Failures []string      `json:"failures,omitempty"`
+	Output   string        `json:"output"`
+	Duration time.Duration `json:"duration"`
+}
+
+// SuiteResult is the outcome of one suite.
+type SuiteResult struct {
+	Agent    string        `json:"agent"`
+	Path     string        `json:"path"`
+	Live     bool          `json:"live"`
+	Cases    []CaseResult  `json:"cases"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Failed counts the cases that did not pass.
+func (r SuiteResult) Failed() int {
+	n := 0
+	for _, c := range r.Cases {
+		if !c.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// Run executes every case in s in order. Agents keep their memory in a store of
+// the run's own, so a run starts without history and leaves none behind.
+func (r *Runner) Run(ctx context.Context, s *Suite) SuiteResult {
+	live := s.Live || r.ForceLive
+	res := SuiteResult{Agent: s.Agent, Path: s.Path, Live: live}
+	start := time.Now()
+	store, remove, storeErr := memory.TempStore("keystone-agent-test-")
+	if storeErr == nil {
+		defer remove()
+		ctx = agent.WithMemoryStore(ctx, store)
+	}
+	for i, c := range s.Cases {
+		caseStart := time.Now()
+		out, err := "", storeErr
+		if storeErr == nil {
+			out, err = r.runCase(ctx, s.Agent, live, i, c)
+		}
+		failures := c.Expect.Check(out, err)
+		res.Cases = append(res.Cases, CaseResult{
+			Name:     c.Name,
+			Passed:   len(failures) == 0,
+			Failures: failures,
+			Output:   out,
+			Duration: time.Since(caseStart),
+		})
+	}
+	res.Duration = time.Since(start)
+	return res
+}
+
+func (r *Runner) runCase(ctx context.Context, agentID string, live bool, i int, c Case) (string, error) {
+	manager, loadErr := r.Live, error(nil)
+	if !live {
+		manager, loadErr = r.mockManager(c.MockResponses)
+	}
+	if manager == nil {
+		if loadErr != nil {
+			return "", loadErr
+		}
+		return "", fmt.Errorf("live agents are not available")
+	}
+	a, err := manager.Get(agentID)
+	if err != nil {
+		if loadErr != nil {
+			return "", loadErr
+		}
+		return "", err
+	}
+
+	t := tickets.NewTicket(fmt.Sprintf("test-%s-%d", agentID, i+1), "agent-test", nil)
+	ctx, input, err := agent.PrepareInput(ctx, a, c.Input, agent.PromptVars{Params: c.Params}, t)
+	if err != nil {
+		return "", err
+	}
+	return a.Handle(ctx, input, t)
+}
+
+// mockManager loads the agents dir with every provider answering from responses.
+// Agents that fail to load are reported in the error alongside the manager.
+func (r *Runner) mockManager(responses []string) (*agent.AgentManager, error) {
+	script := &scriptProvider{responses: responses}
+	mocks := make(map[string]providers.Provider)
+	for name := range agent.DefaultProviders() {
+		mocks[name] = script
+	}
+	manager := agent.NewManager()
+	report, err := agent.NewLoader(r.AgentsDir, mocks).WithSecrets(r.Secrets).Load(manager)
+	if err != nil {
+		return nil, err
+	}
+	return manager, report.Err()
+}
+
+// scriptProvider returns scripted responses in order, or echoes the prompt when none are scripted.
+type scriptProvider struct {
+	mu        sync.Mutex
+	responses []string
+	calls     int
+}
+
+func (p *scriptProvider) GenerateResponse(_ context.Context, input, _ string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if len(p.responses) == 0 {
+		return "mock response: " + input, nil
+	}
+	if p.calls > len(p.responses) {
+		return "", fmt.Errorf("mock provider has no response for call %d (%d scripted)", p.calls, len(p.responses))
+	}
+	return p.responses[p.calls-1], nil
+}
+
+func (p *scriptProvider) UsageInfo() (providers.Usage, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return providers.Usage{Requests: p.calls}, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
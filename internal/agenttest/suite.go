@@ -0,0 +1,147 @@
+// Package agenttest runs YAML test suites against agents, so prompt and config
+// changes can be reviewed with a pass/fail report like code changes.
+package agenttest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDir is where suites live, one file per agent named after its ID.
+// It sits outside the agents dir so suites are never loaded as agents.
+var DefaultDir = filepath.Join(".", "tests", "agents")
+
+// Suite is the set of cases for one agent. Suites run against scripted mock
+// providers unless Live is set, in which case the agent's real provider answers.
+type Suite struct {
+	Agent string `yaml:"agent"`
+	Live  bool   `yaml:"live,omitempty"`
+	Cases []Case `yaml:"cases"`
+
+	Path string `yaml:"-"`
+}
+
+// Case is one input and the assertions its output must satisfy.
+// MockResponses are returned by the mock provider in order, one per model call
+// (tool calls and classifiers included); without them the mock echoes the prompt
+// it receives, which makes the rendered prompt itself the golden output.
+type Case struct {
+	Name          string            `yaml:"name"`
+	Input         string            `yaml:"input"`
+	Params        map[string]string `yaml:"params,omitempty"`
+	MockResponses []string          `yaml:"mock_responses,omitempty"`
+	Expect        Expect            `yaml:"expect"`
+}
+
+// Expect lists the assertions for a case. Every assertion that is set must hold.
+// Error expects the agent to fail with a message containing the given text.
+type Expect struct {
+	Equals      *string           `yaml:"equals,omitempty"`
+	Contains    []string          `yaml:"contains,omitempty"`
+	NotContains []string          `yaml:"not_contains,omitempty"`
+	Regex       string            `yaml:"regex,omitempty"`
+	JSONPath    map[string]string `yaml:"json_path,omitempty"`
+	MaxTokens   int               `yaml:"max_tokens,omitempty"`
+	Error       string            `yaml:"error,omitempty"`
+}
+
+// LoadSuite reads a suite file. The agent ID defaults to the file name.
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Suite
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("suite %s: %w", path, err)
+	}
+	s.Path = path
+	if s.Agent == "" {
+		s.Agent = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("suite %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// FindSuite returns the suite file for agentID in dir.
+func FindSuite(dir, agentID string) (string, error) {
+	for _, ext := range []string{".yaml", ".yml"} {
+		path := filepath.Join(dir, agentID+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no test suite for agent %s in %s", agentID, dir)
+}
+
+// SuiteFiles returns every suite file in dir, sorted by name.
+func SuiteFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("test suite dir %s does not exist", dir)
+		}
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Validate checks that the suite has cases with unique names and valid assertions.
+func (s *Suite) Validate() error {
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("no cases")
+	}
+	seen := make(map[string]bool, len(s.Cases))
+	for i, c := range s.Cases {
+		if c.Name == "" {
+			return fmt.Errorf("case %d has no name", i+1)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("case %q is defined more than once", c.Name)
+		}
+		seen[c.Name] = true
+		if err := c.Expect.validate(); err != nil {
+			return fmt.Errorf("case %q: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+func (e Expect) validate() error {
+	if e.Regex != "" {
+		if _, err := regexp.Compile(e.Regex); err != nil {
+			return fmt.Errorf("regex: %w", err)
+		}
+	}
+	if e.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens must not be negative")
+	}
+	for path := range e.JSONPath {
+		if _, err := parsePath(path); err != nil {
+			return err
+		}
+	}
+	if e.Error != "" && (e.Equals != nil || len(e.Contains) > 0 || e.Regex != "" || len(e.JSONPath) > 0) {
+		return fmt.Errorf("error cannot be combined with output assertions")
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"keystone/internal/agent"
+	"keystone/internal/memory"
+	"keystone/internal/providers"
+)
+
+// flakyProvider echoes prompts, failing those that contain "boom".
+type flakyProvider struct{ calls int32 }
+
+func (p *flakyProvider) GenerateResponse(_ context.Context, prompt, _ string) (string, error) {
+	atomic.AddInt32(&p.calls, 1)
+	if strings.Contains(prompt, "boom") {
+		return "", errors.New("provider exploded")
+	}
+	return "ok: " + prompt, nil
+}
+
+func (p *flakyProvider) UsageInfo() (providers.Usage, error) { return providers.Usage{}, nil }
+
+func writeFile(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func decodeResults(t *testing.T, data []byte) map[int]Result {
+	t.Helper()
+	out := map[int]Result{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var r Result
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("bad result line %q: %v", line, err)
+		}
+		out[r.Index] = r
+	}
+	return out
+}
+
+func TestReadRecords(t *testing.T) {
+	recs, err := ReadRecords(writeFile(t, "in.jsonl", "\"plain\"\n\n{\"id\": \"x\", \"input\": \"obj\", \"params\": {\"n\": 3, \"tags\": [\"a\", \"b\"]}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || recs[0].Input != "plain" || recs[1].Index != 3 || recs[1].Params["n"] != "3" || recs[1].Params["tags"] != "a,b" {
+		t.Errorf("unexpected JSONL records: %+v", recs)
+	}
+
+	recs, err = ReadRecords(writeFile(t, "in.csv", "id,input,tone\nr1,hello,calm\nr2,bye,loud\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || recs[1].ID != "r2" || recs[1].Input != "bye" || recs[1].Params["tone"] != "loud" {
+		t.Errorf("unexpected CSV records: %+v", recs)
+	}
+
+	if _, err := ReadRecords(writeFile(t, "bad.csv", "text\nhello\n")); err == nil || !strings.Contains(err.Error(), `no "input" column`) {
+		t.Errorf("expected missing column error, got %v", err)
+	}
+	if _, err := ReadRecords(writeFile(t, "bad.jsonl", "{\"id\": \"x\"}\n")); err == nil || !strings.Contains(err.Error(), "line 1: record has no input") {
+		t.Errorf("expected missing input error, got %v", err)
+	}
+}
+
+func TestRunCapturesErrorsAndParams(t *testing.T) {
+	p := &flakyProvider{}
+	a := agent.NewAgent("bot", "Bot", "", p, "m", "none", agent.WithPromptTemplate("{{input}} ({{tone}})"))
+	records := []Record{
+		{Index: 1, Input: "one"},
+		{Index: 2, Input: "boom"},
+		{Index: 3, Input: "three", Params: map[string]string{"tone": "loud"}},
+		{Index: 4, Input: "four"},
+	}
+
+	var out bytes.Buffer
+	var updates int
+	r := &Runner{Agent: a, Concurrency: 3, Params: map[string]string{"tone": "calm"}, OnProgress: func(Progress) { updates++ }}
+	progress, err := r.Run(context.Background(), records, map[int]bool{4: true}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress != (Progress{Total: 4, Skipped: 1, Succeeded: 2, Failed: 1}) || updates != 3 {
+		t.Errorf("unexpected progress %+v after %d updates", progress, updates)
+	}
+
+	results := decodeResults(t, out.Bytes())
+	if len(results) != 3 || results[1].Output != "ok: one (calm)" || results[3].Output != "ok: three (loud)" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if results[2].Error != "provider exploded" {
+		t.Errorf("expected the failure to be captured, got %+v", results[2])
+	}
+}
+
+func TestResumeSkipsCompletedRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	partial := `{"index":1,"input":"one","output":"ok"}` + "\n" + `{"index":2,"input":"boom","error":"provider exploded"}` + "\n" + `{"index":3,"inp`
+	if err := os.WriteFile(path, []byte(partial), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := Completed(path, false)
+	if err != nil || len(done) != 2 {
+		t.Fatalf("expected records 1 and 2 done, got %v, %v", done, err)
+	}
+	if done, _ = Completed(path, true); len(done) != 1 || !done[1] {
+		t.Errorf("retrying errors should leave only record 1 done, got %v", done)
+	}
+
+	if _, err := OpenResults(path, false, false); err == nil {
+		t.Error("expected refusal to overwrite existing results")
+	}
+	f, err := OpenResults(path, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &flakyProvider{}
+	r := &Runner{Agent: agent.NewAgent("bot", "Bot", "", p, "m", "none")}
+	records := []Record{{Index: 1, Input: "one"}, {Index: 2, Input: "boom"}, {Index: 3, Input: "three"}}
+	if _, err := r.Run(context.Background(), records, done, f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if p.calls != 2 {
+		t.Errorf("records 2 and 3 should run, got %d calls", p.calls)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "{\"index\":3,\"inp\n{") || !strings.Contains(string(data), `"output":"ok: three"`) {
+		t.Errorf("resumed results should start on a fresh line:\n%s", data)
+	}
+	if done, _ = Completed(path, false); len(done) != 3 {
+		t.Errorf("all records should now be done, got %v", done)
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := &Runner{Agent: agent.NewAgent("bot", "Bot", "", &flakyProvider{}, "m", "none")}
+	var out bytes.Buffer
+	_, err := r.Run(ctx, []Record{{Index: 1, Input: "one"}}, nil, &out)
+	if err == nil || !strings.Contains(err.Error(), "batch interrupted after 0 of 1 records") {
+		t.Errorf("expected interruption error, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("cancelled records must not be written: %s", out.String())
+	}
+}
+
+func TestRunIsolatesMemory(t *testing.T) {
+	dir := t.TempDir()
+	a := agent.NewAgent("recall", "Recall", "", &flakyProvider{}, "m", "chat",
+		agent.WithMemory(memory.NewStore(dir), memory.Config{Strategy: memory.StrategyBuffer}))
+	r := &Runner{Agent: a}
+	records := []Record{{Index: 1, Input: "remember me"}}
+
+	for run := 1; run <= 2; run++ {
+		var out bytes.Buffer
+		if _, err := r.Run(context.Background(), records, nil, &out); err != nil {
+			t.Fatal(err)
+		}
+		if got := decodeResults(t, out.Bytes())[1].Output; got != "ok: remember me" {
+			t.Fatalf("run %d saw earlier history: %q", run, got)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("batch left memory in the agent's store: %v", entries)
+	}
+}
@@ -0,0 +1,209 @@
+// Package batch runs an agent over many input records with a worker pool,
+// writing one JSONL result per record so interrupted runs can resume.
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"keystone/internal/agent"
+)
+
+// Record is one input to run. Index is its JSONL line or CSV data row number and
+// identifies it across resumed runs.
+type Record struct {
+	Index  int
+	ID     string
+	Input  string
+	Params map[string]string
+}
+
+// Result is the outcome of one record, written as a line of the output file.
+type Result struct {
+	Index      int    `json:"index"`
+	ID         string `json:"id,omitempty"`
+	Input      string `json:"input"`
+	Output     string `json:"output,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// ReadRecords reads records from a .csv file or, for any other extension, JSONL.
+//
+// JSONL lines are either a JSON string (the input) or an object with "input" and
+// optional "id" and "params". CSV files need a header row with an "input" column;
+// an "id" column is used as the record ID and every other non-empty cell becomes a parameter.
+func ReadRecords(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		records, err = readCSV(f)
+	} else {
+		records, err = readJSONL(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s: no records", path)
+	}
+	return records, nil
+}
+
+func readJSONL(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		rec := Record{Index: line}
+		if text[0] == '"' {
+			if err := json.Unmarshal(text, &rec.Input); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		} else {
+			var raw struct {
+				ID     string                 `json:"id"`
+				Input  string                 `json:"input"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if err := json.Unmarshal(text, &raw); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			params, err := agent.ParamsFromJSON(raw.Params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			rec.ID, rec.Input, rec.Params = raw.ID, raw.Input, params
+		}
+		if rec.Input == "" {
+			return nil, fmt.Errorf("line %d: record has no input", line)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+func readCSV(r io.Reader) ([]Record, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	header := rows[0]
+	inputCol, idCol := -1, -1
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case "input":
+			inputCol = i
+		case "id":
+			idCol = i
+		}
+	}
+	if inputCol < 0 {
+		return nil, errors.New(`header has no "input" column`)
+	}
+
+	records := make([]Record, 0, len(rows)-1)
+	for n, row := range rows[1:] {
+		rec := Record{Index: n + 1, Input: row[inputCol], Params: map[string]string{}}
+		for i, value := range row {
+			switch i {
+			case inputCol:
+			case idCol:
+				rec.ID = value
+			default:
+				if value != "" {
+					rec.Params[strings.TrimSpace(header[i])] = value
+				}
+			}
+		}
+		if rec.Input == "" {
+			return nil, fmt.Errorf("row %d: record has no input", n+1)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// Completed reads an existing output file and returns the indexes already done.
+// Records that failed count as done unless retryErrors is set; a later line for the
+// same index supersedes an earlier one. A truncated last line, left by a hard kill,
+// is ignored.
+func Completed(path string, retryErrors bool) (map[int]bool, error) {
+	done := map[int]bool{}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var res Result
+		if json.Unmarshal(scanner.Bytes(), &res) != nil || res.Index == 0 {
+			continue
+		}
+		if res.Error != "" && retryErrors {
+			delete(done, res.Index)
+			continue
+		}
+		done[res.Index] = true
+	}
+	return done, scanner.Err()
+}
+
+// OpenResults opens the output file for a run. When resuming, results are appended
+// after any existing ones, starting on a fresh line if the file was cut off mid-line;
+// otherwise the file is created and must not already hold results unless overwrite is set.
+func OpenResults(path string, resume, overwrite bool) (*os.File, error) {
+	if !resume {
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 && !overwrite {
+			return nil, fmt.Errorf("output %s already has results; use --resume to continue or --overwrite to start again", path)
+		}
+		return os.Create(path)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return f, err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if last[0] != '\n' {
+		if _, err := f.Write([]byte("\n")); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,146 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"keystone/internal/agent"
+	"keystone/internal/memory"
+	"keystone/internal/tickets"
+)
+
+// Progress counts records as a batch runs. Skipped records were completed by an
+// earlier run.
+type Progress struct {
+	Total     int `json:"total"`
+	Skipped   int `json:"skipped"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// Done is the number of records finished, including skipped ones.
+func (p Progress) Done() int { return p.Skipped + p.Succeeded + p.Failed }
+
+// Runner runs one agent over records. Params are applied to every record before
+// its own parameters. OnProgress, when set, is called after each record.
+type Runner struct {
+	Agent       agent.Agent
+	Concurrency int
+	Params      map[string]string
+	OnProgress  func(Progress)
+}
+
+// Run processes every record not in done and writes each result to out as a JSONL
+// line, in completion order. Cancelling ctx stops new records from starting;
+// records interrupted mid-run are not written, so a resumed run retries them.
+// Agents keep their memory in a store of the run's own, so no record sees history
+// from an earlier run and the run leaves none behind.
+func (r *Runner) Run(ctx context.Context, records []Record, done map[int]bool, out io.Writer) (Progress, error) {
+	workers := r.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	progress := Progress{Total: len(records)}
+	store, remove, err := memory.TempStore("keystone-batch-")
+	if err != nil {
+		return progress, err
+	}
+	defer remove()
+	ctx = agent.WithMemoryStore(ctx, store)
+
+	var pending []Record
+	for _, rec := range records {
+		if done[rec.Index] {
+			progress.Skipped++
+		} else {
+			pending = append(pending, rec)
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		writeErr error
+		wg       sync.WaitGroup
+	)
+	enc := json.NewEncoder(out)
+	jobs := make(chan Record)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rec := range jobs {
+				res := r.runRecord(ctx, rec)
+				if ctx.Err() != nil {
+					continue
+				}
+				mu.Lock()
+				if err := enc.Encode(res); err != nil && writeErr == nil {
+					writeErr = err
+				}
+				if res.Error != "" {
+					progress.Failed++
+				} else {
+					progress.Succeeded++
+				}
+				if r.OnProgress != nil {
+					r.OnProgress(progress)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for _, rec := range pending {
+		mu.Lock()
+		failed := writeErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		select {
+		case jobs <- rec:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if writeErr != nil {
+		return progress, fmt.Errorf("writing results: %w", writeErr)
+	}
+	if err := ctx.Err(); err != nil {
+		return progress, fmt.Errorf("batch interrupted after %d of %d records: %w", progress.Done(), progress.Total, err)
+	}
+	return progress, nil
+}
+
+// runRecord runs the agent on one record with its own ticket, capturing any error.
+func (r *Runner) runRecord(ctx context.Context, rec Record) Result {
+	res := Result{Index: rec.Index, ID: rec.ID, Input: rec.Input}
+	start := time.Now()
+
+	params := make(map[string]string, len(r.Params)+len(rec.Params))
+	for k, v := range r.Params {
+		params[k] = v
+	}
+	for k, v := range rec.Params {
+		params[k] = v
+	}
+	t := tickets.NewTicket(fmt.Sprintf("batch-%s-%d", r.Agent.ID(), rec.Index), "batch", nil)
+	ctx, input, err := agent.PrepareInput(ctx, r.Agent, rec.Input, agent.PromptVars{Params: params}, t)
+	if err == nil {
+		res.Output, err = r.Agent.Handle(ctx, input, t)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	res.DurationMS = time.Since(start).Milliseconds()
+	return res
+}
@@ -0,0 +1,36 @@
+package diff
+
+import "testing"
+
+func TestUnified_Identical(t *testing.T) {
+	if got := Unified("a", "b", "same\n", "same\n"); got != "" {
+		t.Errorf("expected empty diff, got %q", got)
+	}
+}
+
+func TestUnified_ChangedLine(t *testing.T) {
+	a := "one\ntwo\nthree\n"
+	b := "one\nTWO\nthree\nfour\n"
+	want := "--- old\n+++ new\n@@ -1,3 +1,4 @@\n one\n-two\n+TWO\n three\n+four\n"
+	if got := Unified("old", "new", a, b); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n"
+	want := "--- a\n+++ b\n" +
+		"@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n" +
+		"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n"
+	if got := Unified("a", "b", a, b); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_FromEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Unified("a", "b", "", "x\ny\n"); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
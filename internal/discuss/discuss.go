@@ -0,0 +1,337 @@
+// Package discuss runs multi-agent discussions: registered agents take turns on a
+// shared transcript kept in a ticket, and one of them synthesizes the result.
+package discuss
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"keystone/internal/agent"
+	"keystone/internal/logger"
+	"keystone/internal/tickets"
+)
+
+// Policy decides who speaks next and when a discussion ends.
+type Policy string
+
+const (
+	// RoundRobin lets every participant speak once per round until MaxRounds.
+	RoundRobin Policy = "round-robin"
+	// Moderated asks the moderator agent to pick each next speaker, or to end the discussion.
+	Moderated Policy = "moderator"
+	// Consensus goes round-robin and stops once every participant's latest turn contains the keyword.
+	Consensus Policy = "consensus"
+)
+
+const (
+	// DefaultKeyword is the word participants use to agree under the consensus policy.
+	DefaultKeyword = "AGREED"
+	// DefaultMaxRounds bounds a discussion when no limit is given.
+	DefaultMaxRounds = 3
+	// TicketNamespace is where the transcript and synthesis are stored on the ticket.
+	TicketNamespace = "discuss"
+	// doneReply is what the moderator answers to end the discussion.
+	doneReply = "DONE"
+)
+
+// Turn is one contribution to the discussion.
+type Turn struct {
+	Round int    `json:"round"`
+	Agent string `json:"agent"`
+	Text  string `json:"text"`
+}
+
+// Transcript is the record of a discussion. Stopped says why the turns ended.
+type Transcript struct {
+	Topic     string `json:"topic"`
+	Policy    Policy `json:"policy"`
+	Turns     []Turn `json:"turns"`
+	Stopped   string `json:"stopped"`
+	Synthesis string `json:"synthesis,omitempty"`
+}
+
+// Render returns the turns as plain text, one "agent: text" block per turn.
+func (tr *Transcript) Render() string {
+	var b strings.Builder
+	for _, turn := range tr.Turns {
+		fmt.Fprintf(&b, "%s: %s\n", turn.Agent, strings.TrimSpace(turn.Text))
+	}
+	return b.String()
+}
+
+// Discussion has registered agents take turns on a shared transcript. Moderator is
+// required by the moderator policy; Synthesizer writes the final synthesis and
+// defaults to the moderator, then the first participant. OnTurn, when set, is
+// called after each turn.
+type Discussion struct {
+	Manager     *agent.AgentManager
+	Agents      []string
+	Policy      Policy
+	Moderator   string
+	Synthesizer string
+	Keyword     string
+	MaxRounds   int
+	OnTurn      func(Turn)
+}
+
+// Validate checks the discussion's settings against the registered agents and
+// fills in defaults.
+func (d *Discussion) Validate() error {
+	if len(d.Agents) < 2 {
+		return fmt.Errorf("a discussion needs at least two agents")
+	}
+	seen := map[string]bool{}
+	for _, id := range d.Agents {
+		if seen[id] {
+			return fmt.Errorf("agent %s is listed twice", id)
+		}
+		seen[id] = true
+		if _, err := d.Manager.Get(id); err != nil {
+			return fmt.Errorf("agent %s: %w", id, err)
+		}
+	}
+	switch d.Policy {
+	case "":
+		d.Policy = RoundRobin
+	case RoundRobin, Consensus:
+	case Moderated:
+		if d.Moderator == "" {
+			return fmt.Errorf("the moderator policy needs a moderator agent")
+		}
+	default:
+		return fmt.Errorf("unknown policy %q (want %s, %s or %s)", d.Policy, RoundRobin, Moderated, Consensus)
+	}
+	for _, id := range []string{d.Moderator, d.Synthesizer} {
+		if id == "" {
+			continue
+		}
+		if _, err := d.Manager.Get(id); err != nil {
+			return fmt.Errorf("agent %s: %w", id, err)
+		}
+	}
+	if d.Keyword == "" {
+		d.Keyword = DefaultKeyword
+	}
+	if d.MaxRounds <= 0 {
+		d.MaxRounds = DefaultMaxRounds
+	}
+	return nil
+}
+
+// Hops returns how many ticket hops a discussion that runs to MaxRounds uses: one
+// per turn, one per moderator decision and one for the synthesis.
+func (d *Discussion) Hops() int {
+	rounds := d.MaxRounds
+	if rounds <= 0 {
+		rounds = DefaultMaxRounds
+	}
+	turns := rounds * len(d.Agents)
+	if d.Policy == Moderated {
+		turns *= 2
+	}
+	return turns + 1
+}
+
+// Run holds the discussion on topic. Every agent call is a handoff on t, so the
+// discussion also stops when t runs out of hops, always keeping one for the
+// synthesis. The transcript is stored on t under TicketNamespace after each turn.
+func (d *Discussion) Run(ctx context.Context, topic string, t *tickets.Ticket) (*Transcript, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	tr := &Transcript{Topic: topic, Policy: d.Policy}
+	logger.Info(fmt.Sprintf("Starting %s discussion between %s", d.Policy, strings.Join(d.Agents, ", ")), false)
+
+	maxTurns := d.MaxRounds * len(d.Agents)
+	next := 0
+	for len(tr.Turns) < maxTurns {
+		need := 2 // the turn and the synthesis
+		if d.Policy == Moderated {
+			need++
+		}
+		if t.MaxHops-t.Hops < need {
+			tr.Stopped = "ticket hop limit reached"
+			break
+		}
+
+		speaker := d.Agents[next%len(d.Agents)]
+		if d.Policy == Moderated {
+			pick, done, err := d.pickSpeaker(ctx, tr, speaker, t)
+			if err != nil {
+				return tr, err
+			}
+			if done {
+				tr.Stopped = "moderator ended the discussion"
+				break
+			}
+			speaker = pick
+		}
+
+		text, err := d.call(ctx, speaker, d.turnPrompt(tr, speaker), t)
+		if err != nil {
+			return tr, err
+		}
+		turn := Turn{Round: len(tr.Turns)/len(d.Agents) + 1, Agent: speaker, Text: text}
+		tr.Turns = append(tr.Turns, turn)
+		if err := store(t, "transcript", tr.Turns); err != nil {
+			return tr, err
+		}
+		if d.OnTurn != nil {
+			d.OnTurn(turn)
+		}
+		next = d.index(speaker) + 1
+
+		if d.Policy == Consensus && d.agreed(tr) {
+			tr.Stopped = "consensus reached"
+			break
+		}
+	}
+	if tr.Stopped == "" {
+		tr.Stopped = "max rounds reached"
+	}
+	logger.Info(fmt.Sprintf("Discussion stopped after %d turns: %s", len(tr.Turns), tr.Stopped), false)
+
+	synth, err := d.call(ctx, d.synthesizer(), d.synthesisPrompt(tr), t)
+	if err != nil {
+		return tr, fmt.Errorf("synthesis: %w", err)
+	}
+	tr.Synthesis = synth
+	t.SetNamespaced(TicketNamespace, "synthesis", synth)
+	return tr, nil
+}
+
+// pickSpeaker asks the moderator who speaks next. A reply that starts with neither a
+// participant ID nor DONE falls back to fallback.
+func (d *Discussion) pickSpeaker(ctx context.Context, tr *Transcript, fallback string, t *tickets.Ticket) (string, bool, error) {
+	reply, err := d.call(ctx, d.Moderator, d.moderatorPrompt(tr), t)
+	if err != nil {
+		return "", false, fmt.Errorf("moderator: %w", err)
+	}
+	fields := strings.Fields(reply)
+	if len(fields) == 0 {
+		return fallback, false, nil
+	}
+	word := strings.Trim(fields[0], ".,:;!\"'`*")
+	if strings.EqualFold(word, doneReply) {
+		return "", true, nil
+	}
+	if d.index(word) >= 0 {
+		return word, false, nil
+	}
+	logger.Warn(fmt.Sprintf("Moderator %s did not name a participant, continuing with %s", d.Moderator, fallback), false)
+	return fallback, false, nil
+}
+
+// call hands t to the agent id and runs it on input rendered through its prompt template.
+func (d *Discussion) call(ctx context.Context, id, input string, t *tickets.Ticket) (string, error) {
+	a, err := d.Manager.Get(id)
+	if err != nil {
+		return "", fmt.Errorf("agent %s: %w", id, err)
+	}
+	if err := t.Handoff(id); err != nil {
+		return "", err
+	}
+	// The prompts carry the transcript, which participants need not also remember.
+	ctx = agent.WithoutMemory(ctx, id)
+	ctx, input, err = agent.PrepareInput(ctx, a, input, agent.PromptVars{}, t)
+	if err != nil {
+		return "", err
+	}
+	out, err := a.Handle(ctx, input, t)
+	if err != nil {
+		return "", fmt.Errorf("agent %s failed: %w", id, err)
+	}
+	if ca, ok := a.(agent.ContextualAgent); ok {
+		for k, v := range ca.ContextData() {
+			t.SetNamespaced(id, k, v)
+		}
+	}
+	return out, nil
+}
+
+func (d *Discussion) turnPrompt(tr *Transcript, speaker string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are %s, one of %s discussing: %s\n\n", speaker, strings.Join(d.Agents, ", "), tr.Topic)
+	if len(tr.Turns) > 0 {
+		fmt.Fprintf(&b, "Transcript so far:\n%s\n", tr.Render())
+	}
+	b.WriteString("Add your contribution, responding to the others where useful.")
+	if d.Policy == Consensus {
+		fmt.Fprintf(&b, " Include the word %s only once you agree with the group.", d.Keyword)
+	}
+	return b.String()
+}
+
+func (d *Discussion) moderatorPrompt(tr *Transcript) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are moderating a discussion between %s on: %s\n\n", strings.Join(d.Agents, ", "), tr.Topic)
+	if len(tr.Turns) > 0 {
+		fmt.Fprintf(&b, "Transcript so far:\n%s\n", tr.Render())
+	}
+	fmt.Fprintf(&b, "Reply with only the ID of the participant who should speak next, or %s to end the discussion.", doneReply)
+	return b.String()
+}
+
+func (d *Discussion) synthesisPrompt(tr *Transcript) string {
+	return fmt.Sprintf("Synthesize the discussion between %s on: %s\n\nTranscript:\n%s\nSummarize where the participants agree, where they differ and the conclusion.",
+		strings.Join(d.Agents, ", "), tr.Topic, tr.Render())
+}
+
+// agreed reports whether every participant has spoken and their latest turn contains
+// the keyword as a whole word, in any case. Neither "disagreed" nor a directly negated
+// keyword such as "not agreed" counts.
+func (d *Discussion) agreed(tr *Transcript) bool {
+	keyword := regexp.MustCompile(`(?i)(\b(?:not|never|no|\w+n't)\s+)?\b` + regexp.QuoteMeta(d.Keyword) + `\b`)
+	agrees := func(text string) bool {
+		for _, m := range keyword.FindAllStringSubmatch(text, -1) {
+			if m[1] == "" {
+				return true
+			}
+		}
+		return false
+	}
+	latest := map[string]string{}
+	for _, turn := range tr.Turns {
+		latest[turn.Agent] = turn.Text
+	}
+	for _, id := range d.Agents {
+		text, ok := latest[id]
+		if !ok || !agrees(text) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Discussion) synthesizer() string {
+	switch {
+	case d.Synthesizer != "":
+		return d.Synthesizer
+	case d.Moderator != "":
+		return d.Moderator
+	default:
+		return d.Agents[0]
+	}
+}
+
+func (d *Discussion) index(id string) int {
+	for i, a := range d.Agents {
+		if a == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// store writes v as JSON under key in the discussion's ticket namespace.
+func store(t *tickets.Ticket, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	t.SetNamespaced(TicketNamespace, key, string(data))
+	return nil
+}
@@ -0,0 +1,199 @@
+package discuss
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"keystone/internal/agent"
+	"keystone/internal/memory"
+	"keystone/internal/providers"
+	"keystone/internal/tickets"
+)
+
+// scriptedProvider replies with the next scripted answer, repeating the last one.
+type scriptedProvider struct {
+	replies []string
+	prompts []string
+}
+
+func (p *scriptedProvider) GenerateResponse(_ context.Context, prompt, _ string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	reply := p.replies[len(p.replies)-1]
+	if len(p.prompts) <= len(p.replies) {
+		reply = p.replies[len(p.prompts)-1]
+	}
+	return reply, nil
+}
+
+func (p *scriptedProvider) UsageInfo() (providers.Usage, error) { return providers.Usage{}, nil }
+
+func newManager(t *testing.T, providers map[string]*scriptedProvider) *agent.AgentManager {
+	t.Helper()
+	m := agent.NewManager()
+	for id, p := range providers {
+		if err := m.Register(agent.NewAgent(id, id, "", p, "m", "none")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func newTicket(maxHops int) *tickets.Ticket {
+	t := tickets.NewTicket("discuss-test", "default", nil)
+	t.MaxHops = maxHops
+	return t
+}
+
+func speakers(tr *Transcript) string {
+	var ids []string
+	for _, turn := range tr.Turns {
+		ids = append(ids, turn.Agent)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestRoundRobin(t *testing.T) {
+	a := &scriptedProvider{replies: []string{"a speaks", "a again", "a summary"}}
+	b := &scriptedProvider{replies: []string{"b speaks"}}
+	d := &Discussion{Manager: newManager(t, map[string]*scriptedProvider{"a": a, "b": b}), Agents: []string{"a", "b"}, MaxRounds: 2}
+	tk := newTicket(d.Hops())
+
+	tr, err := d.Run(context.Background(), "tabs or spaces", tk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if speakers(tr) != "a,b,a,b" || tr.Stopped != "max rounds reached" || tr.Turns[3].Round != 2 {
+		t.Fatalf("unexpected transcript: %+v", tr)
+	}
+	if !strings.Contains(b.prompts[0], "a: a speaks") || !strings.Contains(b.prompts[0], "tabs or spaces") {
+		t.Errorf("b should see the topic and transcript, got %q", b.prompts[0])
+	}
+	if tr.Synthesis != "a summary" || tk.Hops != 5 {
+		t.Errorf("synthesis = %q, hops = %d", tr.Synthesis, tk.Hops)
+	}
+
+	var turns []Turn
+	raw, _ := tk.GetNamespaced(TicketNamespace, "transcript")
+	if err := json.Unmarshal([]byte(raw), &turns); err != nil || len(turns) != 4 {
+		t.Errorf("transcript not stored on the ticket: %q, %v", raw, err)
+	}
+	if s, _ := tk.GetNamespaced(TicketNamespace, "synthesis"); s != tr.Synthesis {
+		t.Errorf("synthesis not stored on the ticket: %q", s)
+	}
+}
+
+func TestParticipantsDoNotRememberTranscripts(t *testing.T) {
+	store := memory.NewStore(t.TempDir())
+	m := agent.NewManager()
+	for _, id := range []string{"a", "b"} {
+		p := &scriptedProvider{replies: []string{id + " speaks"}}
+		if err := m.Register(agent.NewAgent(id, id, "", p, "m", "session_"+id,
+			agent.WithMemory(store, memory.Config{Strategy: memory.StrategyBuffer}))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := &Discussion{Manager: m, Agents: []string{"a", "b"}, MaxRounds: 2}
+	tk := newTicket(d.Hops())
+	if _, err := d.Run(context.Background(), "topic", tk); err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := m.Get("a")
+	conv, err := store.Load(a.(*agent.AgentBase).MemoryKey(tk))
+	if err != nil || !conv.Empty() {
+		t.Errorf("expected no memory of the discussion, got %+v (%v)", conv, err)
+	}
+}
+
+func TestHopLimitReservesSynthesis(t *testing.T) {
+	p := &scriptedProvider{replies: []string{"ok"}}
+	d := &Discussion{Manager: newManager(t, map[string]*scriptedProvider{"a": p, "b": p}), Agents: []string{"a", "b"}, MaxRounds: 5}
+
+	tk := newTicket(4)
+	tr, err := d.Run(context.Background(), "topic", tk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.Turns) != 3 || tr.Stopped != "ticket hop limit reached" || tr.Synthesis == "" || tk.Hops != 4 {
+		t.Errorf("unexpected transcript: %+v (hops %d)", tr, tk.Hops)
+	}
+}
+
+func TestConsensus(t *testing.T) {
+	a := &scriptedProvider{replies: []string{"I think tabs", "Fine, agreed."}}
+	b := &scriptedProvider{replies: []string{"Spaces. AGREED?", "Still agreed"}}
+	d := &Discussion{Manager: newManager(t, map[string]*scriptedProvider{"a": a, "b": b}), Agents: []string{"a", "b"}, Policy: Consensus}
+
+	tr, err := d.Run(context.Background(), "tabs or spaces", newTicket(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if speakers(tr) != "a,b,a" || tr.Stopped != "consensus reached" {
+		t.Errorf("unexpected transcript: %+v", tr)
+	}
+	if !strings.Contains(a.prompts[0], "Include the word AGREED") {
+		t.Errorf("speakers should be told the keyword, got %q", a.prompts[0])
+	}
+}
+
+func TestConsensusNeedsWholeKeyword(t *testing.T) {
+	a := &scriptedProvider{replies: []string{"Agreed, tabs.", "Still AGREED"}}
+	b := &scriptedProvider{replies: []string{"I disagreed before and it is not agreed yet.", "Agreed now."}}
+	d := &Discussion{Manager: newManager(t, map[string]*scriptedProvider{"a": a, "b": b}), Agents: []string{"a", "b"}, Policy: Consensus}
+
+	tr, err := d.Run(context.Background(), "tabs or spaces", newTicket(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if speakers(tr) != "a,b,a,b" || tr.Stopped != "consensus reached" {
+		t.Errorf("unexpected transcript: %+v", tr)
+	}
+}
+
+func TestModerator(t *testing.T) {
+	mod := &scriptedProvider{replies: []string{"b", "b.", "nobody", "DONE", "the summary"}}
+	a := &scriptedProvider{replies: []string{"from a"}}
+	b := &scriptedProvider{replies: []string{"from b"}}
+	d := &Discussion{
+		Manager:   newManager(t, map[string]*scriptedProvider{"mod": mod, "a": a, "b": b}),
+		Agents:    []string{"a", "b"},
+		Policy:    Moderated,
+		Moderator: "mod",
+	}
+	var seen []Turn
+	d.OnTurn = func(turn Turn) { seen = append(seen, turn) }
+
+	tr, err := d.Run(context.Background(), "topic", newTicket(d.Hops()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "nobody" is not a participant, so the discussion continues with the agent after b.
+	if speakers(tr) != "b,b,a" || tr.Stopped != "moderator ended the discussion" || len(seen) != 3 {
+		t.Errorf("unexpected transcript: %+v", tr)
+	}
+	if tr.Synthesis != "the summary" {
+		t.Errorf("the moderator should synthesize, got %q", tr.Synthesis)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	p := &scriptedProvider{replies: []string{"ok"}}
+	m := newManager(t, map[string]*scriptedProvider{"a": p, "b": p})
+	for name, tc := range map[string]struct {
+		d    Discussion
+		want string
+	}{
+		"one agent":    {Discussion{Agents: []string{"a"}}, "at least two agents"},
+		"duplicate":    {Discussion{Agents: []string{"a", "a"}}, "listed twice"},
+		"unknown":      {Discussion{Agents: []string{"a", "zed"}}, "agent zed"},
+		"no moderator": {Discussion{Agents: []string{"a", "b"}, Policy: Moderated}, "needs a moderator"},
+		"bad policy":   {Discussion{Agents: []string{"a", "b"}, Policy: "vote"}, "unknown policy"},
+	} {
+		tc.d.Manager = m
+		if err := tc.d.Validate(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected %q, got %v", name, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Package eval compares agents (prompt or model variants) on a dataset of examples,
+// scoring every output and aggregating quality, latency, tokens and cost per agent.
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Example is one dataset line. Expected is the reference answer scorers compare
+// against; Params are passed to the agent like --parameters on agent run.
+type Example struct {
+	ID       string            `json:"id,omitempty"`
+	Input    string            `json:"input"`
+	Expected string            `json:"expected,omitempty"`
+	Params   map[string]string `json:"params,omitempty"`
+}
+
+// LoadDataset reads JSONL examples from path, skipping blank lines. Examples
+// without an ID are numbered by line.
+func LoadDataset(path string) ([]Example, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var examples []Example
+	seen := map[string]int{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var ex Example
+		if err := json.Unmarshal([]byte(text), &ex); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if ex.Input == "" {
+			return nil, fmt.Errorf("%s:%d: example has no input", path, line)
+		}
+		if ex.ID == "" {
+			ex.ID = fmt.Sprintf("line-%d", line)
+		}
+		if prev, ok := seen[ex.ID]; ok {
+			return nil, fmt.Errorf("%s:%d: example id %q already used on line %d", path, line, ex.ID, prev)
+		}
+		seen[ex.ID] = line
+		examples = append(examples, ex)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("%s: dataset is empty", path)
+	}
+	return examples, nil
+}
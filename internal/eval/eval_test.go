@@ -0,0 +1,165 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"keystone/internal/agent"
+	"keystone/internal/memory"
+	"keystone/internal/providers"
+	"keystone/internal/usage"
+)
+
+// fixedProvider always answers with reply.
+type fixedProvider struct{ reply string }
+
+func (p fixedProvider) GenerateResponse(context.Context, string, string) (string, error) {
+	return p.reply, nil
+}
+
+func (p fixedProvider) UsageInfo() (providers.Usage, error) { return providers.Usage{}, nil }
+
+func TestLoadDataset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "d.jsonl")
+	data := `{"id": "a", "input": "2+2", "expected": "4"}
+
+{"input": "hi", "params": {"tone": "calm"}}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	examples, err := LoadDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(examples) != 2 || examples[1].ID != "line-3" || examples[1].Params["tone"] != "calm" {
+		t.Errorf("unexpected examples: %+v", examples)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"id": "a", "input": "x"}`+"\n"+`{"id": "a", "input": "y"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDataset(path); err == nil || !strings.Contains(err.Error(), `example id "a" already used on line 1`) {
+		t.Errorf("expected duplicate id error, got %v", err)
+	}
+}
+
+func TestParseJudgeReply(t *testing.T) {
+	for reply, want := range map[string]float64{
+		"8\nClear and correct.": 0.8,
+		"Score: 7/10 - close":   0.7,
+		"10":                    1,
+	} {
+		got, err := parseJudgeReply(reply)
+		if err != nil || got.Value != want {
+			t.Errorf("parseJudgeReply(%q) = %+v, %v; want %v", reply, got, err, want)
+		}
+	}
+	if _, err := parseJudgeReply("great answer"); err == nil {
+		t.Error("expected an error for a reply without a score")
+	}
+	if _, err := parseJudgeReply("42"); err == nil {
+		t.Error("expected an error for a score above 10")
+	}
+}
+
+func TestRunnerComparesAgents(t *testing.T) {
+	tracker := usage.NewTracker()
+	m := agent.NewManager()
+	for _, a := range []agent.Agent{
+		agent.NewAgent("good", "Good", "", usage.Meter(fixedProvider{"Paris"}, "mock", tracker), "small", "none"),
+		agent.NewAgent("bad", "Bad", "", usage.Meter(fixedProvider{"Lyon is lovely"}, "mock", tracker), "big", "none"),
+		agent.NewAgent("judge", "Judge", "", usage.Meter(fixedProvider{"6\nPartly right."}, "mock", tracker), "small", "none"),
+	} {
+		if err := m.Register(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	judge, _ := m.Get("judge")
+
+	r := &Runner{
+		Manager: m,
+		Tracker: tracker,
+		Pricing: usage.Pricing{"big": 10},
+		Scorers: []Scorer{ExactMatch{}, Similarity{}, Judge{Agent: judge}},
+	}
+	examples := []Example{
+		{ID: "capital", Input: "Capital of France?", Expected: "paris"},
+		{ID: "open", Input: "Say something"},
+	}
+	report, err := r.Run(context.Background(), "geo.jsonl", examples, []string{"good", "bad"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Results) != 4 || report.Results[0].Agent != "good" || report.Results[1].Agent != "bad" {
+		t.Fatalf("results should be per example, then per agent: %+v", report.Results)
+	}
+	if _, ok := report.Results[2].Scores[ScorerExact]; ok {
+		t.Error("reference scorers should skip examples without expected output")
+	}
+	good, bad := report.Summary[0], report.Summary[1]
+	if good.Scores[ScorerExact] != 1 || bad.Scores[ScorerExact] != 0 {
+		t.Errorf("unexpected exact scores: %v / %v", good.Scores, bad.Scores)
+	}
+	if good.Scores[ScorerJudge] != 0.6 || report.Results[0].Scores[ScorerJudge].Reason != "Partly right." {
+		t.Errorf("unexpected judge score: %+v", report.Results[0].Scores)
+	}
+	if good.Tokens == 0 || good.Cost != 0 || bad.Cost <= 0 {
+		t.Errorf("judge calls must not be charged to agents, and only priced models cost: %+v %+v", good, bad)
+	}
+
+	table := report.Table()
+	if !strings.Contains(table, "AGENT  EXACT  SIMILARITY  JUDGE  ERRORS") || !strings.Contains(table, "good   1.00") {
+		t.Errorf("unexpected table:\n%s", table)
+	}
+	var md bytes.Buffer
+	if err := report.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "| capital | bad | 0.00 |") {
+		t.Errorf("unexpected markdown:\n%s", md.String())
+	}
+
+	if _, err := r.Run(context.Background(), "geo.jsonl", examples, []string{"ghost"}); err == nil {
+		t.Error("expected an error for an unknown agent")
+	}
+}
+
+// echoProvider answers with the prompt it was sent.
+type echoProvider struct{}
+
+func (echoProvider) GenerateResponse(_ context.Context, input, _ string) (string, error) {
+	return input, nil
+}
+
+func (echoProvider) UsageInfo() (providers.Usage, error) { return providers.Usage{}, nil }
+
+func TestRunnerIsolatesMemory(t *testing.T) {
+	dir := t.TempDir()
+	store := memory.NewStore(dir)
+	m := agent.NewManager()
+	a := agent.NewAgent("recall", "Recall", "", echoProvider{}, "small", "chat", agent.WithMemory(store, memory.Config{Strategy: memory.StrategyBuffer}))
+	if err := m.Register(a); err != nil {
+		t.Fatal(err)
+	}
+	r := &Runner{Manager: m, Tracker: usage.NewTracker()}
+	examples := []Example{{ID: "hello", Input: "remember me"}}
+
+	for run := 1; run <= 2; run++ {
+		report, err := r.Run(context.Background(), "memory", examples, []string{"recall"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := report.Results[0].Output; got != "remember me" {
+			t.Fatalf("run %d saw earlier history: %q", run, got)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("eval left memory in the agent's store: %v", entries)
+	}
+}
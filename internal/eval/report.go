@@ -0,0 +1,184 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Report is the outcome of an evaluation: every result plus per-agent aggregates.
+type Report struct {
+	Dataset   string    `json:"dataset"`
+	Agents    []string  `json:"agents"`
+	Scorers   []string  `json:"scorers"`
+	CreatedAt time.Time `json:"created_at"`
+	Summary   []Summary `json:"summary"`
+	Results   []Result  `json:"results"`
+}
+
+// Summary aggregates one agent's results. Scores are means over the examples
+// each scorer rated, leaving out scorer errors; latency is the mean per example.
+type Summary struct {
+	Agent        string             `json:"agent"`
+	Examples     int                `json:"examples"`
+	Errors       int                `json:"errors"`
+	Scores       map[string]float64 `json:"scores"`
+	AvgLatencyMS float64            `json:"avg_latency_ms"`
+	Tokens       int                `json:"tokens"`
+	Cost         float64            `json:"cost"`
+}
+
+func (r *Report) summarize() {
+	r.Summary = nil
+	for _, id := range r.Agents {
+		s := Summary{Agent: id, Scores: map[string]float64{}}
+		counts := map[string]int{}
+		var latency int64
+		for _, res := range r.Results {
+			if res.Agent != id {
+				continue
+			}
+			s.Examples++
+			if res.Error != "" {
+				s.Errors++
+			}
+			latency += res.LatencyMS
+			s.Tokens += res.Tokens
+			s.Cost += res.Cost
+			for name, score := range res.Scores {
+				if score.Error != "" {
+					continue
+				}
+				s.Scores[name] += score.Value
+				counts[name]++
+			}
+		}
+		for name, n := range counts {
+			s.Scores[name] /= float64(n)
+		}
+		if s.Examples > 0 {
+			s.AvgLatencyMS = float64(latency) / float64(s.Examples)
+		}
+		r.Summary = append(r.Summary, s)
+	}
+}
+
+// Table renders the per-agent comparison as aligned text.
+func (r *Report) Table() string {
+	header := []string{"AGENT"}
+	for _, name := range r.Scorers {
+		header = append(header, strings.ToUpper(name))
+	}
+	header = append(header, "ERRORS", "AVG LATENCY", "TOKENS", "COST")
+	rows := [][]string{header}
+	for _, s := range r.Summary {
+		rows = append(rows, r.summaryRow(s))
+	}
+	return alignColumns(rows)
+}
+
+func (r *Report) summaryRow(s Summary) []string {
+	row := []string{s.Agent}
+	for _, name := range r.Scorers {
+		row = append(row, r.scoreCell(s, name))
+	}
+	return append(row,
+		fmt.Sprintf("%d/%d", s.Errors, s.Examples),
+		fmt.Sprintf("%.0fms", s.AvgLatencyMS),
+		fmt.Sprintf("%d", s.Tokens),
+		fmt.Sprintf("$%.4f", s.Cost),
+	)
+}
+
+func (r *Report) scoreCell(s Summary, name string) string {
+	v, ok := s.Scores[name]
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// WriteJSON writes the full report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteMarkdown writes the aggregate table followed by a per-example table.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Evaluation: %s\n\n", r.Dataset)
+	fmt.Fprintf(&b, "Run %s, %d agent(s), scorers: %s.\n\n## Summary\n\n", r.CreatedAt.Format(time.RFC3339), len(r.Agents), strings.Join(r.Scorers, ", "))
+
+	header := append([]string{"Agent"}, r.Scorers...)
+	header = append(header, "Errors", "Avg latency", "Tokens", "Cost (USD)")
+	writeMarkdownRow(&b, header)
+	writeMarkdownRow(&b, markdownRule(len(header)))
+	for _, s := range r.Summary {
+		writeMarkdownRow(&b, r.summaryRow(s))
+	}
+
+	b.WriteString("\n## Examples\n\n")
+	header = append([]string{"Example", "Agent"}, r.Scorers...)
+	header = append(header, "Latency", "Output")
+	writeMarkdownRow(&b, header)
+	writeMarkdownRow(&b, markdownRule(len(header)))
+	for _, res := range r.Results {
+		row := []string{res.Example, res.Agent}
+		for _, name := range r.Scorers {
+			if score, ok := res.Scores[name]; ok && score.Error == "" {
+				row = append(row, fmt.Sprintf("%.2f", score.Value))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		output := res.Output
+		if res.Error != "" {
+			output = "ERROR: " + res.Error
+		}
+		row = append(row, fmt.Sprintf("%dms", res.LatencyMS), truncate(output, 120))
+		writeMarkdownRow(&b, row)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = strings.NewReplacer("|", `\|`, "\n", " ").Replace(c)
+	}
+	fmt.Fprintf(b, "| %s |\n", strings.Join(escaped, " | "))
+}
+
+func markdownRule(n int) []string {
+	rule := make([]string, n)
+	for i := range rule {
+		rule[i] = "---"
+	}
+	return rule
+}
+
+// alignColumns pads every column to its widest cell.
+func alignColumns(rows [][]string) string {
+	widths := map[int]int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	lines := make([]string, len(rows))
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		lines[r] = strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,114 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"keystone/internal/agent"
+	"keystone/internal/memory"
+	"keystone/internal/tickets"
+	"keystone/internal/usage"
+)
+
+// Runner runs examples through agents and scores the outputs. Manager's agents
+// should be built on providers wrapped with usage.Meter(…, Tracker) so token use
+// and cost can be attributed to each run.
+type Runner struct {
+	Manager *agent.AgentManager
+	Tracker *usage.Tracker
+	Pricing usage.Pricing
+	Scorers []Scorer
+}
+
+// Result is one agent's run on one example.
+type Result struct {
+	Example   string           `json:"example"`
+	Agent     string           `json:"agent"`
+	Output    string           `json:"output"`
+	Error     string           `json:"error,omitempty"`
+	Scores    map[string]Score `json:"scores"`
+	LatencyMS int64            `json:"latency_ms"`
+	Tokens    int              `json:"tokens"`
+	Cost      float64          `json:"cost"`
+}
+
+// Run sends every example to every agent, in dataset order, and builds the report.
+// Agents keep their memory in a store of the run's own, so a run starts without
+// history and leaves none behind.
+func (r *Runner) Run(ctx context.Context, dataset string, examples []Example, agentIDs []string) (*Report, error) {
+	if len(agentIDs) == 0 {
+		return nil, fmt.Errorf("no agents to evaluate")
+	}
+	agents := make([]agent.Agent, 0, len(agentIDs))
+	for _, id := range agentIDs {
+		a, err := r.Manager.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		agents = append(agents, a)
+	}
+
+	store, remove, err := memory.TempStore("keystone-eval-")
+	if err != nil {
+		return nil, err
+	}
+	defer remove()
+	ctx = agent.WithMemoryStore(ctx, store)
+
+	report := &Report{Dataset: dataset, Agents: agentIDs, CreatedAt: time.Now().UTC()}
+	for _, s := range r.Scorers {
+		report.Scorers = append(report.Scorers, s.Name())
+	}
+	for _, ex := range examples {
+		for _, a := range agents {
+			res := r.runOne(ctx, a, ex)
+			r.score(ctx, &res, ex)
+			report.Results = append(report.Results, res)
+		}
+	}
+	report.summarize()
+	return report, nil
+}
+
+func (r *Runner) runOne(ctx context.Context, a agent.Agent, ex Example) Result {
+	res := Result{Example: ex.ID, Agent: a.ID(), Scores: map[string]Score{}}
+	mark := len(r.Tracker.List())
+	start := time.Now()
+
+	t := tickets.NewTicket(fmt.Sprintf("eval-%s-%s", a.ID(), ex.ID), "eval", nil)
+	ctx, input, err := agent.PrepareInput(ctx, a, ex.Input, agent.PromptVars{Params: ex.Params}, t)
+	if err == nil {
+		res.Output, err = a.Handle(usage.WithAgent(ctx, a.ID()), input, t)
+	}
+	res.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	for _, e := range r.Tracker.List()[mark:] {
+		if e.AgentID == a.ID() {
+			res.Tokens += e.Tokens
+			res.Cost += r.Pricing.Cost(e)
+		}
+	}
+	return res
+}
+
+// score applies every scorer to a successful run. Reference-based scorers skip
+// examples without an expected answer.
+func (r *Runner) score(ctx context.Context, res *Result, ex Example) {
+	if res.Error != "" {
+		return
+	}
+	for _, s := range r.Scorers {
+		if ex.Expected == "" && s.Name() != ScorerJudge {
+			continue
+		}
+		score, err := s.Score(usage.WithAgent(ctx, "scorer:"+s.Name()), ex, res.Output)
+		if err != nil {
+			score = Score{Error: err.Error()}
+		}
+		res.Scores[s.Name()] = score
+	}
+}
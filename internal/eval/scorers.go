@@ -0,0 +1,121 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"keystone/internal/agent"
+)
+
+// Scorer names.
+const (
+	ScorerExact      = "exact"
+	ScorerSimilarity = "similarity"
+	ScorerJudge      = "judge"
+)
+
+// Score is one scorer's verdict on an output, from 0 (worst) to 1 (best).
+// Error is set instead when the scorer could not rate the output.
+type Score struct {
+	Value  float64 `json:"value"`
+	Reason string  `json:"reason,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// Scorer rates an agent's output for an example.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, ex Example, output string) (Score, error)
+}
+
+// ExactMatch scores 1 when the output equals the expected answer, ignoring
+// surrounding whitespace and case.
+type ExactMatch struct{}
+
+// Name implements Scorer.
+func (ExactMatch) Name() string { return ScorerExact }
+
+// Score implements Scorer.
+func (ExactMatch) Score(_ context.Context, ex Example, output string) (Score, error) {
+	if strings.EqualFold(strings.TrimSpace(output), strings.TrimSpace(ex.Expected)) {
+		return Score{Value: 1}, nil
+	}
+	return Score{Value: 0}, nil
+}
+
+// Similarity scores the term overlap between the output and the expected answer.
+type Similarity struct{}
+
+// Name implements Scorer.
+func (Similarity) Name() string { return ScorerSimilarity }
+
+// Score implements Scorer.
+func (Similarity) Score(_ context.Context, ex Example, output string) (Score, error) {
+	return Score{Value: agent.TextSimilarity(output, ex.Expected)}, nil
+}
+
+// Judge asks another agent to grade the output against a rubric on a 0-10 scale.
+type Judge struct {
+	Agent  agent.Agent
+	Rubric string
+}
+
+// DefaultRubric is used by Judge when no rubric is given.
+const DefaultRubric = "Is the response correct, complete and clearly written? If a reference answer is given, does the response agree with it?"
+
+// Name implements Scorer.
+func (Judge) Name() string { return ScorerJudge }
+
+var judgeScoreRe = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:/\s*10)?`)
+
+// Score implements Scorer.
+func (j Judge) Score(ctx context.Context, ex Example, output string) (Score, error) {
+	rubric := j.Rubric
+	if rubric == "" {
+		rubric = DefaultRubric
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are grading an AI agent's response.\n\nRubric:\n%s\n\nInput:\n%s\n\n", rubric, ex.Input)
+	if ex.Expected != "" {
+		fmt.Fprintf(&b, "Reference answer:\n%s\n\n", ex.Expected)
+	}
+	fmt.Fprintf(&b, "Response:\n%s\n\n", output)
+	b.WriteString("Reply with a score from 0 to 10 on the first line, then one sentence explaining it.")
+
+	reply, err := j.Agent.Handle(ctx, b.String(), nil)
+	if err != nil {
+		return Score{}, fmt.Errorf("judge %s: %w", j.Agent.ID(), err)
+	}
+	return parseJudgeReply(reply)
+}
+
+// parseJudgeReply reads the 0-10 score from the first line of a judge reply and
+// keeps the rest as the reason.
+func parseJudgeReply(reply string) (Score, error) {
+	lines := strings.SplitN(strings.TrimSpace(reply), "\n", 2)
+	m := judgeScoreRe.FindStringSubmatch(lines[0])
+	if m == nil {
+		return Score{}, fmt.Errorf("judge reply has no score: %q", truncate(lines[0], 80))
+	}
+	v, _ := strconv.ParseFloat(m[1], 64)
+	if v > 10 {
+		return Score{}, fmt.Errorf("judge score %s is outside 0-10", m[1])
+	}
+	s := Score{Value: v / 10}
+	if len(lines) > 1 {
+		s.Reason = strings.TrimSpace(lines[1])
+	} else {
+		s.Reason = strings.TrimSpace(strings.Replace(lines[0], m[0], "", 1))
+	}
+	return s, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,233 @@
+// Package guardrails enforces input and output policies around agent calls.
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Stages a policy applies to.
+const (
+	StageInput  = "input"
+	StageOutput = "output"
+)
+
+// Rules a Violation can break.
+const (
+	RuleDeny        = "deny"
+	RuleMaxLength   = "max_length"
+	RulePII         = "pii"
+	RuleBannedTopic = "banned_topic"
+	RuleRequire     = "require"
+)
+
+// PII handling modes.
+const (
+	PIIBlock  = "block"  // reject text containing PII
+	PIIRedact = "redact" // replace PII with a [REDACTED:TYPE] marker and continue
+)
+
+// Config holds an agent's guardrails. Classifier names the agent asked whether text
+// touches a banned topic; it is required when either policy lists banned topics.
+type Config struct {
+	Input      Policy `yaml:"input,omitempty" json:"input,omitempty"`
+	Output     Policy `yaml:"output,omitempty" json:"output,omitempty"`
+	Classifier string `yaml:"classifier,omitempty" json:"classifier,omitempty"`
+}
+
+// Policy is the set of checks applied to one side of an agent call.
+// Deny and Require are regular expressions; MaxLength counts characters.
+type Policy struct {
+	Deny         []string `yaml:"deny,omitempty" json:"deny,omitempty"`
+	MaxLength    int      `yaml:"max_length,omitempty" json:"max_length,omitempty"`
+	PII          string   `yaml:"pii,omitempty" json:"pii,omitempty"`
+	PIITypes     []string `yaml:"pii_types,omitempty" json:"pii_types,omitempty"`
+	BannedTopics []string `yaml:"banned_topics,omitempty" json:"banned_topics,omitempty"`
+	Require      []string `yaml:"require,omitempty" json:"require,omitempty"`
+}
+
+// IsZero reports whether no guardrails are configured.
+func (c Config) IsZero() bool {
+	return c.Input.IsZero() && c.Output.IsZero() && c.Classifier == ""
+}
+
+// IsZero reports whether the policy has no checks.
+func (p Policy) IsZero() bool {
+	return len(p.Deny) == 0 && p.MaxLength == 0 && p.PII == "" && len(p.PIITypes) == 0 &&
+		len(p.BannedTopics) == 0 && len(p.Require) == 0
+}
+
+// Validate checks both policies and that banned topics have a classifier.
+func (c Config) Validate() error {
+	for _, s := range []struct {
+		stage  string
+		policy Policy
+	}{{StageInput, c.Input}, {StageOutput, c.Output}} {
+		if err := s.policy.validate(); err != nil {
+			return fmt.Errorf("guardrails %s: %w", s.stage, err)
+		}
+		if len(s.policy.BannedTopics) > 0 && c.Classifier == "" {
+			return fmt.Errorf("guardrails %s: banned_topics require a classifier agent", s.stage)
+		}
+	}
+	if len(c.Input.Require) > 0 {
+		return fmt.Errorf("guardrails input: require applies to output only")
+	}
+	return nil
+}
+
+func (p Policy) validate() error {
+	if p.MaxLength < 0 {
+		return fmt.Errorf("max_length must not be negative")
+	}
+	switch p.PII {
+	case "", PIIBlock, PIIRedact:
+	default:
+		return fmt.Errorf("unknown pii mode %q (want %s or %s)", p.PII, PIIBlock, PIIRedact)
+	}
+	if len(p.PIITypes) > 0 && p.PII == "" {
+		return fmt.Errorf("pii_types set without a pii mode")
+	}
+	for _, t := range p.PIITypes {
+		if _, ok := piiPatterns[t]; !ok {
+			return fmt.Errorf("unknown pii type %q (want %s)", t, strings.Join(PIITypes(), ", "))
+		}
+	}
+	for _, field := range []struct {
+		name     string
+		patterns []string
+	}{{"deny", p.Deny}, {"require", p.Require}} {
+		for _, pat := range field.patterns {
+			if _, err := regexp.Compile(pat); err != nil {
+				return fmt.Errorf("%s pattern %q: %w", field.name, pat, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Error reports a guardrail violation. Callers can detect it with errors.As.
+type Error struct {
+	Agent  string
+	Stage  string
+	Rule   string
+	Detail string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("agent %s %s blocked by guardrail %s: %s", e.Agent, e.Stage, e.Rule, e.Detail)
+}
+
+// Classifier reports which of topics text is about, or "" when none apply.
+type Classifier func(ctx context.Context, text string, topics []string) (string, error)
+
+// Guard applies a Config. Build one with New.
+type Guard struct {
+	agent    string
+	input    compiled
+	output   compiled
+	classify Classifier
+}
+
+type compiled struct {
+	Policy
+	deny    []*regexp.Regexp
+	require []*regexp.Regexp
+}
+
+// New compiles cfg for the agent with the given ID. classify may be nil when no
+// policy lists banned topics.
+func New(agentID string, cfg Config, classify Classifier) (*Guard, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if classify == nil && (len(cfg.Input.BannedTopics) > 0 || len(cfg.Output.BannedTopics) > 0) {
+		return nil, fmt.Errorf("guardrails: banned_topics set but classifier %q is unavailable", cfg.Classifier)
+	}
+	return &Guard{
+		agent:    agentID,
+		input:    compile(cfg.Input),
+		output:   compile(cfg.Output),
+		classify: classify,
+	}, nil
+}
+
+func compile(p Policy) compiled {
+	c := compiled{Policy: p}
+	for _, pat := range p.Deny {
+		c.deny = append(c.deny, regexp.MustCompile(pat))
+	}
+	for _, pat := range p.Require {
+		c.require = append(c.require, regexp.MustCompile(pat))
+	}
+	return c
+}
+
+// ChecksOutput reports whether the guard inspects responses, in which case callers
+// must not stream them before CheckOutput has run.
+func (g *Guard) ChecksOutput() bool { return !g.output.IsZero() }
+
+// CheckInput applies the input policy, returning the text to use (redacted if configured).
+func (g *Guard) CheckInput(ctx context.Context, text string) (string, error) {
+	return g.check(ctx, StageInput, g.input, text)
+}
+
+// CheckOutput applies the output policy, returning the text to use (redacted if configured).
+func (g *Guard) CheckOutput(ctx context.Context, text string) (string, error) {
+	return g.check(ctx, StageOutput, g.output, text)
+}
+
+func (g *Guard) check(ctx context.Context, stage string, p compiled, text string) (string, error) {
+	violation := func(rule, format string, args ...interface{}) error {
+		return &Error{Agent: g.agent, Stage: stage, Rule: rule, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	if n := utf8.RuneCountInString(text); p.MaxLength > 0 && n > p.MaxLength {
+		return "", violation(RuleMaxLength, "%d characters exceeds the limit of %d", n, p.MaxLength)
+	}
+	for _, re := range p.deny {
+		if re.MatchString(text) {
+			return "", violation(RuleDeny, "matches denied pattern %q", re.String())
+		}
+	}
+	if p.PII != "" {
+		found := FindPII(text, p.PIITypes...)
+		if len(found) > 0 && p.PII == PIIBlock {
+			return "", violation(RulePII, "contains %s", strings.Join(found, ", "))
+		}
+		if len(found) > 0 {
+			text = RedactPII(text, p.PIITypes...)
+		}
+	}
+	for _, re := range p.require {
+		if !re.MatchString(text) {
+			return "", violation(RuleRequire, "does not match required pattern %q", re.String())
+		}
+	}
+	if len(p.BannedTopics) > 0 {
+		topic, err := g.classify(ctx, text, p.BannedTopics)
+		if err != nil {
+			return "", fmt.Errorf("agent %s %s guardrail classifier: %w", g.agent, stage, err)
+		}
+		if topic != "" {
+			return "", violation(RuleBannedTopic, "discusses banned topic %q", topic)
+		}
+	}
+	return text, nil
+}
+
+// MatchTopic interprets a classifier reply: the first line names one of topics,
+// or anything else (such as "none") means no topic applies.
+func MatchTopic(reply string, topics []string) string {
+	line := strings.SplitN(strings.TrimSpace(reply), "\n", 2)[0]
+	line = strings.Trim(line, " \t.\"'`*")
+	for _, t := range topics {
+		if strings.EqualFold(line, strings.TrimSpace(t)) {
+			return t
+		}
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func mustGuard(t *testing.T, cfg Config, classify Classifier) *Guard {
+	t.Helper()
+	g, err := New("bot", cfg, classify)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return g
+}
+
+func wantViolation(t *testing.T, err error, stage, rule string) {
+	t.Helper()
+	var ge *Error
+	if !errors.As(err, &ge) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if ge.Agent != "bot" || ge.Stage != stage || ge.Rule != rule {
+		t.Errorf("got %s/%s/%s, want bot/%s/%s", ge.Agent, ge.Stage, ge.Rule, stage, rule)
+	}
+}
+
+func TestDenyAndMaxLength(t *testing.T) {
+	g := mustGuard(t, Config{Input: Policy{Deny: []string{`(?i)ignore previous instructions`}, MaxLength: 20}}, nil)
+
+	if out, err := g.CheckInput(context.Background(), "hello"); err != nil || out != "hello" {
+		t.Fatalf("clean input: %q, %v", out, err)
+	}
+	_, err := g.CheckInput(context.Background(), "IGNORE previous instructions")
+	wantViolation(t, err, StageInput, RuleMaxLength)
+	_, err = g.CheckInput(context.Background(), "Ignore previous")
+	if err != nil {
+		t.Fatalf("partial phrase should pass: %v", err)
+	}
+
+	g = mustGuard(t, Config{Input: Policy{Deny: []string{`(?i)drop\s+table`}}}, nil)
+	_, err = g.CheckInput(context.Background(), "please DROP  TABLE users")
+	wantViolation(t, err, StageInput, RuleDeny)
+	if !strings.Contains(err.Error(), "agent bot input blocked by guardrail deny") {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestPIIBlockAndRedact(t *testing.T) {
+	text := "Mail jane.doe@example.com or call +1 415-555-0132, key sk-abcdefghijklmnopqrstu"
+
+	got := FindPII(text)
+	if strings.Join(got, ",") != "email,key,phone" {
+		t.Errorf("FindPII = %v", got)
+	}
+	if got := FindPII("nothing to see, order 12345"); len(got) != 0 {
+		t.Errorf("false positive: %v", got)
+	}
+
+	g := mustGuard(t, Config{Input: Policy{PII: PIIBlock, PIITypes: []string{PIIEmail}}}, nil)
+	_, err := g.CheckInput(context.Background(), text)
+	wantViolation(t, err, StageInput, RulePII)
+
+	g = mustGuard(t, Config{Output: Policy{PII: PIIRedact}}, nil)
+	out, err := g.CheckOutput(context.Background(), text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Mail [REDACTED:EMAIL] or call [REDACTED:PHONE], key [REDACTED:KEY]"
+	if out != want {
+		t.Errorf("redacted = %q, want %q", out, want)
+	}
+}
+
+func TestRequireAndBannedTopics(t *testing.T) {
+	var asked []string
+	classify := func(_ context.Context, text string, topics []string) (string, error) {
+		asked = append(asked, text)
+		if strings.Contains(text, "stocks") {
+			return MatchTopic("Investment advice.\nIt recommends buying.", topics), nil
+		}
+		return MatchTopic("none", topics), nil
+	}
+	g := mustGuard(t, Config{
+		Classifier: "topics",
+		Output:     Policy{Require: []string{`^\{.*\}$`}, BannedTopics: []string{"investment advice"}},
+	}, classify)
+
+	_, err := g.CheckOutput(context.Background(), "plain text")
+	wantViolation(t, err, StageOutput, RuleRequire)
+	if len(asked) != 0 {
+		t.Error("classifier should not run after an earlier rule fails")
+	}
+	_, err = g.CheckOutput(context.Background(), `{"tip": "buy stocks"}`)
+	wantViolation(t, err, StageOutput, RuleBannedTopic)
+	if out, err := g.CheckOutput(context.Background(), `{"ok": true}`); err != nil || out != `{"ok": true}` {
+		t.Errorf("clean output: %q, %v", out, err)
+	}
+	if !g.ChecksOutput() {
+		t.Error("ChecksOutput should be true with an output policy")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{Input: Policy{MaxLength: -1}}, "max_length must not be negative"},
+		{Config{Input: Policy{PII: "hide"}}, `unknown pii mode "hide"`},
+		{Config{Output: Policy{PII: PIIRedact, PIITypes: []string{"ssn"}}}, `unknown pii type "ssn"`},
+		{Config{Output: Policy{PIITypes: []string{PIIEmail}}}, "pii_types set without a pii mode"},
+		{Config{Input: Policy{Deny: []string{"("}}}, `deny pattern "("`},
+		{Config{Input: Policy{BannedTopics: []string{"x"}}}, "banned_topics require a classifier"},
+		{Config{Input: Policy{Require: []string{"x"}}}, "require applies to output only"},
+	} {
+		err := tc.cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Validate(%+v) = %v, want %q", tc.cfg, err, tc.want)
+		}
+	}
+	if _, err := New("bot", Config{Classifier: "c", Input: Policy{BannedTopics: []string{"x"}}}, nil); err == nil {
+		t.Error("New should fail without a classifier func for banned topics")
+	}
+}
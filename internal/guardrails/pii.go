@@ -0,0 +1,73 @@
+package guardrails
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PII types detected by FindPII and RedactPII.
+const (
+	PIIEmail = "email"
+	PIIPhone = "phone"
+	PIIKey   = "key" // API keys, access tokens and private key blocks
+)
+
+var piiPatterns = map[string]*regexp.Regexp{
+	PIIEmail: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	PIIPhone: regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)|\d{2,4})[\s.-]?\d{3,4}[\s.-]?\d{4}\b`),
+	PIIKey: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----` +
+		`|\b(?:sk|pk|rk)[-_][A-Za-z0-9_-]{16,}` +
+		`|\bAKIA[0-9A-Z]{16}\b` +
+		`|\bgh[pousr]_[A-Za-z0-9]{30,}` +
+		`|\bxox[abpr]-[A-Za-z0-9-]{10,}`),
+}
+
+// PIITypes returns the supported PII type names.
+func PIITypes() []string {
+	types := make([]string, 0, len(piiPatterns))
+	for t := range piiPatterns {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// FindPII returns the PII types present in text, checking only types when given.
+func FindPII(text string, types ...string) []string {
+	var found []string
+	for _, t := range selectTypes(types) {
+		if piiPatterns[t].MatchString(text) {
+			found = append(found, t)
+		}
+	}
+	return found
+}
+
+// RedactPII replaces each PII match in text with [REDACTED:TYPE], checking only types when given.
+func RedactPII(text string, types ...string) string {
+	// Keys first, so a token that looks like a phone number is redacted as a whole.
+	for _, t := range []string{PIIKey, PIIEmail, PIIPhone} {
+		if !contains(selectTypes(types), t) {
+			continue
+		}
+		text = piiPatterns[t].ReplaceAllString(text, "[REDACTED:"+strings.ToUpper(t)+"]")
+	}
+	return text
+}
+
+func selectTypes(types []string) []string {
+	if len(types) == 0 {
+		return PIITypes()
+	}
+	return types
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,8 +16,23 @@ var (
 	globalLogger  *log.Logger
 	logFile       *os.File
 	mu            sync.Mutex
+	redacted      = map[string]bool{}
 )
 
+// RedactedText replaces redacted values in log entries.
+const RedactedText = "[REDACTED]"
+
+// Redact keeps values, such as resolved secrets, out of every later log entry.
+func Redact(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		if v != "" {
+			redacted[v] = true
+		}
+	}
+}
+
 const defaultLogPath = "./logs/keystone.log"
 
 // InitDefault initializes logger using the default dev path
@@ -75,6 +91,9 @@ func logEntry(symbol, message string, jsonMode bool) {
 		globalLogger = log.New(os.Stderr, "", 0)
 	}
 
+	for v := range redacted {
+		message = strings.ReplaceAll(message, v, RedactedText)
+	}
 	ts := time.Now().Format("2006-01-02 15:04:05")
 	entry := fmt.Sprintf("[%s] %s %s\n", ts, symbol, message)
 
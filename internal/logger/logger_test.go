@@ -83,3 +83,22 @@ func TestCloseDoesNotPanic(t *testing.T) {
 	Close()
 	Close()
 }
+
+func TestRedactHidesValues(t *testing.T) {
+	var buf bytes.Buffer
+	tmp := filepath.Join(t.TempDir(), "keystone_redact.log")
+	if err := InitWithWriter(tmp, false, &buf); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer Close()
+
+	Redact("sk-live-123", "")
+	Warn("calling api with key sk-live-123", false)
+
+	data, _ := os.ReadFile(tmp)
+	for _, out := range []string{buf.String(), string(data)} {
+		if strings.Contains(out, "sk-live-123") || !strings.Contains(out, "calling api with key [REDACTED]") {
+			t.Errorf("secret not redacted: %q", out)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package memory persists agent conversation history between runs.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Memory strategies.
+const (
+	StrategyBuffer  = "buffer"  // keep every turn
+	StrategyWindow  = "window"  // keep the most recent turns that fit in MaxTokens
+	StrategySummary = "summary" // fold older turns into a rolling LLM summary
+)
+
+// DefaultMaxTokens bounds window and summary memories when MaxTokens is unset.
+const DefaultMaxTokens = 1024
+
+// Turn roles.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Config selects and tunes an agent's memory strategy.
+type Config struct {
+	Strategy  string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+	MaxTokens int    `yaml:"max_tokens,omitempty" json:"max_tokens,omitempty"`
+}
+
+// Enabled reports whether a strategy has been configured.
+func (c Config) Enabled() bool { return c.Strategy != "" }
+
+// Validate checks the strategy name and token limit.
+func (c Config) Validate() error {
+	if c.Strategy != "" && !ValidStrategy(c.Strategy) {
+		return fmt.Errorf("unknown memory strategy %q (want %s)", c.Strategy, strings.Join(Strategies(), ", "))
+	}
+	if c.MaxTokens < 0 {
+		return fmt.Errorf("memory max_tokens must not be negative")
+	}
+	return nil
+}
+
+func (c Config) maxTokens() int {
+	if c.MaxTokens > 0 {
+		return c.MaxTokens
+	}
+	return DefaultMaxTokens
+}
+
+// Strategies returns the supported strategy names.
+func Strategies() []string {
+	return []string{StrategyBuffer, StrategyWindow, StrategySummary}
+}
+
+// ValidStrategy reports whether name is a supported strategy.
+func ValidStrategy(name string) bool {
+	for _, s := range Strategies() {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Turn is a single message in a conversation.
+type Turn struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Conversation is the stored history for one memory key.
+type Conversation struct {
+	Summary string `json:"summary,omitempty"`
+	Turns   []Turn `json:"turns"`
+}
+
+// Append adds a turn to the conversation.
+func (c *Conversation) Append(role, content string) {
+	c.Turns = append(c.Turns, Turn{Role: role, Content: content, Timestamp: time.Now()})
+}
+
+// Empty reports whether the conversation has no summary or turns.
+func (c *Conversation) Empty() bool {
+	return c.Summary == "" && len(c.Turns) == 0
+}
+
+// Render formats the summary and turns as a plain-text transcript.
+func (c *Conversation) Render() string {
+	var sb strings.Builder
+	if c.Summary != "" {
+		fmt.Fprintf(&sb, "Summary of earlier conversation:\n%s\n\n", c.Summary)
+	}
+	if len(c.Turns) > 0 {
+		sb.WriteString("Conversation so far:\n")
+		for _, t := range c.Turns {
+			fmt.Fprintf(&sb, "%s: %s\n", roleLabel(t.Role), t.Content)
+		}
+	}
+	return sb.String()
+}
+
+// Prepend returns input preceded by the rendered conversation, if any.
+func (c *Conversation) Prepend(input string) string {
+	if c.Empty() {
+		return input
+	}
+	return fmt.Sprintf("%s\n%s", c.Render(), input)
+}
+
+// Tokens estimates the size of the summary and turns.
+func (c *Conversation) Tokens() int {
+	n := EstimateTokens(c.Summary)
+	for _, t := range c.Turns {
+		n += EstimateTokens(t.Content)
+	}
+	return n
+}
+
+// Summarizer condenses a transcript into a short summary, typically via an LLM.
+type Summarizer func(ctx context.Context, transcript string) (string, error)
+
+// Compact applies the configured strategy so the conversation fits its budget.
+// The summary strategy falls back to windowing when no summarizer is available.
+func Compact(ctx context.Context, c *Conversation, cfg Config, summarize Summarizer) error {
+	switch cfg.Strategy {
+	case "", StrategyBuffer:
+		return nil
+	case StrategyWindow:
+		window(c, cfg.maxTokens())
+		return nil
+	case StrategySummary:
+		if summarize == nil {
+			window(c, cfg.maxTokens())
+			return nil
+		}
+		return summarizeOldest(ctx, c, cfg.maxTokens(), summarize)
+	default:
+		return fmt.Errorf("unknown memory strategy %q", cfg.Strategy)
+	}
+}
+
+// window drops the oldest turns until the conversation fits, always keeping the latest turn.
+func window(c *Conversation, limit int) {
+	for len(c.Turns) > 1 && c.Tokens() > limit {
+		c.Turns = c.Turns[1:]
+	}
+}
+
+// summarizeOldest folds the oldest turns into the summary until the rest fit in half the budget.
+func summarizeOldest(ctx context.Context, c *Conversation, limit int, summarize Summarizer) error {
+	if c.Tokens() <= limit {
+		return nil
+	}
+	keep := len(c.Turns)
+	kept := 0
+	for keep > 0 && kept+EstimateTokens(c.Turns[keep-1].Content) <= limit/2 {
+		kept += EstimateTokens(c.Turns[keep-1].Content)
+		keep--
+	}
+	if keep == 0 {
+		return nil
+	}
+
+	old := &Conversation{Summary: c.Summary, Turns: c.Turns[:keep]}
+	summary, err := summarize(ctx, old.Render())
+	if err != nil {
+		return fmt.Errorf("summarizing memory: %w", err)
+	}
+	c.Summary = strings.TrimSpace(summary)
+	c.Turns = append([]Turn(nil), c.Turns[keep:]...)
+	return nil
+}
+
+// EstimateTokens gives a rough token count for s (about four characters per token).
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+func roleLabel(role string) string {
+	switch role {
+	case RoleUser:
+		return "User"
+	case RoleAssistant:
+		return "Assistant"
+	default:
+		return role
+	}
+}
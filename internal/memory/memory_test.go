@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func conversationOf(contents ...string) *Conversation {
+	c := &Conversation{}
+	for i, content := range contents {
+		role := RoleUser
+		if i%2 == 1 {
+			role = RoleAssistant
+		}
+		c.Append(role, content)
+	}
+	return c
+}
+
+func TestConversationRenderAndPrepend(t *testing.T) {
+	c := &Conversation{}
+	if got := c.Prepend("hi"); got != "hi" {
+		t.Errorf("empty conversation should not change input, got %q", got)
+	}
+
+	c = conversationOf("hello", "hi there")
+	c.Summary = "User greeted."
+	got := c.Prepend("how are you?")
+	want := "Summary of earlier conversation:\nUser greeted.\n\nConversation so far:\nUser: hello\nAssistant: hi there\n\nhow are you?"
+	if got != want {
+		t.Errorf("unexpected prompt:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestCompactBufferKeepsEverything(t *testing.T) {
+	c := conversationOf(strings.Repeat("a", 400), strings.Repeat("b", 400))
+	if err := Compact(context.Background(), c, Config{Strategy: StrategyBuffer, MaxTokens: 10}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Turns) != 2 {
+		t.Errorf("expected 2 turns, got %d", len(c.Turns))
+	}
+}
+
+func TestCompactWindowDropsOldest(t *testing.T) {
+	c := conversationOf(strings.Repeat("a", 40), strings.Repeat("b", 40), strings.Repeat("c", 40), strings.Repeat("d", 40))
+	if err := Compact(context.Background(), c, Config{Strategy: StrategyWindow, MaxTokens: 20}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Turns) != 2 {
+		t.Fatalf("expected 2 turns within budget, got %d", len(c.Turns))
+	}
+	if c.Turns[0].Content[0] != 'c' || c.Turns[1].Content[0] != 'd' {
+		t.Errorf("expected the newest turns to be kept, got %+v", c.Turns)
+	}
+
+	// The latest turn is kept even if it alone exceeds the budget.
+	c = conversationOf(strings.Repeat("x", 400))
+	_ = Compact(context.Background(), c, Config{Strategy: StrategyWindow, MaxTokens: 5}, nil)
+	if len(c.Turns) != 1 {
+		t.Errorf("expected latest turn to survive, got %d turns", len(c.Turns))
+	}
+}
+
+func TestCompactSummaryFoldsOldTurns(t *testing.T) {
+	c := conversationOf(strings.Repeat("a", 40), strings.Repeat("b", 40), strings.Repeat("c", 40), strings.Repeat("d", 40))
+	c.Summary = "earlier"
+
+	var transcript string
+	summarize := func(_ context.Context, text string) (string, error) {
+		transcript = text
+		return " rolled up ", nil
+	}
+	if err := Compact(context.Background(), c, Config{Strategy: StrategySummary, MaxTokens: 40}, summarize); err != nil {
+		t.Fatal(err)
+	}
+	if c.Summary != "rolled up" {
+		t.Errorf("expected summary to be replaced, got %q", c.Summary)
+	}
+	if len(c.Turns) != 2 {
+		t.Errorf("expected 2 recent turns to remain, got %d", len(c.Turns))
+	}
+	if !strings.Contains(transcript, "earlier") || !strings.Contains(transcript, "User: aaaa") {
+		t.Errorf("summarizer should see the previous summary and old turns, got %q", transcript)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, s := range append(Strategies(), "") {
+		if err := (Config{Strategy: s}).Validate(); err != nil {
+			t.Errorf("strategy %q should be valid: %v", s, err)
+		}
+	}
+	if err := (Config{Strategy: "forever"}).Validate(); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+	if err := (Config{Strategy: StrategyWindow, MaxTokens: -1}).Validate(); err == nil {
+		t.Error("expected error for negative max_tokens")
+	}
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultDir is the default memory storage path.
+var DefaultDir = filepath.Join(os.Getenv("HOME"), ".keystone", "memory")
+
+// Key identifies a stored conversation: the agent's memory identifier plus user and ticket.
+type Key struct {
+	Memory   string
+	UserID   string
+	TicketID string
+}
+
+// Store handles file-based persistence of conversations.
+// Each conversation is saved as <dir>/<memory>/<user>_<ticket>.json.
+type Store struct {
+	dir   string
+	mu    sync.Mutex
+	turns map[string]*turnLock
+}
+
+// turnLock serializes turns on one conversation; waiters counts the callers holding
+// or waiting for it, so it can be dropped once nobody does.
+type turnLock struct {
+	sync.Mutex
+	waiters int
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   = map[string]*Store{}
+)
+
+// NewStore creates a memory store at dir.
+// If dir is empty, it defaults to KEYSTONE_MEMORY_DIR or DefaultDir.
+func NewStore(dir string) *Store {
+	if dir == "" {
+		if envDir := os.Getenv("KEYSTONE_MEMORY_DIR"); envDir != "" {
+			dir = envDir
+		} else {
+			dir = DefaultDir
+		}
+	}
+	return &Store{dir: dir}
+}
+
+// SharedStore returns the store for dir shared by everything in the process, so that
+// agents keeping memory in the same place also share its locks. An empty dir is
+// resolved as by NewStore.
+func SharedStore(dir string) *Store {
+	s := NewStore(dir)
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if existing, ok := shared[s.dir]; ok {
+		return existing
+	}
+	shared[s.dir] = s
+	return s
+}
+
+// TempStore creates a store in a new temporary directory named after pattern, as by
+// os.MkdirTemp, for runs whose history must not outlive them. remove deletes the
+// directory and everything stored in it.
+func TempStore(pattern string) (s *Store, remove func(), err error) {
+	dir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("memory: %w", err)
+	}
+	return NewStore(dir), func() { os.RemoveAll(dir) }, nil
+}
+
+// Lock holds the conversation for k until the returned function is called, so a turn
+// that loads, extends and saves it cannot interleave with another turn and lose it.
+func (s *Store) Lock(k Key) (unlock func()) {
+	path := s.path(k)
+	s.mu.Lock()
+	if s.turns == nil {
+		s.turns = make(map[string]*turnLock)
+	}
+	l, ok := s.turns[path]
+	if !ok {
+		l = &turnLock{}
+		s.turns[path] = l
+	}
+	l.waiters++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if l.waiters--; l.waiters == 0 {
+			delete(s.turns, path)
+		}
+	}
+}
+
+// sanitize makes a key component safe to use as a path element. Separators are
+// replaced, and so are the dots of "." and "..", which would leave the directory.
+func sanitize(s string) string {
+	if s == "" {
+		return "_"
+	}
+	s = filepath.Base(strings.NewReplacer("/", "_", "\\", "_").Replace(s))
+	if s == "." || s == ".." {
+		return strings.Repeat("_", len(s))
+	}
+	return s
+}
+
+func (s *Store) path(k Key) string {
+	return filepath.Join(s.dir, sanitize(k.Memory), fmt.Sprintf("%s_%s.json", sanitize(k.UserID), sanitize(k.TicketID)))
+}
+
+// Load returns the conversation for k, or an empty one if nothing is stored yet.
+func (s *Store) Load(k Key) (*Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(k))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Conversation{}, nil
+		}
+		return nil, err
+	}
+	var c Conversation
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("corrupt memory file %s: %w", s.path(k), err)
+	}
+	return &c, nil
+}
+
+// Save writes the conversation for k to disk.
+func (s *Store) Save(k Key, c *Conversation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.path(k)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Clear removes the stored conversation for k.
+func (s *Store) Clear(k Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path(k)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
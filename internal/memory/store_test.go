@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestStoreSaveLoadClear(t *testing.T) {
+	store := NewStore(t.TempDir())
+	key := Key{Memory: "session_test", UserID: "user1", TicketID: "cli/ticket-1"}
+
+	empty, err := store.Load(key)
+	if err != nil {
+		t.Fatalf("load of missing conversation failed: %v", err)
+	}
+	if !empty.Empty() {
+		t.Fatal("expected empty conversation")
+	}
+
+	conv := &Conversation{Summary: "s"}
+	conv.Append(RoleUser, "hello")
+	if err := store.Save(key, conv); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	loaded, err := store.Load(key)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if loaded.Summary != "s" || len(loaded.Turns) != 1 || loaded.Turns[0].Content != "hello" {
+		t.Errorf("unexpected conversation: %+v", loaded)
+	}
+
+	// Different tickets keep separate histories.
+	other, _ := store.Load(Key{Memory: "session_test", UserID: "user1", TicketID: "t2"})
+	if !other.Empty() {
+		t.Error("expected a separate conversation per ticket")
+	}
+
+	if err := store.Clear(key); err != nil {
+		t.Fatalf("clear failed: %v", err)
+	}
+	if err := store.Clear(key); err != nil {
+		t.Fatalf("clearing twice should not fail: %v", err)
+	}
+	cleared, _ := store.Load(key)
+	if !cleared.Empty() {
+		t.Error("expected conversation to be cleared")
+	}
+}
+
+func TestStoreLockSerializesTurns(t *testing.T) {
+	dir := t.TempDir()
+	if SharedStore(dir) != SharedStore(dir) {
+		t.Fatal("expected one shared store per dir")
+	}
+	store := SharedStore(dir)
+	key := Key{Memory: "session_test", UserID: "user1", TicketID: "t1"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			unlock := store.Lock(key)
+			defer unlock()
+			conv, err := store.Load(key)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conv.Append(RoleUser, fmt.Sprintf("turn %d", i))
+			if err := store.Save(key, conv); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	conv, _ := store.Load(key)
+	if len(conv.Turns) != 8 {
+		t.Errorf("expected every turn to be kept, got %d", len(conv.Turns))
+	}
+	if len(store.turns) != 0 {
+		t.Errorf("expected locks to be released, %d left", len(store.turns))
+	}
+}
+
+func TestStoreKeepsDotKeysInsideDir(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "memory")
+	store := NewStore(dir)
+
+	for _, key := range []Key{
+		{Memory: "..", UserID: "u", TicketID: "t"},
+		{Memory: ".", UserID: "..", TicketID: ".."},
+		{Memory: "../..", UserID: "u", TicketID: "../t"},
+	} {
+		conv := &Conversation{}
+		conv.Append(RoleUser, "hello")
+		if err := store.Save(key, conv); err != nil {
+			t.Fatalf("save %+v failed: %v", key, err)
+		}
+		if rel, err := filepath.Rel(dir, store.path(key)); err != nil || !filepath.IsLocal(rel) {
+			t.Errorf("key %+v is stored outside the memory dir at %s", key, store.path(key))
+		}
+	}
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 1 {
+		t.Errorf("expected only the memory dir in %s, got %d entries", root, len(entries))
+	}
+}
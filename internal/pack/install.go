@@ -0,0 +1,342 @@
+package pack
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"keystone/internal/agent"
+	"keystone/internal/diff"
+	"keystone/internal/prompt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RecordsDir is the directory inside the agents dir where installed packs are recorded.
+// Agent loading skips hidden directories, so records are never mistaken for agents.
+const RecordsDir = ".packs"
+
+// Record describes an installed pack. Files are relative to the directory holding the
+// agents dir, which also holds the prompts and workflows dirs.
+type Record struct {
+	Name        string    `yaml:"name" json:"name"`
+	Version     string    `yaml:"version" json:"version"`
+	Namespace   string    `yaml:"namespace" json:"namespace"`
+	Source      string    `yaml:"source" json:"source"`
+	InstalledAt time.Time `yaml:"installed_at" json:"installed_at"`
+	Agents      []string  `yaml:"agents" json:"agents"`
+	Files       []string  `yaml:"files" json:"files"`
+}
+
+// File is a file a pack installs, at Path.
+type File struct {
+	Path string
+	Data []byte
+}
+
+// Files renders the pack as it will be installed alongside agentsDir: agents under
+// <agentsDir>/<namespace>/, prompts in the prompt library and workflows in the
+// workflows dir next to it. It fails if any file would land outside its directory.
+func (p *Pack) Files(agentsDir string) ([]File, error) {
+	var files []File
+	add := func(dir, path string, data []byte) error {
+		if rel, err := filepath.Rel(dir, path); err != nil || !filepath.IsLocal(rel) {
+			return fmt.Errorf("pack file %s is outside %s", path, dir)
+		}
+		files = append(files, File{Path: path, Data: data})
+		return nil
+	}
+	for i := range p.Agents {
+		cfg := &p.Agents[i]
+		data, err := yaml.Marshal(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("encoding agent %s: %w", cfg.ID, err)
+		}
+		if err := add(agentsDir, p.AgentPath(agentsDir, cfg.ID), data); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range p.Prompts {
+		dir := agent.PromptsDir(agentsDir)
+		name := fmt.Sprintf("v%d%s", t.Version, prompt.TemplateExt)
+		if err := add(dir, filepath.Join(dir, t.Name, name), []byte(t.Text)); err != nil {
+			return nil, err
+		}
+	}
+	for i := range p.Workflows {
+		wf := &p.Workflows[i]
+		data, err := yaml.Marshal(wf)
+		if err != nil {
+			return nil, fmt.Errorf("encoding workflow %s: %w", wf.ID, err)
+		}
+		dir := agent.WorkflowsDir(agentsDir)
+		if err := add(dir, filepath.Join(dir, wf.ID+".yaml"), data); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// AgentPath returns where the pack installs the agent with the namespaced ID id.
+func (p *Pack) AgentPath(agentsDir, id string) string {
+	return filepath.Join(agentsDir, p.Namespace, strings.TrimPrefix(id, p.Namespace+".")+".yaml")
+}
+
+// ChangeKind says what installing a pack does to one file.
+type ChangeKind string
+
+const (
+	Added     ChangeKind = "added"
+	Modified  ChangeKind = "modified"
+	Removed   ChangeKind = "removed"
+	Unchanged ChangeKind = "unchanged"
+)
+
+// Change is the effect of an install on one file.
+type Change struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	Old  []byte     `json:"-"`
+	New  []byte     `json:"-"`
+}
+
+// Diff returns a unified diff of the change, or "" when the file is unchanged.
+func (c Change) Diff() string {
+	from, to := c.Path, c.Path
+	switch c.Kind {
+	case Added:
+		from = "/dev/null"
+	case Removed:
+		to = "/dev/null"
+	}
+	return diff.Unified(from, to, string(c.Old), string(c.New))
+}
+
+// Plan is the set of changes installing or upgrading a pack will make. Previous is
+// the installed record the plan upgrades, or nil for a fresh install.
+type Plan struct {
+	Pack     *Pack
+	Previous *Record
+	Changes  []Change
+}
+
+// Changed reports whether applying the plan would modify any file.
+func (p *Plan) Changed() bool {
+	for _, c := range p.Changes {
+		if c.Kind != Unchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// Installer installs packs alongside an agents directory.
+type Installer struct {
+	agentsDir string
+	root      string
+}
+
+// NewInstaller returns an installer for agentsDir.
+func NewInstaller(agentsDir string) *Installer {
+	return &Installer{agentsDir: agentsDir, root: filepath.Dir(filepath.Clean(agentsDir))}
+}
+
+// List returns the installed packs ordered by name.
+func (in *Installer) List() ([]Record, error) {
+	entries, err := os.ReadDir(filepath.Join(in.agentsDir, RecordsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []Record
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".yaml")
+		if e.IsDir() || !ok {
+			continue
+		}
+		rec, err := in.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		if rec != nil {
+			records = append(records, *rec)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Name < records[j].Name })
+	return records, nil
+}
+
+// Get returns the record of an installed pack, or nil when it is not installed.
+func (in *Installer) Get(name string) (*Record, error) {
+	data, err := os.ReadFile(in.recordPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var rec Record
+	if err := yaml.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("reading pack record %s: %w", name, err)
+	}
+	return &rec, nil
+}
+
+// Plan works out what installing p would change. It fails when p would overwrite a
+// file or agent ID that its previous install, if any, does not own.
+func (in *Installer) Plan(p *Pack) (*Plan, error) {
+	prev, err := in.Get(p.Manifest.Name)
+	if err != nil {
+		return nil, err
+	}
+	owned := map[string]bool{}
+	if prev != nil {
+		for _, f := range prev.Files {
+			owned[in.resolve(f)] = true
+		}
+	}
+
+	files, err := p.Files(in.agentsDir)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{Pack: p, Previous: prev}
+	wanted := map[string]bool{}
+	for _, f := range files {
+		path := filepath.Clean(f.Path)
+		wanted[path] = true
+		old, err := os.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+			plan.Changes = append(plan.Changes, Change{Path: path, Kind: Added, New: f.Data})
+		case err != nil:
+			return nil, err
+		case !owned[path]:
+			return nil, fmt.Errorf("%s already exists and is not part of pack %s", path, p.Manifest.Name)
+		case bytes.Equal(old, f.Data):
+			plan.Changes = append(plan.Changes, Change{Path: path, Kind: Unchanged, Old: old, New: f.Data})
+		default:
+			plan.Changes = append(plan.Changes, Change{Path: path, Kind: Modified, Old: old, New: f.Data})
+		}
+	}
+	if prev != nil {
+		for _, f := range prev.Files {
+			path := in.resolve(f)
+			if wanted[path] {
+				continue
+			}
+			old, _ := os.ReadFile(path)
+			plan.Changes = append(plan.Changes, Change{Path: path, Kind: Removed, Old: old})
+		}
+	}
+
+	if index, err := agent.IndexConfigDir(in.agentsDir); err == nil {
+		for _, cfg := range p.Agents {
+			if f, ok := index[cfg.ID]; ok && !owned[filepath.Clean(f.Path)] && !wanted[filepath.Clean(f.Path)] {
+				return nil, fmt.Errorf("agent %s already exists in %s", cfg.ID, f.Path)
+			}
+		}
+	}
+	sort.SliceStable(plan.Changes, func(i, j int) bool { return plan.Changes[i].Path < plan.Changes[j].Path })
+	return plan, nil
+}
+
+// Apply writes the plan's files, removes files the pack no longer ships and records
+// the install. source is recorded as where the pack came from.
+func (in *Installer) Apply(plan *Plan, source string) (*Record, error) {
+	p := plan.Pack
+	rec := &Record{
+		Name:        p.Manifest.Name,
+		Version:     p.Manifest.Version,
+		Namespace:   p.Namespace,
+		Source:      source,
+		InstalledAt: time.Now().UTC(),
+	}
+	for _, cfg := range p.Agents {
+		rec.Agents = append(rec.Agents, cfg.ID)
+	}
+
+	for _, c := range plan.Changes {
+		switch c.Kind {
+		case Removed:
+			if err := in.removeFile(c.Path); err != nil {
+				return nil, err
+			}
+			continue
+		case Added, Modified:
+			if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(c.Path, c.New, 0o644); err != nil {
+				return nil, err
+			}
+		}
+		rel, err := filepath.Rel(in.root, c.Path)
+		if err != nil {
+			return nil, err
+		}
+		rec.Files = append(rec.Files, filepath.ToSlash(rel))
+	}
+
+	data, err := yaml.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(in.agentsDir, RecordsDir), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(in.recordPath(rec.Name), data, 0o644); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// Remove deletes every file an installed pack owns and its record.
+func (in *Installer) Remove(name string) (*Record, error) {
+	rec, err := in.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("pack %s is not installed", name)
+	}
+	for _, f := range rec.Files {
+		if err := in.removeFile(in.resolve(f)); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.Remove(in.recordPath(name)); err != nil {
+		return nil, err
+	}
+	_ = os.Remove(filepath.Join(in.agentsDir, RecordsDir)) // only succeeds once no pack is left
+	return rec, nil
+}
+
+// removeFile deletes path and then its directory if that is left empty, as the
+// namespace and prompt directories a pack creates are.
+func (in *Installer) removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	switch dir := filepath.Dir(path); dir {
+	case filepath.Clean(in.agentsDir), agent.PromptsDir(in.agentsDir), agent.WorkflowsDir(in.agentsDir):
+	default:
+		_ = os.Remove(dir) // fails, harmlessly, unless the directory is empty
+	}
+	return nil
+}
+
+func (in *Installer) recordPath(name string) string {
+	return filepath.Join(in.agentsDir, RecordsDir, name+".yaml")
+}
+
+// resolve turns a path from a record back into one usable from the working directory.
+func (in *Installer) resolve(rel string) string {
+	return filepath.Join(in.root, filepath.FromSlash(rel))
+}
@@ -0,0 +1,140 @@
+// Package pack installs versioned bundles of agents, prompts and workflows, namespacing
+// their IDs so that packs from different teams cannot collide.
+package pack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the name of the manifest at the root of every pack.
+const ManifestFile = "pack.yaml"
+
+// nameRe matches valid pack names and namespaces, and the IDs of pack agents and workflows.
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Manifest describes a pack. Agents, Prompts and Workflows are paths relative to the
+// pack root: agent and workflow entries may be YAML files or directories of them,
+// prompt entries are prompt library directories (<name>/v<N>.tmpl). Namespace
+// defaults to Name; Providers lists the providers the pack's agents need.
+type Manifest struct {
+	Name        string   `yaml:"name" json:"name"`
+	Version     string   `yaml:"version" json:"version"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Namespace   string   `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Providers   []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	Agents      []string `yaml:"agents" json:"agents"`
+	Prompts     []string `yaml:"prompts,omitempty" json:"prompts,omitempty"`
+	Workflows   []string `yaml:"workflows,omitempty" json:"workflows,omitempty"`
+}
+
+// ReadManifest reads and validates the manifest of the pack in dir.
+func ReadManifest(dir string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, fmt.Errorf("%s is not a pack: no %s", dir, ManifestFile)
+		}
+		return m, err
+	}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("parsing %s: %w", ManifestFile, err)
+	}
+	if err := m.Validate(); err != nil {
+		return m, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	return m, nil
+}
+
+// Validate checks the manifest's name, version, namespace and paths.
+func (m Manifest) Validate() error {
+	if !nameRe.MatchString(m.Name) {
+		return fmt.Errorf("invalid pack name %q (use letters, digits, - and _)", m.Name)
+	}
+	if _, err := parseVersion(m.Version); err != nil {
+		return err
+	}
+	if m.Namespace != "" && !nameRe.MatchString(m.Namespace) {
+		return fmt.Errorf("invalid namespace %q (use letters, digits, - and _)", m.Namespace)
+	}
+	if len(m.Agents) == 0 {
+		return fmt.Errorf("pack %s lists no agents", m.Name)
+	}
+	for _, p := range m.Providers {
+		if strings.TrimSpace(p) == "" {
+			return fmt.Errorf("providers must not contain empty entries")
+		}
+	}
+	for _, list := range []struct {
+		field string
+		paths []string
+	}{{"agents", m.Agents}, {"prompts", m.Prompts}, {"workflows", m.Workflows}} {
+		for _, p := range list.paths {
+			if p == "" || filepath.IsAbs(p) || !filepath.IsLocal(filepath.FromSlash(p)) {
+				return fmt.Errorf("%s entry %q must be a path inside the pack", list.field, p)
+			}
+		}
+	}
+	return nil
+}
+
+// DefaultNamespace returns the namespace the pack installs into unless overridden.
+func (m Manifest) DefaultNamespace() string {
+	if m.Namespace != "" {
+		return m.Namespace
+	}
+	return m.Name
+}
+
+// ValidNamespace reports whether ns can be used as a pack namespace.
+func ValidNamespace(ns string) bool { return nameRe.MatchString(ns) }
+
+// CompareVersions compares two pack versions such as "1.2.0" or "v2", returning
+// -1, 0 or 1. Missing components count as zero, so "1.2" equals "1.2.0".
+func CompareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		switch {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// parseVersion splits a dotted numeric version, with an optional leading "v".
+func parseVersion(v string) ([]int, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(v), "v"), ".")
+	out := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid pack version %q (want numbers separated by dots, such as 1.2.0)", v)
+		}
+		out[i] = n
+	}
+	return out, nil
+}
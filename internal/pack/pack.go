@@ -0,0 +1,238 @@
+package pack
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"keystone/internal/agent"
+	"keystone/internal/prompt"
+	"keystone/internal/workflow"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pack is a pack read from disk, with every agent, prompt and workflow ID prefixed by
+// its namespace. References between items of the same pack are rewritten to match;
+// references to anything outside the pack are kept as written.
+type Pack struct {
+	Manifest  Manifest
+	Dir       string
+	Namespace string
+	Agents    []agent.AgentConfig
+	Prompts   []prompt.Template
+	Workflows []workflow.Workflow
+}
+
+// Open reads the pack in dir and namespaces its contents. An empty namespace uses the
+// manifest's default.
+func Open(dir, namespace string) (*Pack, error) {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		namespace = m.DefaultNamespace()
+	}
+	if !ValidNamespace(namespace) {
+		return nil, fmt.Errorf("invalid namespace %q (use letters, digits, - and _)", namespace)
+	}
+
+	p := &Pack{Manifest: m, Dir: dir, Namespace: namespace}
+	if err := p.readAgents(); err != nil {
+		return nil, err
+	}
+	if err := p.readPrompts(); err != nil {
+		return nil, err
+	}
+	if err := p.readWorkflows(); err != nil {
+		return nil, err
+	}
+	p.namespace()
+	return p, nil
+}
+
+// Qualify returns id inside the pack's namespace, such as "support.triage".
+func (p *Pack) Qualify(id string) string { return p.Namespace + "." + id }
+
+func (p *Pack) readAgents() error {
+	seen := map[string]string{}
+	for _, entry := range p.Manifest.Agents {
+		paths, err := yamlFiles(filepath.Join(p.Dir, filepath.FromSlash(entry)))
+		if err != nil {
+			return fmt.Errorf("agents entry %q: %w", entry, err)
+		}
+		for _, path := range paths {
+			cfg, err := agent.ReadConfigFile(path)
+			if err != nil {
+				return fmt.Errorf("reading agent %s: %w", path, err)
+			}
+			if cfg.ID == "" {
+				return fmt.Errorf("agent %s has no id", path)
+			}
+			if !nameRe.MatchString(cfg.ID) {
+				return fmt.Errorf("agent %s has invalid id %q (use letters, digits, - and _)", path, cfg.ID)
+			}
+			if prev, ok := seen[cfg.ID]; ok {
+				return fmt.Errorf("agent id %q is defined by both %s and %s", cfg.ID, prev, path)
+			}
+			seen[cfg.ID] = path
+			p.Agents = append(p.Agents, cfg)
+		}
+	}
+	return nil
+}
+
+func (p *Pack) readPrompts() error {
+	seen := map[string]bool{}
+	for _, entry := range p.Manifest.Prompts {
+		dir := filepath.Join(p.Dir, filepath.FromSlash(entry))
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("prompts entry %q is not a directory", entry)
+		}
+		lib, err := prompt.LoadLibrary(dir)
+		if err != nil {
+			return err
+		}
+		for _, t := range lib.List() {
+			if seen[t.Ref()] {
+				return fmt.Errorf("prompt %s is defined more than once", t.Ref())
+			}
+			seen[t.Ref()] = true
+			p.Prompts = append(p.Prompts, t)
+		}
+	}
+	return nil
+}
+
+func (p *Pack) readWorkflows() error {
+	seen := map[string]bool{}
+	for _, entry := range p.Manifest.Workflows {
+		paths, err := yamlFiles(filepath.Join(p.Dir, filepath.FromSlash(entry)))
+		if err != nil {
+			return fmt.Errorf("workflows entry %q: %w", entry, err)
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var wf workflow.Workflow
+			if err := yaml.Unmarshal(data, &wf); err != nil {
+				return fmt.Errorf("parsing workflow %s: %w", path, err)
+			}
+			if wf.ID == "" {
+				return fmt.Errorf("workflow %s has no id", path)
+			}
+			if !nameRe.MatchString(wf.ID) {
+				return fmt.Errorf("workflow %s has invalid id %q (use letters, digits, - and _)", path, wf.ID)
+			}
+			if seen[wf.ID] {
+				return fmt.Errorf("workflow id %q is defined more than once", wf.ID)
+			}
+			seen[wf.ID] = true
+			p.Workflows = append(p.Workflows, wf)
+		}
+	}
+	return nil
+}
+
+// namespace prefixes every ID in the pack and rewrites the references between them.
+func (p *Pack) namespace() {
+	agents := map[string]bool{}
+	for _, cfg := range p.Agents {
+		agents[cfg.ID] = true
+	}
+	prompts := map[string]bool{}
+	for _, t := range p.Prompts {
+		prompts[t.Name] = true
+	}
+	agentRef := func(id string) string {
+		if agents[id] {
+			return p.Qualify(id)
+		}
+		return id
+	}
+
+	for i := range p.Agents {
+		cfg := &p.Agents[i]
+		cfg.ID = p.Qualify(cfg.ID)
+		cfg.Extends = agentRef(cfg.Extends)
+		cfg.Guardrails.Classifier = agentRef(cfg.Guardrails.Classifier)
+		cfg.Voting.Scorer = agentRef(cfg.Voting.Scorer)
+		cfg.Router.Default = agentRef(cfg.Router.Default)
+		if cfg.Tools != nil {
+			tools := make([]string, len(cfg.Tools))
+			for j, id := range cfg.Tools {
+				tools[j] = agentRef(id)
+			}
+			cfg.Tools = tools
+		}
+		if cfg.Router.Routes != nil {
+			routes := append([]agent.Route(nil), cfg.Router.Routes...)
+			for j := range routes {
+				routes[j].Agent = agentRef(routes[j].Agent)
+			}
+			cfg.Router.Routes = routes
+		}
+		if name, version, found := strings.Cut(cfg.PromptRef, "@"); prompts[name] {
+			cfg.PromptRef = p.Qualify(name)
+			if found {
+				cfg.PromptRef += "@" + version
+			}
+		}
+	}
+
+	for i := range p.Prompts {
+		t := &p.Prompts[i]
+		t.Name = p.Qualify(t.Name)
+		t.Text = includeRe.ReplaceAllStringFunc(t.Text, func(s string) string {
+			m := includeRe.FindStringSubmatch(s)
+			if !prompts[m[2]] {
+				return s
+			}
+			return m[1] + p.Qualify(m[2]) + m[3]
+		})
+	}
+
+	for i := range p.Workflows {
+		wf := &p.Workflows[i]
+		wf.ID = p.Qualify(wf.ID)
+		steps := append([]workflow.Step(nil), wf.Steps...)
+		for j := range steps {
+			steps[j].AgentID = agentRef(steps[j].AgentID)
+			steps[j].Voting.Scorer = agentRef(steps[j].Voting.Scorer)
+		}
+		wf.Steps = steps
+	}
+}
+
+// includeRe matches the prompt name in a {{template "name"}} include.
+var includeRe = regexp.MustCompile(`(\{\{-?\s*template\s+")([^"@]+)((?:@v?\d+)?")`)
+
+// yamlFiles returns path itself or every YAML file beneath it, in lexical order.
+func yamlFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(p); !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
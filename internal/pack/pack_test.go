@@ -0,0 +1,253 @@
+package pack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"keystone/internal/agent"
+)
+
+// writeFiles creates files under dir from a map of slash-separated paths to contents.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// samplePack writes a pack with two agents, a prompt that includes another and a workflow.
+func samplePack(t *testing.T, version string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"pack.yaml":              "name: support\nversion: " + version + "\nproviders: [mock]\nagents: [agents]\nprompts: [prompts]\nworkflows: [workflows]\n",
+		"agents/triage.yaml":     "id: triage\nname: Triage\nprovider: mock\nprompt_ref: triage@v1\n",
+		"agents/helper.yaml":     "id: helper\nname: Helper\nprovider: mock\nextends: triage\ntools: [triage, shared_search]\n",
+		"prompts/triage/v1.tmpl": "{{template \"tone\"}} Triage: {{input}}\n",
+		"prompts/tone/v1.tmpl":   "Be kind.",
+		"workflows/handle.yaml":  "id: handle\nsteps:\n  - agent_id: triage\n    input: \"{{input}}\"\n",
+	})
+	return dir
+}
+
+func TestManifestValidate(t *testing.T) {
+	valid := Manifest{Name: "support", Version: "1.2.0", Agents: []string{"agents"}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid manifest, got %v", err)
+	}
+	for name, m := range map[string]Manifest{
+		"bad name":      {Name: "../x", Version: "1", Agents: []string{"a"}},
+		"bad version":   {Name: "x", Version: "1.beta", Agents: []string{"a"}},
+		"no agents":     {Name: "x", Version: "1"},
+		"escaping path": {Name: "x", Version: "1", Agents: []string{"../elsewhere"}},
+		"bad namespace": {Name: "x", Version: "1", Namespace: "a.b", Agents: []string{"a"}},
+	} {
+		if err := m.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2", 0},
+		{"v1.10.0", "1.9.9", 1},
+		{"0.9", "1", -1},
+	} {
+		got, err := CompareVersions(tc.a, tc.b)
+		if err != nil || got != tc.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, %v; want %d", tc.a, tc.b, got, err, tc.want)
+		}
+	}
+}
+
+func TestOpenNamespacesReferences(t *testing.T) {
+	p, err := Open(samplePack(t, "1.0.0"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Namespace != "support" || len(p.Agents) != 2 {
+		t.Fatalf("unexpected pack: %+v", p)
+	}
+	helper, triage := p.Agents[0], p.Agents[1]
+	if helper.ID != "support.helper" || helper.Extends != "support.triage" {
+		t.Errorf("helper not namespaced: %+v", helper)
+	}
+	if strings.Join(helper.Tools, ",") != "support.triage,shared_search" {
+		t.Errorf("only pack agents should be namespaced in tools, got %v", helper.Tools)
+	}
+	if triage.PromptRef != "support.triage@v1" {
+		t.Errorf("prompt_ref = %q", triage.PromptRef)
+	}
+	for _, tpl := range p.Prompts {
+		if tpl.Name == "support.triage" && !strings.Contains(tpl.Text, `{{template "support.tone"}}`) {
+			t.Errorf("include not namespaced: %q", tpl.Text)
+		}
+	}
+	if wf := p.Workflows[0]; wf.ID != "support.handle" || wf.Steps[0].AgentID != "support.triage" {
+		t.Errorf("workflow not namespaced: %+v", wf)
+	}
+
+	p, err = Open(samplePack(t, "1.0.0"), "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Agents[0].ID != "team.helper" {
+		t.Errorf("namespace override ignored: %s", p.Agents[0].ID)
+	}
+}
+
+func TestInstallerLifecycle(t *testing.T) {
+	root := t.TempDir()
+	agentsDir := filepath.Join(root, "agents")
+	inst := NewInstaller(agentsDir)
+	src := samplePack(t, "1.0.0")
+
+	p, err := Open(src, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := inst.Plan(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Previous != nil || len(plan.Changes) != 5 || !plan.Changed() {
+		t.Fatalf("unexpected install plan: %+v", plan)
+	}
+	rec, err := inst.Apply(plan, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range rec.Files {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(f))); err != nil {
+			t.Errorf("installed file missing: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "workflows", "support.handle.yaml")); err != nil {
+		t.Errorf("workflow not installed next to the agents dir: %v", err)
+	}
+
+	// Upgrade: one prompt changes, one agent is dropped.
+	writeFiles(t, src, map[string]string{"prompts/tone/v1.tmpl": "Be very kind."})
+	if err := os.Remove(filepath.Join(src, "agents", "helper.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	p, err = Open(src, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err = inst.Plan(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[ChangeKind]int{}
+	for _, c := range plan.Changes {
+		kinds[c.Kind]++
+		if c.Kind == Modified && !strings.Contains(c.Diff(), "+Be very kind.") {
+			t.Errorf("unexpected diff:\n%s", c.Diff())
+		}
+	}
+	if plan.Previous == nil || kinds[Modified] != 1 || kinds[Removed] != 1 || kinds[Unchanged] != 3 {
+		t.Fatalf("unexpected upgrade plan: %v", kinds)
+	}
+	if _, err := inst.Apply(plan, src); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(agentsDir, "support", "helper.yaml")); !os.IsNotExist(err) {
+		t.Error("dropped agent should have been removed")
+	}
+
+	records, err := inst.List()
+	if err != nil || len(records) != 1 || len(records[0].Agents) != 1 {
+		t.Fatalf("unexpected records: %+v, %v", records, err)
+	}
+	if _, err := inst.Remove("support"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(agentsDir, "support")); !os.IsNotExist(err) {
+		t.Error("empty namespace dir should have been removed")
+	}
+	if rec, _ := inst.Get("support"); rec != nil {
+		t.Error("record should have been removed")
+	}
+}
+
+func TestInstallerRefusesForeignFiles(t *testing.T) {
+	root := t.TempDir()
+	agentsDir := filepath.Join(root, "agents")
+	writeFiles(t, root, map[string]string{"agents/support/triage.yaml": "id: mine\nname: Mine\nprovider: mock\n"})
+
+	p, err := Open(samplePack(t, "1.0.0"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewInstaller(agentsDir).Plan(p); err == nil || !strings.Contains(err.Error(), "not part of pack support") {
+		t.Errorf("expected a conflict error, got %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(agentsDir, "support", "triage.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, map[string]string{"agents/other.yaml": "id: support.helper\nname: Taken\nprovider: mock\n"})
+	if _, err := NewInstaller(agentsDir).Plan(p); err == nil || !strings.Contains(err.Error(), "agent support.helper already exists") {
+		t.Errorf("expected an ID collision error, got %v", err)
+	}
+}
+
+func TestPackRejectsPathTraversal(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"agent": {
+			"pack.yaml":       "name: evil\nversion: 1\nagents: [agents]\n",
+			"agents/bad.yaml": "id: x/../../../../escaped_agent\nname: Bad\nprovider: mock\n",
+		},
+		"workflow": {
+			"pack.yaml": "name: evil\nversion: 1\nagents: [a.yaml]\nworkflows: [wf.yaml]\n",
+			"a.yaml":    "id: a\nname: A\nprovider: mock\n",
+			"wf.yaml":   "id: ../../escaped_workflow\nsteps: []\n",
+		},
+	} {
+		dir := t.TempDir()
+		writeFiles(t, dir, files)
+		if _, err := Open(dir, ""); err == nil || !strings.Contains(err.Error(), "invalid id") {
+			t.Errorf("%s: expected an invalid id error, got %v", name, err)
+		}
+	}
+
+	root := t.TempDir()
+	p := &Pack{Namespace: "evil"}
+	p.Agents = append(p.Agents, agent.AgentConfig{ID: "evil.../../escaped", Name: "Bad", Provider: "mock"})
+	if _, err := NewInstaller(filepath.Join(root, "agents")).Plan(p); err == nil || !strings.Contains(err.Error(), "outside") {
+		t.Errorf("expected the install to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped.yaml")); !os.IsNotExist(err) {
+		t.Errorf("nothing should be written outside the agents dir: %v", err)
+	}
+}
+
+func TestIsGitURL(t *testing.T) {
+	local := t.TempDir()
+	for src, want := range map[string]bool{
+		"https://example.com/team/pack.git": true,
+		"git@example.com:team/pack.git":     true,
+		"./packs/support":                   false,
+		local:                               false,
+	} {
+		if got := IsGitURL(src); got != want {
+			t.Errorf("IsGitURL(%q) = %v, want %v", src, got, want)
+		}
+	}
+	if _, _, err := Fetch(t.Context(), local, "main"); err == nil {
+		t.Error("expected an error for a ref on a local directory")
+	}
+}
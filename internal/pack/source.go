@@ -0,0 +1,71 @@
+package pack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// IsGitURL reports whether src names a remote git repository rather than a local directory.
+func IsGitURL(src string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "file://", "git@"} {
+		if strings.HasPrefix(src, prefix) {
+			return true
+		}
+	}
+	if strings.HasSuffix(src, ".git") {
+		info, err := os.Stat(src)
+		return err != nil || !info.IsDir()
+	}
+	return false
+}
+
+// Fetch returns a local directory holding the pack at src. A git URL is cloned,
+// at ref when one is given, into a temporary directory that cleanup removes; a
+// local directory, which may be a git checkout, is used as is.
+func Fetch(ctx context.Context, src, ref string) (dir string, cleanup func(), err error) {
+	if !IsGitURL(src) {
+		if ref != "" {
+			return "", nil, fmt.Errorf("a ref can only be given for a git URL, not %s", src)
+		}
+		info, err := os.Stat(src)
+		if err != nil {
+			return "", nil, err
+		}
+		if !info.IsDir() {
+			return "", nil, fmt.Errorf("%s is not a directory", src)
+		}
+		return src, func() {}, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "keystone-pack-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(tmp) }
+	dir = filepath.Join(tmp, "pack")
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	out, err := exec.CommandContext(ctx, "git", append(args, src, dir)...).CombinedOutput()
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("cloning %s: %v: %s", src, err, strings.TrimSpace(string(out)))
+	}
+	return dir, cleanup, nil
+}
+
+// SourceOf returns how src is recorded: git URLs as given, directories as absolute paths.
+func SourceOf(src string) string {
+	if IsGitURL(src) {
+		return src
+	}
+	if abs, err := filepath.Abs(src); err == nil {
+		return abs
+	}
+	return src
+}
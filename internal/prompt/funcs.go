@@ -0,0 +1,54 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// Funcs returns the filters available to prompt templates.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+		"truncate": truncate,
+		"json":     toJSON,
+		"default":  defaultValue,
+	}
+}
+
+// truncate shortens s to at most n runes.
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if n < 0 || len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// toJSON encodes v as compact JSON.
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("json filter: %w", err)
+	}
+	return string(b), nil
+}
+
+// defaultValue returns v unless it is empty, in which case def is returned.
+func defaultValue(def, v any) any {
+	if v == nil {
+		return def
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if rv.Len() == 0 {
+			return def
+		}
+	}
+	return v
+}
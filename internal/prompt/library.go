@@ -0,0 +1,264 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// TemplateExt is the file extension of prompt library templates.
+const TemplateExt = ".tmpl"
+
+// Template is a single version of a named prompt in the library.
+// Templates live at <dir>/<name>/v<version>.tmpl.
+type Template struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+	Path    string `json:"path"`
+	Text    string `json:"-"`
+}
+
+// Ref returns the canonical reference for the template, e.g. "summarize@v2".
+func (t Template) Ref() string {
+	return fmt.Sprintf("%s@v%d", t.Name, t.Version)
+}
+
+// Library is a directory of named, versioned prompt templates.
+type Library struct {
+	dir     string
+	prompts map[string][]Template // sorted by ascending version
+}
+
+// LoadLibrary reads every template under dir. A missing directory yields an empty library.
+func LoadLibrary(dir string) (*Library, error) {
+	lib := &Library{dir: dir, prompts: make(map[string][]Template)}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lib, nil
+		}
+		return nil, fmt.Errorf("failed to read prompts dir %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		files, err := os.ReadDir(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt %s: %w", name, err)
+		}
+		for _, f := range files {
+			version, ok := parseVersionFile(f.Name())
+			if f.IsDir() || !ok {
+				continue
+			}
+			path := filepath.Join(dir, name, f.Name())
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read prompt %s: %w", path, err)
+			}
+			lib.prompts[name] = append(lib.prompts[name], Template{
+				Name:    name,
+				Version: version,
+				Path:    path,
+				Text:    string(data),
+			})
+		}
+		sort.Slice(lib.prompts[name], func(i, j int) bool {
+			return lib.prompts[name][i].Version < lib.prompts[name][j].Version
+		})
+	}
+	return lib, nil
+}
+
+// Dir returns the directory the library was loaded from.
+func (l *Library) Dir() string { return l.dir }
+
+// List returns every template version, ordered by name then version.
+func (l *Library) List() []Template {
+	names := make([]string, 0, len(l.prompts))
+	for name := range l.prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []Template
+	for _, name := range names {
+		out = append(out, l.prompts[name]...)
+	}
+	return out
+}
+
+// Add registers t in memory alongside the templates read from disk, replacing any
+// template with the same name and version, so configs can be checked against
+// prompts that have not been written yet.
+func (l *Library) Add(t Template) {
+	versions := l.prompts[t.Name]
+	for i, v := range versions {
+		if v.Version == t.Version {
+			versions[i] = t
+			return
+		}
+	}
+	versions = append(versions, t)
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	l.prompts[t.Name] = versions
+}
+
+// Versions returns all versions of a named prompt in ascending order.
+func (l *Library) Versions(name string) []Template {
+	return l.prompts[name]
+}
+
+// Get returns the template for a reference such as "name", "name@v2" or "name@2".
+// A reference without a version resolves to the latest version.
+func (l *Library) Get(ref string) (Template, error) {
+	name, version, err := ParseRef(ref)
+	if err != nil {
+		return Template{}, err
+	}
+	versions := l.prompts[name]
+	if len(versions) == 0 {
+		return Template{}, fmt.Errorf("prompt %q not found in %s", name, l.dir)
+	}
+	if version == 0 {
+		return versions[len(versions)-1], nil
+	}
+	for _, t := range versions {
+		if t.Version == version {
+			return t, nil
+		}
+	}
+	return Template{}, fmt.Errorf("prompt %q has no version v%d", name, version)
+}
+
+// Resolve returns the text of a referenced prompt with every included prompt
+// inlined as a {{define}} block, so the result renders without the library.
+func (l *Library) Resolve(ref string) (string, error) {
+	root, err := l.Get(ref)
+	if err != nil {
+		return "", err
+	}
+
+	var defs strings.Builder
+	defined := map[string]bool{}
+
+	var visit func(t Template, stack []string) error
+	visit = func(t Template, stack []string) error {
+		includes, err := includedNames(t.Text)
+		if err != nil {
+			return fmt.Errorf("prompt %s: %w", t.Ref(), err)
+		}
+		for _, inc := range includes {
+			dep, err := l.Get(inc)
+			if err != nil {
+				return fmt.Errorf("prompt %s includes %q: %w", t.Ref(), inc, err)
+			}
+			for _, seen := range stack {
+				if seen == dep.Ref() {
+					return fmt.Errorf("prompt include cycle: %s -> %s", strings.Join(stack, " -> "), dep.Ref())
+				}
+			}
+			if defined[inc] {
+				continue
+			}
+			if strings.Contains(dep.Text, "{{define") {
+				return fmt.Errorf("prompt %s cannot be included: it declares its own define blocks", dep.Ref())
+			}
+			defined[inc] = true
+			if err := visit(dep, append(stack, dep.Ref())); err != nil {
+				return err
+			}
+			// Partials usually end with a newline that would double up at the include site.
+			fmt.Fprintf(&defs, "{{define %q}}%s{{end}}", inc, strings.TrimSuffix(dep.Text, "\n"))
+		}
+		return nil
+	}
+
+	if err := visit(root, []string{root.Ref()}); err != nil {
+		return "", err
+	}
+	return root.Text + defs.String(), nil
+}
+
+// ParseRef splits a prompt reference into its name and version (0 means latest).
+func ParseRef(ref string) (string, int, error) {
+	name, ver, hasVersion := strings.Cut(strings.TrimSpace(ref), "@")
+	if name == "" {
+		return "", 0, fmt.Errorf("invalid prompt reference %q", ref)
+	}
+	if !hasVersion {
+		return name, 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(ver, "v"))
+	if err != nil || n <= 0 {
+		return "", 0, fmt.Errorf("invalid prompt version in reference %q", ref)
+	}
+	return name, n, nil
+}
+
+// parseVersionFile extracts the version from a file name like "v3.tmpl".
+func parseVersionFile(name string) (int, bool) {
+	if filepath.Ext(name) != TemplateExt || !strings.HasPrefix(name, "v") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "v"), TemplateExt))
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// includedNames returns the templates invoked by text that it does not define itself.
+func includedNames(text string) ([]string, error) {
+	tpl, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	local := map[string]bool{}
+	for _, t := range tpl.Templates() {
+		local[t.Name()] = true
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			if !local[n.Name] && !seen[n.Name] {
+				seen[n.Name] = true
+				names = append(names, n.Name)
+			}
+		}
+	}
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,110 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writePrompt writes a prompt version into a library directory.
+func writePrompt(t *testing.T, dir, name string, version int, text string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0o755))
+	path := filepath.Join(dir, name, fmt.Sprintf("v%d%s", version, TemplateExt))
+	require.NoError(t, os.WriteFile(path, []byte(text), 0o644))
+}
+
+func TestLibrary_GetVersions(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "summarize", 1, "Summarize: {{input}}\n")
+	writePrompt(t, dir, "summarize", 2, "Summarize briefly: {{input}}\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "summarize", "notes.txt"), []byte("ignored"), 0o644))
+
+	lib, err := LoadLibrary(dir)
+	require.NoError(t, err)
+	require.Len(t, lib.List(), 2)
+
+	latest, err := lib.Get("summarize")
+	require.NoError(t, err)
+	require.Equal(t, 2, latest.Version)
+	require.Equal(t, "summarize@v2", latest.Ref())
+
+	v1, err := lib.Get("summarize@v1")
+	require.NoError(t, err)
+	require.Equal(t, "Summarize: {{input}}\n", v1.Text)
+
+	_, err = lib.Get("summarize@v3")
+	require.Error(t, err)
+	_, err = lib.Get("missing")
+	require.Error(t, err)
+	_, err = lib.Get("summarize@vX")
+	require.Error(t, err)
+}
+
+func TestLibrary_ResolveIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "rules", 1, "Be brief.\n")
+	writePrompt(t, dir, "persona", 1, "You are {{.Params.role}}. {{template \"rules\" .}}\n")
+	writePrompt(t, dir, "agent", 1, "{{template \"persona@v1\" .}}\nTask: {{input}}\n")
+
+	lib, err := LoadLibrary(dir)
+	require.NoError(t, err)
+
+	text, err := lib.Resolve("agent")
+	require.NoError(t, err)
+
+	out, err := Render(text, NewData("a1", "count sheep", "", map[string]string{"role": "a shepherd"}, nil))
+	require.NoError(t, err)
+	require.Equal(t, "You are a shepherd. Be brief.\nTask: count sheep\n", out)
+
+	// Parameters used by included prompts are reported as required.
+	vars, err := Inspect(text)
+	require.NoError(t, err)
+	require.Equal(t, []string{"role"}, vars.Required)
+}
+
+func TestLibrary_ResolveErrors(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "a", 1, "{{template \"b\" .}}")
+	writePrompt(t, dir, "b", 1, "{{template \"a\" .}}")
+	writePrompt(t, dir, "c", 1, "{{template \"nope\" .}}")
+
+	lib, err := LoadLibrary(dir)
+	require.NoError(t, err)
+
+	_, err = lib.Resolve("a")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cycle")
+
+	_, err = lib.Resolve("c")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "nope")
+}
+
+func TestLoadLibrary_MissingDir(t *testing.T) {
+	lib, err := LoadLibrary(filepath.Join(t.TempDir(), "absent"))
+	require.NoError(t, err)
+	require.Empty(t, lib.List())
+}
+
+func TestLibrary_Add(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "summarize", 1, "v1 {{input}}\n")
+	lib, err := LoadLibrary(dir)
+	require.NoError(t, err)
+
+	lib.Add(Template{Name: "summarize", Version: 3, Text: "v3 {{input}}\n"})
+	lib.Add(Template{Name: "summarize", Version: 1, Text: "new v1 {{input}}\n"})
+	lib.Add(Template{Name: "greet", Version: 1, Text: "hi\n"})
+
+	latest, err := lib.Get("summarize")
+	require.NoError(t, err)
+	require.Equal(t, 3, latest.Version)
+	v1, err := lib.Get("summarize@v1")
+	require.NoError(t, err)
+	require.Equal(t, "new v1 {{input}}\n", v1.Text)
+	require.Len(t, lib.List(), 3)
+}
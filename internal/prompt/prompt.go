@@ -0,0 +1,415 @@
+// Package prompt renders agent prompt templates using text/template.
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"keystone/internal/tickets"
+)
+
+// Data is the value a prompt template is executed against.
+type Data struct {
+	Input    string                       // input for the current run
+	Params   map[string]string            // merged agent and caller parameters
+	Previous string                       // output of the previous workflow step
+	AgentID  string                       // ID of the agent rendering the prompt
+	Context  map[string]string            // ticket context namespaced to AgentID
+	Ticket   map[string]map[string]string // ticket context for every agent namespace
+}
+
+// NewData builds template data from a run's input, parameters and optional ticket.
+func NewData(agentID, input, previous string, params map[string]string, t *tickets.Ticket) Data {
+	if params == nil {
+		params = map[string]string{}
+	}
+	d := Data{
+		Input:    input,
+		Params:   params,
+		Previous: previous,
+		AgentID:  agentID,
+		Context:  map[string]string{},
+		Ticket:   map[string]map[string]string{},
+	}
+	if t == nil {
+		return d
+	}
+	for k, v := range t.SerializeContext() {
+		s, ok := v.(string)
+		if !ok || !strings.HasPrefix(k, "agent.") {
+			continue
+		}
+		ns, key, found := strings.Cut(strings.TrimPrefix(k, "agent."), ".")
+		if !found {
+			continue
+		}
+		if d.Ticket[ns] == nil {
+			d.Ticket[ns] = map[string]string{}
+		}
+		d.Ticket[ns][key] = s
+	}
+	if own, ok := d.Ticket[agentID]; ok {
+		d.Context = own
+	}
+	return d
+}
+
+// keys returns the names of the parameters in d.
+func (d Data) keys() []string {
+	keys := make([]string, 0, len(d.Params))
+	for k := range d.Params {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// MissingVariableError is returned when a template references parameters that were not supplied.
+type MissingVariableError struct {
+	Names []string
+}
+
+func (e *MissingVariableError) Error() string {
+	return fmt.Sprintf("missing template variable(s): %s", strings.Join(e.Names, ", "))
+}
+
+// Render executes a template against data and returns the result.
+func Render(text string, data Data) (string, error) {
+	tpl, err := Parse(text, data.keys()...)
+	if err != nil {
+		return "", err
+	}
+	return execute(tpl, inspectTemplate(tpl), data)
+}
+
+// Build renders a template into the final prompt sent to a provider.
+// Templates that never reference the input have it appended on a new line.
+func Build(text string, data Data) (string, error) {
+	if text == "" {
+		return data.Input, nil
+	}
+	tpl, err := Parse(text, data.keys()...)
+	if err != nil {
+		return "", err
+	}
+	vars := inspectTemplate(tpl)
+	rendered, err := execute(tpl, vars, data)
+	if err != nil {
+		return "", err
+	}
+	if vars.UsesInput {
+		return rendered, nil
+	}
+	return fmt.Sprintf("%s\n%s", rendered, data.Input), nil
+}
+
+// execute checks required parameters are present and runs the template.
+func execute(tpl *template.Template, vars Variables, data Data) (string, error) {
+	var missing []string
+	for _, name := range vars.Required {
+		if _, ok := data.Params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", &MissingVariableError{Names: missing}
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Parse parses a prompt template, accepting legacy {{name}} placeholders. keys are
+// the parameter names the template may refer to; see normalize.
+func Parse(text string, keys ...string) (*template.Template, error) {
+	known := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		known[k] = true
+	}
+	tpl, err := template.New("prompt").
+		Funcs(Funcs()).
+		Option("missingkey=zero").
+		Parse(normalize(text, known))
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %w", err)
+	}
+	return tpl, nil
+}
+
+// -------------------------
+// Legacy placeholders
+// -------------------------
+
+// action matches a template action, e.g. {{input}}, {{query | upper}} or {{truncate 10 input}}.
+var action = regexp.MustCompile(`(?s)\{\{(.*?)\}\}`)
+
+// operand matches the tokens of an action: quoted strings, field and variable chains,
+// and numbers are matched whole so that only bare names are left to rewrite. Names may
+// contain dashes, as parameter keys such as max-len do.
+var operand = regexp.MustCompile(`"(?:[^"\\]|\\.)*"` + "|`[^`]*`|" + `'(?:[^'\\]|\\.)*'|[.$]?[A-Za-z_][\w-]*(?:\.[A-Za-z_][\w-]*)*|\d[\w.]*`)
+
+// identRe matches names that can be looked up as a field, e.g. .Params.query.
+var identRe = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// reserved lists identifiers that must not be rewritten into field lookups.
+var reserved = map[string]bool{
+	"if": true, "else": true, "end": true, "range": true, "with": true,
+	"define": true, "template": true, "block": true, "break": true, "continue": true,
+	"nil": true, "true": true, "false": true,
+	"and": true, "or": true, "not": true, "len": true, "index": true, "slice": true,
+	"print": true, "printf": true, "println": true, "html": true, "js": true, "urlquery": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true, "call": true,
+}
+
+// normalize rewrites legacy placeholders into lookups on Data. Bare names in an action
+// are placeholders, as a filter's operand ({{query | upper}}) or a function's argument
+// ({{truncate 10 input}}), unless they name a function or keyword. A parameter in keys
+// is a placeholder even when it shares a function's or keyword's name, except where it
+// is called with arguments, so {{default}} still renders a parameter named default.
+func normalize(text string, keys map[string]bool) string {
+	funcs := Funcs()
+	return action.ReplaceAllStringFunc(text, func(m string) string {
+		body := m[2 : len(m)-2]
+		if strings.HasPrefix(strings.TrimLeft(body, "- "), "/*") {
+			return m // comment
+		}
+		return "{{" + replaceOperands(body, func(tok string, called bool) string {
+			if !isIdentStart(tok[0]) {
+				return tok
+			}
+			if keys[tok] && tok != "input" && tok != "previous" {
+				if _, isFunc := funcs[tok]; !called || (!isFunc && !reserved[tok]) {
+					return paramLookup(tok, "")
+				}
+			}
+			name, rest, _ := strings.Cut(tok, ".")
+			if _, isFunc := funcs[name]; isFunc || reserved[name] {
+				return tok
+			}
+			switch name {
+			case "input":
+				return withRest(".Input", rest)
+			case "previous":
+				return withRest(".Previous", rest)
+			}
+			return paramLookup(name, rest)
+		}) + "}}"
+	})
+}
+
+// replaceOperands calls replace for every token of an action body. called reports
+// whether the token is in a function's place: a later pipeline stage, or the start of
+// a command that is given arguments.
+func replaceOperands(body string, replace func(tok string, called bool) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range operand.FindAllStringIndex(body, -1) {
+		before := strings.TrimRight(body[:loc[0]], " \t\r\n")
+		after := strings.TrimLeft(body[loc[1]:], " \t\r\n")
+		piped := strings.HasSuffix(before, "|")
+		starts := before == "" || before == "-" || strings.HasSuffix(before, "(")
+		hasArgs := after != "" && after != "-" && !strings.HasPrefix(after, "|") && !strings.HasPrefix(after, ")")
+		b.WriteString(body[last:loc[0]])
+		b.WriteString(replace(body[loc[0]:loc[1]], piped || (starts && hasArgs)))
+		last = loc[1]
+	}
+	b.WriteString(body[last:])
+	return b.String()
+}
+
+// paramLookup returns the expression looking up parameter name, followed by the
+// field chain rest. Names that are not identifiers are looked up with index.
+func paramLookup(name, rest string) string {
+	if identRe.MatchString(name) {
+		return withRest(".Params."+name, rest)
+	}
+	return withRest(fmt.Sprintf("(index .Params %s)", strconv.Quote(name)), rest)
+}
+
+func withRest(field, rest string) string {
+	if rest == "" {
+		return field
+	}
+	return field + "." + rest
+}
+
+// isIdentStart reports whether c can start a bare identifier.
+func isIdentStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// -------------------------
+// Template inspection
+// -------------------------
+
+// Variables describes the data a template references.
+type Variables struct {
+	Required  []string // parameters that must be supplied
+	Optional  []string // parameters guarded by default, if or with
+	UsesInput bool     // whether the template references the input
+}
+
+// Inspect parses a template and reports the variables it references. keys are the
+// parameter names the template may refer to, as for Parse.
+func Inspect(text string, keys ...string) (Variables, error) {
+	tpl, err := Parse(text, keys...)
+	if err != nil {
+		return Variables{}, err
+	}
+	return inspectTemplate(tpl), nil
+}
+
+func inspectTemplate(tpl *template.Template) Variables {
+	w := &walker{required: map[string]bool{}, optional: map[string]bool{}, checked: map[string]int{}}
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil {
+			w.node(t.Tree.Root, true, false)
+		}
+	}
+
+	vars := Variables{UsesInput: w.usesInput}
+	for name := range w.required {
+		vars.Required = append(vars.Required, name)
+	}
+	for name := range w.optional {
+		if !w.required[name] {
+			vars.Optional = append(vars.Optional, name)
+		}
+	}
+	sort.Strings(vars.Required)
+	sort.Strings(vars.Optional)
+	return vars
+}
+
+// walker collects field references from a parsed template tree.
+type walker struct {
+	required  map[string]bool
+	optional  map[string]bool
+	checked   map[string]int // parameters tested by an enclosing if
+	usesInput bool
+}
+
+// node visits n. rootDot reports whether dot still refers to Data;
+// guarded reports whether missing values are tolerated at this point.
+func (w *walker) node(n parse.Node, rootDot, guarded bool) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			w.node(c, rootDot, guarded)
+		}
+	case *parse.ActionNode:
+		w.pipe(n.Pipe, rootDot, guarded)
+	case *parse.IfNode:
+		// Parameters tested by the condition are safe to use in its body.
+		cond := &walker{required: map[string]bool{}, optional: map[string]bool{}, checked: map[string]int{}}
+		cond.pipe(n.Pipe, rootDot, false)
+		for name := range cond.required {
+			w.checked[name]++
+		}
+		w.branch(&n.BranchNode, rootDot, guarded, rootDot)
+		for name := range cond.required {
+			w.checked[name]--
+		}
+	case *parse.WithNode:
+		w.branch(&n.BranchNode, rootDot, guarded, false)
+	case *parse.RangeNode:
+		w.branch(&n.BranchNode, rootDot, guarded, false)
+	case *parse.TemplateNode:
+		w.pipe(n.Pipe, rootDot, guarded)
+	}
+}
+
+func (w *walker) branch(b *parse.BranchNode, rootDot, guarded, bodyRootDot bool) {
+	w.pipe(b.Pipe, rootDot, true)
+	w.node(b.List, bodyRootDot, guarded)
+	w.node(b.ElseList, rootDot, guarded)
+}
+
+func (w *walker) pipe(p *parse.PipeNode, rootDot, guarded bool) {
+	if p == nil {
+		return
+	}
+	for i, c := range p.Cmds {
+		g := guarded || isDefault(c)
+		if i+1 < len(p.Cmds) && isDefault(p.Cmds[i+1]) {
+			g = true
+		}
+		if name, ok := indexedParam(c); ok && rootDot {
+			w.field([]string{"Params", name}, g)
+			continue
+		}
+		for _, arg := range c.Args {
+			w.arg(arg, rootDot, g)
+		}
+	}
+}
+
+func (w *walker) arg(n parse.Node, rootDot, guarded bool) {
+	switch n := n.(type) {
+	case *parse.FieldNode:
+		if rootDot {
+			w.field(n.Ident, guarded)
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			w.field(n.Ident[1:], guarded)
+		}
+	case *parse.PipeNode:
+		w.pipe(n, rootDot, guarded)
+	case *parse.ChainNode:
+		w.arg(n.Node, rootDot, guarded)
+	}
+}
+
+func (w *walker) field(ident []string, guarded bool) {
+	switch {
+	case len(ident) == 0:
+	case ident[0] == "Input":
+		w.usesInput = true
+	case ident[0] == "Params" && len(ident) > 1:
+		if guarded || w.checked[ident[1]] > 0 {
+			w.optional[ident[1]] = true
+		} else {
+			w.required[ident[1]] = true
+		}
+	}
+}
+
+func isDefault(c *parse.CommandNode) bool {
+	if len(c.Args) == 0 {
+		return false
+	}
+	id, ok := c.Args[0].(*parse.IdentifierNode)
+	return ok && id.Ident == "default"
+}
+
+// indexedParam reports the parameter looked up by a command of the form index .Params "key".
+func indexedParam(c *parse.CommandNode) (string, bool) {
+	if len(c.Args) != 3 {
+		return "", false
+	}
+	id, ok := c.Args[0].(*parse.IdentifierNode)
+	if !ok || id.Ident != "index" {
+		return "", false
+	}
+	field, ok := c.Args[1].(*parse.FieldNode)
+	if !ok || len(field.Ident) != 1 || field.Ident[0] != "Params" {
+		return "", false
+	}
+	key, ok := c.Args[2].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+	return key.Text, true
+}
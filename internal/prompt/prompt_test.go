@@ -0,0 +1,135 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+
+	"keystone/internal/tickets"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender_LegacyPlaceholders(t *testing.T) {
+	data := NewData("a1", "hello", "", map[string]string{"query": "cats"}, nil)
+
+	out, err := Render("Lookup query: {{query}} for {{input}}", data)
+	require.NoError(t, err)
+	require.Equal(t, "Lookup query: cats for hello", out)
+
+	out, err = Render("{{input | upper}} / {{- input | lower -}} /", data)
+	require.NoError(t, err)
+	require.Equal(t, "HELLO /hello/", out)
+}
+
+func TestRender_ConditionalsLoopsAndFilters(t *testing.T) {
+	data := NewData("a1", "a long piece of input", "previous output", map[string]string{"tone": "formal"}, nil)
+
+	tpl := `{{if .Params.tone}}Tone: {{.Params.tone}}. {{end}}` +
+		`{{range $k, $v := .Params}}[{{$k}}={{$v}}]{{end}} ` +
+		`{{.Input | truncate 6}}|{{.Params.style | default "plain"}}|{{.Previous | json}}`
+	out, err := Render(tpl, data)
+	require.NoError(t, err)
+	require.Equal(t, `Tone: formal. [tone=formal] a long|plain|"previous output"`, out)
+}
+
+func TestRender_TicketContext(t *testing.T) {
+	tk := tickets.NewTicket("t1", "user1", nil)
+	tk.SetNamespaced("a1", "topic", "weather")
+	tk.SetNamespaced("a2", "city", "Oslo")
+
+	data := NewData("a1", "go", "", nil, tk)
+	out, err := Render("{{.Context.topic}} in {{.Ticket.a2.city}}{{.Ticket.missing.key}}", data)
+	require.NoError(t, err)
+	require.Equal(t, "weather in Oslo", out)
+}
+
+func TestRender_MissingVariables(t *testing.T) {
+	data := NewData("a1", "x", "", map[string]string{"task": "call"}, nil)
+
+	_, err := Render("Schedule {{task}} at {{time}} in {{.Params.zone}}", data)
+	var missing *MissingVariableError
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, []string{"time", "zone"}, missing.Names)
+	require.Contains(t, err.Error(), "missing template variable(s): time, zone")
+
+	_, err = Render("{{if .Params.zone}}{{.Params.zone}}{{end}}{{with .Params.time}}{{.}}{{end}}", data)
+	require.NoError(t, err)
+}
+
+func TestRender_InvalidTemplate(t *testing.T) {
+	_, err := Render("{{if .Input}}unterminated", NewData("a1", "", "", nil, nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid prompt template")
+}
+
+func TestBuild_AppendsInputWhenUnused(t *testing.T) {
+	data := NewData("a1", "hello", "", map[string]string{"name": "World"}, nil)
+
+	out, err := Build("Hello {{name}}!", data)
+	require.NoError(t, err)
+	require.Equal(t, "Hello World!\nhello", out)
+
+	out, err = Build("You are helpful. {{.Input}}", data)
+	require.NoError(t, err)
+	require.Equal(t, "You are helpful. hello", out)
+
+	out, err = Build("", data)
+	require.NoError(t, err)
+	require.Equal(t, "hello", out)
+}
+
+func TestInspect(t *testing.T) {
+	vars, err := Inspect("{{task}} {{.Params.zone | default \"UTC\"}} {{if .Params.x}}{{end}} {{$.Params.y}} {{input}}")
+	require.NoError(t, err)
+	require.Equal(t, []string{"task", "y"}, vars.Required)
+	require.Equal(t, []string{"x", "zone"}, vars.Optional)
+	require.True(t, vars.UsesInput)
+}
+
+func TestRender_LegacyPlaceholdersAsArguments(t *testing.T) {
+	data := NewData("a1", "hello world", "", map[string]string{"query": "cats and dogs", "sep": "-"}, nil)
+
+	out, err := Render(`{{truncate 5 input}}|{{printf "%s/%s" query previous}}|{{if query}}q{{end}}|{{default "x" missing}}|{{/* input */}}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "hello|cats and dogs/|q|x|", out)
+
+	vars, err := Inspect(`{{truncate 10 query}} {{printf "%s%s" sep .Params.list}}`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"list", "query", "sep"}, vars.Required)
+}
+
+func TestRender_LegacyPlaceholdersWithDashes(t *testing.T) {
+	data := NewData("a1", "hello", "", map[string]string{"max-len": "40"}, nil)
+
+	out, err := Render("Answer in {{max-len}} words: {{ max-len | printf \"%s!\" }}", data)
+	require.NoError(t, err)
+	require.Equal(t, "Answer in 40 words: 40!", out)
+
+	_, err = Render("{{min-len}}", data)
+	var missing *MissingVariableError
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, []string{"min-len"}, missing.Names)
+
+	vars, err := Inspect("{{max-len}}", "max-len")
+	require.NoError(t, err)
+	require.Equal(t, []string{"max-len"}, vars.Required)
+}
+
+func TestRender_LegacyPlaceholdersNamedLikeFuncs(t *testing.T) {
+	params := map[string]string{"default": "d", "upper": "u", "json": "j", "end": "e", "query": "q"}
+	data := NewData("a1", "hello", "", params, nil)
+
+	out, err := Render("{{default}} {{upper}} {{ json }} {{end}} {{upper | upper}}", data)
+	require.NoError(t, err)
+	require.Equal(t, "d u j e U", out)
+
+	// Called with arguments, the names are still functions.
+	delete(params, "end")
+	out, err = Render(`{{if query}}{{upper query}} {{default "x" .Params.none}}{{- end}}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "Q x", out)
+
+	vars, err := Inspect("{{default}} {{upper | upper}}", "default", "upper")
+	require.NoError(t, err)
+	require.Equal(t, []string{"default", "upper"}, vars.Required)
+}
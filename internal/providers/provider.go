@@ -7,6 +7,44 @@ type Provider interface {
 	UsageInfo() (Usage, error)
 }
 
+// StreamingProvider is implemented by providers that can emit a response incrementally.
+// onChunk is called with each piece of text as it arrives; the full response is returned.
+type StreamingProvider interface {
+	Provider
+	StreamResponse(ctx context.Context, prompt string, model string, onChunk func(string)) (string, error)
+}
+
+// Roles of the messages in a chat request.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is one role-tagged entry in a chat request.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatProvider is implemented by providers that take a conversation of messages
+// rather than a single prompt, so system prompts and examples keep their roles.
+type ChatProvider interface {
+	Provider
+	Chat(ctx context.Context, messages []Message, model string) (string, error)
+}
+
+// ModelCatalog is implemented by providers that publish the models they serve.
+type ModelCatalog interface {
+	Models() []string
+}
+
+// Embedder is implemented by providers that can turn text into embedding vectors.
+// One vector is returned per input text, in order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string, model string) ([][]float64, error)
+}
+
 type Usage struct {
 	Requests int
 	Tokens   int
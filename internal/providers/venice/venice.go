@@ -3,10 +3,22 @@ package venice
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"keystone/internal/providers"
 )
 
+// models is the catalog of model IDs the Venice API accepts; "default" lets the service choose.
+var models = []string{
+	"default",
+	"llama-3.2-3b",
+	"llama-3.3-70b",
+	"mistral-31-24b",
+	"qwen-2.5-coder-32b",
+	"qwen3-235b",
+	"venice-uncensored",
+}
+
 type VeniceProvider struct {
 	apiKey  string
 	baseURL string
@@ -31,7 +43,43 @@ func (v *VeniceProvider) GenerateResponse(ctx context.Context, prompt string, mo
 	return response, nil
 }
 
+// StreamResponse simulates a streamed API call by emitting the mocked response word by word.
+func (v *VeniceProvider) StreamResponse(ctx context.Context, prompt string, model string, onChunk func(string)) (string, error) {
+	response, err := v.GenerateResponse(ctx, prompt, model)
+	if err != nil {
+		return "", err
+	}
+	for _, chunk := range strings.SplitAfter(response, " ") {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		onChunk(chunk)
+	}
+	return response, nil
+}
+
+// Chat simulates a call to Venice's chat completions endpoint. The mocked reply
+// answers the last user message; every message counts toward usage.
+func (v *VeniceProvider) Chat(ctx context.Context, messages []providers.Message, model string) (string, error) {
+	var prompt string
+	for _, m := range messages {
+		v.usage.Tokens += len(m.Content) / 4
+		if m.Role == providers.RoleUser {
+			prompt = m.Content
+		}
+	}
+	v.usage.Requests++
+
+	response := fmt.Sprintf("🧠 Venice says (model=%s, %d messages): %q [mocked]", model, len(messages), prompt)
+	return response, nil
+}
+
 // UsageInfo returns mock usage data.
 func (v *VeniceProvider) UsageInfo() (providers.Usage, error) {
 	return v.usage, nil
 }
+
+// Models returns the model IDs served by Venice.
+func (v *VeniceProvider) Models() []string {
+	return append([]string(nil), models...)
+}
@@ -2,7 +2,10 @@ package venice
 
 import (
 	"context"
+	"strings"
 	"testing"
+
+	"keystone/internal/providers"
 )
 
 func TestGenerateResponseAndUsage(t *testing.T) {
@@ -45,3 +48,24 @@ func TestGenerateResponseAndUsage(t *testing.T) {
 		t.Errorf("expected 3 requests after 3 calls, got %d", usage.Requests)
 	}
 }
+
+func TestChat(t *testing.T) {
+	provider := New("MOCK_KEY", "")
+	messages := []providers.Message{
+		{Role: providers.RoleSystem, Content: "Be terse."},
+		{Role: providers.RoleUser, Content: "2+2?"},
+		{Role: providers.RoleAssistant, Content: "4"},
+		{Role: providers.RoleUser, Content: "3+3?"},
+	}
+
+	resp, err := provider.Chat(context.Background(), messages, "default")
+	if err != nil {
+		t.Fatalf("Chat error: %v", err)
+	}
+	if !strings.Contains(resp, "4 messages") || !strings.Contains(resp, `"3+3?"`) {
+		t.Errorf("expected reply to the last user message, got %q", resp)
+	}
+	if usage, _ := provider.UsageInfo(); usage.Requests != 1 {
+		t.Errorf("expected 1 request, got %d", usage.Requests)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -31,6 +32,7 @@ type Ticket struct {
 	MaxHops   int                    `json:"max_hops"`
 	TTL       time.Duration          `json:"ttl"`
 	mu        sync.Mutex
+	base      *Ticket // the state a branch started from
 }
 
 // OnHandoffHook is an optional function to receive handoff events.
@@ -156,6 +158,72 @@ func (t *Ticket) Handoff(agentID string) error {
 	return nil
 }
 
+// Branch returns a copy of the ticket that can be worked on independently of it,
+// such as by one of several attempts running in parallel. Merge applies the work
+// done on a branch back to the ticket.
+func (t *Ticket) Branch() *Ticket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.copyLocked()
+	b.base = t.copyLocked()
+	return b
+}
+
+// Merge applies the hops and steps spent and the context set on b, a branch of t, to t.
+func (t *Ticket) Merge(b *Ticket) {
+	b.mu.Lock()
+	hops, steps := b.Hops-b.base.Hops, b.Step-b.base.Step
+	changed := make(map[string]interface{})
+	for k, v := range b.Context {
+		if old, ok := b.base.Context[k]; !ok || !reflect.DeepEqual(old, v) {
+			changed[k] = v
+		}
+	}
+	b.mu.Unlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Hops += hops
+	t.Step += steps
+	if t.Context == nil {
+		t.Context = make(map[string]interface{}, len(changed))
+	}
+	for k, v := range changed {
+		t.Context[k] = v
+	}
+}
+
+// Charge applies only the hops spent on b, a branch of t, to t, for attempts whose
+// work is discarded but whose handoffs still count against MaxHops.
+func (t *Ticket) Charge(b *Ticket) {
+	b.mu.Lock()
+	hops := b.Hops - b.base.Hops
+	b.mu.Unlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Hops += hops
+}
+
+// copyLocked copies the ticket and its context; t.mu must be held.
+func (t *Ticket) copyLocked() *Ticket {
+	ctx := make(map[string]interface{}, len(t.Context))
+	for k, v := range t.Context {
+		ctx[k] = v
+	}
+	return &Ticket{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Context:   ctx,
+		CreatedAt: t.CreatedAt,
+		ExpiresAt: t.ExpiresAt,
+		Hops:      t.Hops,
+		Step:      t.Step,
+		MaxHops:   t.MaxHops,
+		TTL:       t.TTL,
+	}
+}
+
 // Serialize returns a snapshot of ticket fields.
 func (t *Ticket) Serialize() map[string]interface{} {
 	t.mu.Lock()
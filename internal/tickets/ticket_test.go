@@ -112,3 +112,31 @@ func TestTicketBasics(t *testing.T) {
 		assert.True(t, strings.Contains(id, "part2"))
 	})
 }
+
+func TestTicketBranchMerge(t *testing.T) {
+	ticket := NewTicket("ticket-branch", "user1", nil)
+	ticket.SetNamespaced("a", "kept", "yes")
+
+	winner, loser := ticket.Branch(), ticket.Branch()
+	assert.NoError(t, winner.Handoff("tool"))
+	winner.SetNamespaced("tool", "result", "42")
+	assert.NoError(t, loser.Handoff("tool"))
+	assert.NoError(t, loser.Handoff("tool"))
+	loser.SetNamespaced("tool", "result", "41")
+	assert.Equal(t, 0, ticket.Hops, "branches must not change the ticket")
+
+	ticket.SetNamespaced("a", "later", "set meanwhile")
+	ticket.Merge(winner)
+	assert.Equal(t, 1, ticket.Hops)
+	assert.Equal(t, 1, ticket.Step)
+	result, _ := ticket.GetNamespaced("tool", "result")
+	assert.Equal(t, "42", result)
+	later, _ := ticket.GetNamespaced("a", "later")
+	assert.Equal(t, "set meanwhile", later)
+
+	ticket.Charge(loser)
+	assert.Equal(t, 3, ticket.Hops, "the loser's hops are charged")
+	assert.Equal(t, 1, ticket.Step)
+	result, _ = ticket.GetNamespaced("tool", "result")
+	assert.Equal(t, "42", result, "the loser's context is discarded")
+}
@@ -0,0 +1,172 @@
+package toolbox
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+func calculatorTool() Tool {
+	return Tool{
+		Name:        Calculator,
+		Description: "evaluate an arithmetic expression with + - * / % ^ and parentheses, e.g. (2 + 3) * 4",
+		Run: func(_ context.Context, input string) (string, error) {
+			v, err := Evaluate(input)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatFloat(v, 'g', -1, 64), nil
+		},
+	}
+}
+
+// Evaluate computes an arithmetic expression of numbers, + - * / % ^ (right
+// associative power) and parentheses, with the usual precedence.
+func Evaluate(expr string) (float64, error) {
+	p := &calcParser{src: expr}
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.src[p.pos], p.pos+1)
+	}
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("result is not a finite number")
+	}
+	return v, nil
+}
+
+// calcParser is a recursive descent parser over src:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = unary { ("*" | "/" | "%") unary }
+//	unary  = ("+" | "-") unary | power
+//	power  = atom [ "^" unary ]
+//	atom   = number | "(" expr ")"
+type calcParser struct {
+	src string
+	pos int
+}
+
+func (p *calcParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept consumes op if it is next.
+func (p *calcParser) accept(op byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *calcParser) expr() (float64, error) {
+	v, err := p.term()
+	for err == nil {
+		switch {
+		case p.accept('+'):
+			var r float64
+			r, err = p.term()
+			v += r
+		case p.accept('-'):
+			var r float64
+			r, err = p.term()
+			v -= r
+		default:
+			return v, nil
+		}
+	}
+	return 0, err
+}
+
+func (p *calcParser) term() (float64, error) {
+	v, err := p.unary()
+	for err == nil {
+		var op byte
+		switch {
+		case p.accept('*'):
+			op = '*'
+		case p.accept('/'):
+			op = '/'
+		case p.accept('%'):
+			op = '%'
+		default:
+			return v, nil
+		}
+		var r float64
+		if r, err = p.unary(); err != nil {
+			break
+		}
+		switch {
+		case op == '*':
+			v *= r
+		case r == 0:
+			return 0, fmt.Errorf("division by zero")
+		case op == '/':
+			v /= r
+		default:
+			v = math.Mod(v, r)
+		}
+	}
+	return 0, err
+}
+
+func (p *calcParser) unary() (float64, error) {
+	if p.accept('-') {
+		v, err := p.unary()
+		return -v, err
+	}
+	if p.accept('+') {
+		return p.unary()
+	}
+	return p.power()
+}
+
+func (p *calcParser) power() (float64, error) {
+	v, err := p.atom()
+	if err != nil || !p.accept('^') {
+		return v, err
+	}
+	exp, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(v, exp), nil
+}
+
+func (p *calcParser) atom() (float64, error) {
+	if p.accept('(') {
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if !p.accept(')') {
+			return 0, fmt.Errorf("missing closing parenthesis")
+		}
+		return v, nil
+	}
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] == '.' || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+		p.pos++
+	}
+	if start == p.pos {
+		if p.pos >= len(p.src) {
+			return 0, fmt.Errorf("unexpected end of expression")
+		}
+		return 0, fmt.Errorf("unexpected %q at position %d", p.src[p.pos], p.pos+1)
+	}
+	v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", strings.TrimSpace(p.src[start:p.pos]))
+	}
+	return v, nil
+}
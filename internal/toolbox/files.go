@@ -0,0 +1,108 @@
+package toolbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func readFileTool(root string) Tool {
+	return Tool{
+		Name:        ReadFile,
+		Description: "read a text file; input is a path relative to the allowed directory",
+		Run: func(_ context.Context, input string) (string, error) {
+			path, err := sandboxPath(root, input)
+			if err != nil {
+				return "", err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return "", fmt.Errorf("reading %s: %w", input, err)
+			}
+			defer f.Close()
+			if info, err := f.Stat(); err == nil && info.IsDir() {
+				return "", fmt.Errorf("%s is a directory; use %s", input, ListDir)
+			}
+			data, err := io.ReadAll(io.LimitReader(f, MaxOutputBytes+1))
+			if err != nil {
+				return "", fmt.Errorf("reading %s: %w", input, err)
+			}
+			return truncate(string(data)), nil
+		},
+	}
+}
+
+func listDirTool(root string) Tool {
+	return Tool{
+		Name:        ListDir,
+		Description: `list a directory, marking subdirectories with "/"; input is a path relative to the allowed directory ("." for its root)`,
+		Run: func(_ context.Context, input string) (string, error) {
+			path, err := sandboxPath(root, input)
+			if err != nil {
+				return "", err
+			}
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return "", fmt.Errorf("listing %s: %w", input, err)
+			}
+			names := make([]string, 0, len(entries))
+			for _, e := range entries {
+				name := e.Name()
+				if e.IsDir() {
+					name += "/"
+				}
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if len(names) == 0 {
+				return "(empty directory)", nil
+			}
+			return strings.Join(names, "\n"), nil
+		},
+	}
+}
+
+// sandboxPath resolves rel inside root, following symlinks, and rejects paths that
+// are absolute or end up outside root.
+func sandboxPath(root, rel string) (string, error) {
+	rel = strings.TrimSpace(rel)
+	if rel == "" {
+		rel = "."
+	}
+	if filepath.IsAbs(rel) {
+		return "", fmt.Errorf("path %q must be relative to the allowed directory", rel)
+	}
+	base, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("allowed directory: %w", err)
+	}
+	base, err = filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(base, rel)
+	if !within(base, path) {
+		return "", fmt.Errorf("path %q is outside the allowed directory", rel)
+	}
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%s does not exist", rel)
+		}
+		return "", err
+	}
+	if !within(base, path) {
+		return "", fmt.Errorf("path %q is outside the allowed directory", rel)
+	}
+	return path, nil
+}
+
+// within reports whether path is base or below it.
+func within(base, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
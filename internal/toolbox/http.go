@@ -0,0 +1,68 @@
+package toolbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func httpGetTool(allowlist []string, client *http.Client) Tool {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+	// Copy the client so redirects are held to the allowlist without changing the caller's.
+	guarded := *client
+	guarded.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		return checkURL(req.URL, allowlist)
+	}
+
+	return Tool{
+		Name:        HTTPGet,
+		Description: "fetch a URL with HTTP GET; only these hosts are allowed: " + strings.Join(allowlist, ", "),
+		Run: func(ctx context.Context, input string) (string, error) {
+			u, err := url.Parse(strings.TrimSpace(input))
+			if err != nil {
+				return "", fmt.Errorf("invalid URL: %w", err)
+			}
+			if err := checkURL(u, allowlist); err != nil {
+				return "", err
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+			if err != nil {
+				return "", err
+			}
+			resp, err := guarded.Do(req)
+			if err != nil {
+				return "", err
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(io.LimitReader(resp.Body, MaxOutputBytes+1))
+			if err != nil {
+				return "", fmt.Errorf("reading response: %w", err)
+			}
+			return fmt.Sprintf("HTTP %d\n%s", resp.StatusCode, truncate(string(body))), nil
+		},
+	}
+}
+
+// checkURL allows http and https URLs whose host is on the allowlist.
+func checkURL(u *url.URL, allowlist []string) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("only http and https URLs are allowed")
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range allowlist {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || (strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:])) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %q is not on the allowlist", host)
+}
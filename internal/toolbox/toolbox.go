@@ -0,0 +1,120 @@
+// Package toolbox provides the sandboxed built-in tools that autonomous agents can
+// use: reading and listing files under one directory, arithmetic, HTTP GET against
+// a host allowlist and writing to the ticket context.
+package toolbox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Built-in tool names.
+const (
+	ReadFile     = "read_file"
+	ListDir      = "list_dir"
+	Calculator   = "calculator"
+	HTTPGet      = "http_get"
+	WriteContext = "write_context"
+)
+
+// MaxOutputBytes caps how much of a file or HTTP response a tool returns.
+const MaxOutputBytes = 64 * 1024
+
+// DefaultHTTPTimeout bounds each http_get request when no client is configured.
+const DefaultHTTPTimeout = 15 * time.Second
+
+// Names lists every built-in tool.
+func Names() []string {
+	return []string{ReadFile, ListDir, Calculator, HTTPGet, WriteContext}
+}
+
+// Tool is a named action an agent can take. Run receives the agent's action input
+// and returns the observation to show it; errors are reported to the agent too.
+type Tool struct {
+	Name        string
+	Description string
+	Run         func(ctx context.Context, input string) (string, error)
+}
+
+// Config sandboxes the tools. AllowedDir is the only directory read_file and
+// list_dir may see; HTTPAllowlist holds the hosts http_get may fetch, where
+// "*.example.com" matches any subdomain. HTTPClient defaults to a client with
+// DefaultHTTPTimeout.
+type Config struct {
+	AllowedDir    string
+	HTTPAllowlist []string
+	HTTPClient    *http.Client
+}
+
+// Check reports whether the named tools exist and cfg has what they need.
+func (cfg Config) Check(names []string) error {
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			return fmt.Errorf("tool %q is listed more than once", name)
+		}
+		seen[name] = true
+		switch name {
+		case ReadFile, ListDir:
+			if cfg.AllowedDir == "" {
+				return fmt.Errorf("tool %s needs allowed_dir", name)
+			}
+		case HTTPGet:
+			if len(cfg.HTTPAllowlist) == 0 {
+				return fmt.Errorf("tool %s needs http_allowlist", name)
+			}
+		case Calculator, WriteContext:
+		default:
+			return fmt.Errorf("unknown tool %q (want one of %s)", name, strings.Join(Names(), ", "))
+		}
+	}
+	return nil
+}
+
+// New builds the named tool. write_context depends on the ticket being worked on,
+// so it is built with ContextWriter instead.
+func New(name string, cfg Config) (Tool, error) {
+	if err := cfg.Check([]string{name}); err != nil {
+		return Tool{}, err
+	}
+	switch name {
+	case ReadFile:
+		return readFileTool(cfg.AllowedDir), nil
+	case ListDir:
+		return listDirTool(cfg.AllowedDir), nil
+	case Calculator:
+		return calculatorTool(), nil
+	case HTTPGet:
+		return httpGetTool(cfg.HTTPAllowlist, cfg.HTTPClient), nil
+	default:
+		return Tool{}, fmt.Errorf("tool %s cannot be built without a ticket; use ContextWriter", name)
+	}
+}
+
+// ContextWriter builds write_context, which stores "key=value" input with set.
+func ContextWriter(set func(key, value string)) Tool {
+	return Tool{
+		Name:        WriteContext,
+		Description: `store a value on the ticket for later steps and agents; input "key=value"`,
+		Run: func(_ context.Context, input string) (string, error) {
+			key, value, ok := strings.Cut(input, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" || strings.ContainsAny(key, " \t\n") {
+				return "", fmt.Errorf(`input must be "key=value" with a key without spaces`)
+			}
+			set(key, strings.TrimSpace(value))
+			return fmt.Sprintf("stored %s", key), nil
+		},
+	}
+}
+
+// truncate cuts s to MaxOutputBytes, noting that it did.
+func truncate(s string) string {
+	if len(s) <= MaxOutputBytes {
+		return s
+	}
+	return s[:MaxOutputBytes] + fmt.Sprintf("\n[truncated to %d bytes]", MaxOutputBytes)
+}
@@ -0,0 +1,145 @@
+package toolbox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func run(t *testing.T, tool Tool, input string) (string, error) {
+	t.Helper()
+	return tool.Run(context.Background(), input)
+}
+
+func TestEvaluate(t *testing.T) {
+	for expr, want := range map[string]float64{
+		"1 + 2 * 3":     7,
+		"(1 + 2) * 3":   9,
+		"-2 ^ 2":        -4,
+		"2 ^ 3 ^ 2":     512,
+		"10 % 4 - 1.5":  0.5,
+		"  7 / 2 ":      3.5,
+		"-(3 - 5) * +2": 4,
+	} {
+		got, err := Evaluate(expr)
+		if err != nil || got != want {
+			t.Errorf("Evaluate(%q) = %v, %v; want %v", expr, got, err, want)
+		}
+	}
+	for expr, wantErr := range map[string]string{
+		"1 / 0":      "division by zero",
+		"(1 + 2":     "missing closing parenthesis",
+		"2 +":        "unexpected end of expression",
+		"2 * x":      `unexpected 'x' at position 5`,
+		"1 2":        `unexpected '2' at position 3`,
+		"1.2.3 + 1":  `invalid number "1.2.3"`,
+		"10 ^ 10000": "not a finite number",
+	} {
+		if _, err := Evaluate(expr); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Evaluate(%q) error = %v; want %q", expr, err, wantErr)
+		}
+	}
+}
+
+func TestFileToolsStayInAllowedDir(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "a.md"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	read, _ := New(ReadFile, Config{AllowedDir: root})
+	list, _ := New(ListDir, Config{AllowedDir: root})
+
+	if out, err := run(t, read, "docs/a.md"); err != nil || out != "hello" {
+		t.Errorf("read_file = %q, %v", out, err)
+	}
+	if out, err := run(t, list, "."); err != nil || out != "docs/\nlink.txt" {
+		t.Errorf("list_dir = %q, %v", out, err)
+	}
+	for _, input := range []string{"../secret.txt", "docs/../../x", "link.txt", filepath.Join(outside, "secret.txt")} {
+		if out, err := run(t, read, input); err == nil {
+			t.Errorf("read_file(%q) escaped the sandbox: %q", input, out)
+		}
+	}
+	if _, err := run(t, read, "docs"); err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("expected directory error, got %v", err)
+	}
+	if _, err := run(t, read, "missing.md"); err == nil || !strings.Contains(err.Error(), "missing.md does not exist") {
+		t.Errorf("expected missing file error, got %v", err)
+	}
+}
+
+func TestHTTPGetAllowlist(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/away" {
+			http.Redirect(w, r, "http://blocked.example/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("pong"))
+	}))
+	defer srv.Close()
+	host := mustHost(t, srv.URL)
+
+	get, err := New(HTTPGet, Config{HTTPAllowlist: []string{host}, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := run(t, get, srv.URL+"/ping"); err != nil || out != "HTTP 200\npong" {
+		t.Errorf("http_get = %q, %v", out, err)
+	}
+	if _, err := run(t, get, srv.URL+"/away"); err == nil || !strings.Contains(err.Error(), `host "blocked.example" is not on the allowlist`) {
+		t.Errorf("redirects must stay on the allowlist, got %v", err)
+	}
+	if _, err := run(t, get, "file:///etc/passwd"); err == nil || !strings.Contains(err.Error(), "only http and https") {
+		t.Errorf("expected scheme error, got %v", err)
+	}
+
+	if err := checkURL(&url.URL{Scheme: "https", Host: "api.example.com"}, []string{"*.example.com"}); err != nil {
+		t.Errorf("wildcard should match subdomains: %v", err)
+	}
+	if err := checkURL(&url.URL{Scheme: "https", Host: "evilexample.com"}, []string{"*.example.com"}); err == nil {
+		t.Error("wildcard must not match other domains")
+	}
+}
+
+func TestContextWriterAndCheck(t *testing.T) {
+	got := map[string]string{}
+	w := ContextWriter(func(k, v string) { got[k] = v })
+	if out, err := run(t, w, "city = Paris"); err != nil || out != "stored city" || got["city"] != "Paris" {
+		t.Errorf("write_context = %q, %v, %v", out, err, got)
+	}
+	if _, err := run(t, w, "no equals"); err == nil {
+		t.Error("expected format error")
+	}
+
+	if err := (Config{}).Check([]string{Calculator, Calculator}); err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Errorf("expected duplicate error, got %v", err)
+	}
+	if _, err := New(WriteContext, Config{}); err == nil {
+		t.Error("write_context needs a ticket and cannot be built with New")
+	}
+}
+
+func mustHost(t *testing.T, raw string) string {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Hostname()
+}
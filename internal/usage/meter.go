@@ -0,0 +1,130 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"keystone/internal/providers"
+)
+
+type agentKey struct{}
+
+// WithAgent labels provider calls made with ctx as belonging to agentID.
+func WithAgent(ctx context.Context, agentID string) context.Context {
+	return context.WithValue(ctx, agentKey{}, agentID)
+}
+
+// AgentFromContext returns the label set by WithAgent, or "" when there is none.
+func AgentFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(agentKey{}).(string)
+	return id
+}
+
+// Meter wraps p so that every response is recorded in t under the provider name
+// and the agent labelled in the request context. Tokens are estimated from the
+// prompt and response at about four characters per token. Chat and embedding
+// support of p is preserved.
+func Meter(p providers.Provider, name string, t *Tracker) providers.Provider {
+	m := &metered{Provider: p, name: name, tracker: t}
+	c, chats := p.(providers.ChatProvider)
+	e, embeds := p.(providers.Embedder)
+	switch {
+	case chats && embeds:
+		return &meteredChatEmbedder{meteredChat: &meteredChat{metered: m, chat: c}, embedder: e}
+	case chats:
+		return &meteredChat{metered: m, chat: c}
+	case embeds:
+		return &meteredEmbedder{metered: m, embedder: e}
+	}
+	return m
+}
+
+type metered struct {
+	providers.Provider
+	name    string
+	tracker *Tracker
+}
+
+func (m *metered) GenerateResponse(ctx context.Context, prompt, model string) (string, error) {
+	resp, err := m.Provider.GenerateResponse(ctx, prompt, model)
+	if err == nil {
+		m.record(ctx, model, len(prompt)+len(resp))
+	}
+	return resp, err
+}
+
+// record adds an entry for a response to a request of chars characters in total.
+func (m *metered) record(ctx context.Context, model string, chars int) {
+	m.tracker.RecordEntry(Entry{
+		AgentID:  AgentFromContext(ctx),
+		Provider: m.name,
+		Model:    model,
+		Tokens:   (chars + 3) / 4,
+	})
+}
+
+type meteredChat struct {
+	*metered
+	chat providers.ChatProvider
+}
+
+func (m *meteredChat) Chat(ctx context.Context, messages []providers.Message, model string) (string, error) {
+	resp, err := m.chat.Chat(ctx, messages, model)
+	if err == nil {
+		chars := len(resp)
+		for _, msg := range messages {
+			chars += len(msg.Content)
+		}
+		m.record(ctx, model, chars)
+	}
+	return resp, err
+}
+
+type meteredEmbedder struct {
+	*metered
+	embedder providers.Embedder
+}
+
+func (m *meteredEmbedder) Embed(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	return m.embedder.Embed(ctx, texts, model)
+}
+
+type meteredChatEmbedder struct {
+	*meteredChat
+	embedder providers.Embedder
+}
+
+func (m *meteredChatEmbedder) Embed(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	return m.embedder.Embed(ctx, texts, model)
+}
+
+// Pricing maps a model or provider name to its price in USD per 1,000 tokens.
+type Pricing map[string]float64
+
+// ParsePricing reads "name=price" pairs, such as "gpt-4o=0.005".
+func ParsePricing(pairs []string) (Pricing, error) {
+	p := Pricing{}
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid price %q (want name=usd_per_1k_tokens)", pair)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("invalid price %q: want a non-negative number", pair)
+		}
+		p[strings.TrimSpace(name)] = price
+	}
+	return p, nil
+}
+
+// Cost prices e by its model, falling back to its provider; unpriced entries cost nothing.
+func (p Pricing) Cost(e Entry) float64 {
+	price, ok := p[e.Model]
+	if !ok {
+		price = p[e.Provider]
+	}
+	return price * float64(e.Tokens) / 1000
+}
@@ -10,6 +10,7 @@ type Entry struct {
 	RequestID string    // unique ID per request
 	AgentID   string    // which agent made the request
 	Provider  string    // provider used (e.g., venice)
+	Model     string    // model requested, when known
 	Tokens    int       // tokens used
 	Timestamp time.Time // time of the request
 }
@@ -35,16 +36,16 @@ func NewTracker() *Tracker {
 
 // Record adds a new usage entry to the tracker.
 func (t *Tracker) Record(agentID, provider string, tokens int) Entry {
+	return t.RecordEntry(Entry{AgentID: agentID, Provider: provider, Tokens: tokens})
+}
+
+// RecordEntry adds e to the tracker, assigning its request ID and timestamp.
+func (t *Tracker) RecordEntry(e Entry) Entry {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	e := Entry{
-		RequestID: generateID(),
-		AgentID:   agentID,
-		Provider:  provider,
-		Tokens:    tokens,
-		Timestamp: time.Now(),
-	}
+	e.RequestID = generateID()
+	e.Timestamp = time.Now()
 
 	t.entries = append(t.entries, e)
 	return e
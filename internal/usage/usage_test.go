@@ -1,9 +1,12 @@
 package usage
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
+
+	"keystone/internal/providers"
 )
 
 func TestUsageTracker(t *testing.T) {
@@ -71,3 +74,66 @@ func TestConcurrentRecordSafety(t *testing.T) {
 		t.Error("expected non-zero total tokens after concurrent writes")
 	}
 }
+
+func TestMeterRecordsAgentAndPricing(t *testing.T) {
+	tracker := NewTracker()
+	p := Meter(&echoProvider{}, "mock", tracker)
+
+	if _, err := p.GenerateResponse(WithAgent(context.Background(), "writer"), "12345678", "big"); err != nil {
+		t.Fatal(err)
+	}
+	entries := tracker.List()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.AgentID != "writer" || e.Provider != "mock" || e.Model != "big" || e.Tokens != 4 {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	pricing, err := ParsePricing([]string{"big=2.5", "mock=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pricing.Cost(e); got != 0.01 {
+		t.Errorf("expected model price to win, got %v", got)
+	}
+	e.Model = "other"
+	if got := pricing.Cost(e); got != 0.004 {
+		t.Errorf("expected provider fallback, got %v", got)
+	}
+	if _, err := ParsePricing([]string{"big"}); err == nil {
+		t.Error("expected an error for a price without a value")
+	}
+}
+
+func TestMeterKeepsChat(t *testing.T) {
+	tracker := NewTracker()
+	p, ok := Meter(&chatProvider{}, "mock", tracker).(providers.ChatProvider)
+	if !ok {
+		t.Fatal("metered chat provider should still implement ChatProvider")
+	}
+	messages := []providers.Message{{Role: providers.RoleSystem, Content: "1234"}, {Role: providers.RoleUser, Content: "5678"}}
+	if _, err := p.Chat(context.Background(), messages, "big"); err != nil {
+		t.Fatal(err)
+	}
+	if entries := tracker.List(); len(entries) != 1 || entries[0].Tokens != 3 {
+		t.Errorf("expected one entry of 3 tokens, got %+v", entries)
+	}
+}
+
+// chatProvider answers chats with the last message.
+type chatProvider struct{ echoProvider }
+
+func (chatProvider) Chat(_ context.Context, messages []providers.Message, _ string) (string, error) {
+	return messages[len(messages)-1].Content, nil
+}
+
+// echoProvider returns the prompt unchanged.
+type echoProvider struct{}
+
+func (echoProvider) GenerateResponse(_ context.Context, prompt, _ string) (string, error) {
+	return prompt, nil
+}
+
+func (echoProvider) UsageInfo() (providers.Usage, error) { return providers.Usage{}, nil }
@@ -0,0 +1,323 @@
+// Package voting samples several responses to one input and picks an answer by
+// self-consistency: the majority answer, or the one a scorer rates highest.
+package voting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"keystone/internal/logger"
+	"keystone/internal/tickets"
+)
+
+// How samples are compared.
+const (
+	MatchExact = "exact" // whole responses, ignoring surrounding whitespace and case
+	MatchJSON  = "json"  // one field of a JSON object in the response
+)
+
+// How the winning answer is picked.
+const (
+	PickMajority = "majority" // the answer most samples gave
+	PickScore    = "score"    // the answer the scorer agent rates highest
+)
+
+// DefaultSamples is how many responses are sampled when neither samples nor models are set.
+const DefaultSamples = 3
+
+// Config holds the voting settings of an agent or workflow step. Models, when set,
+// are cycled through so samples can come from several models. Field is the dotted
+// path of the JSON field compared with match: json. Scorer names the agent that
+// rates answers with pick: score.
+type Config struct {
+	Samples int      `yaml:"samples,omitempty" json:"samples,omitempty"`
+	Models  []string `yaml:"models,omitempty" json:"models,omitempty"`
+	Match   string   `yaml:"match,omitempty" json:"match,omitempty"`
+	Field   string   `yaml:"field,omitempty" json:"field,omitempty"`
+	Pick    string   `yaml:"pick,omitempty" json:"pick,omitempty"`
+	Scorer  string   `yaml:"scorer,omitempty" json:"scorer,omitempty"`
+}
+
+// IsZero reports whether no voting is configured.
+func (c Config) IsZero() bool {
+	return c.Samples == 0 && len(c.Models) == 0 && c.Match == "" && c.Field == "" && c.Pick == "" && c.Scorer == ""
+}
+
+// Validate checks that the settings are consistent.
+func (c Config) Validate() error {
+	if c.Samples < 0 {
+		return fmt.Errorf("voting samples must not be negative")
+	}
+	if n := c.SampleCount(); n < 2 {
+		return fmt.Errorf("voting needs at least two samples, got %d", n)
+	}
+	switch c.Match {
+	case "", MatchExact:
+		if c.Field != "" {
+			return fmt.Errorf("voting field requires match: %s", MatchJSON)
+		}
+	case MatchJSON:
+		if c.Field == "" {
+			return fmt.Errorf("voting match: %s requires a field", MatchJSON)
+		}
+	default:
+		return fmt.Errorf("unknown voting match %q (want %s or %s)", c.Match, MatchExact, MatchJSON)
+	}
+	switch c.Pick {
+	case "", PickMajority:
+		if c.Scorer != "" {
+			return fmt.Errorf("voting scorer requires pick: %s", PickScore)
+		}
+	case PickScore:
+		if c.Scorer == "" {
+			return fmt.Errorf("voting pick: %s requires a scorer agent", PickScore)
+		}
+	default:
+		return fmt.Errorf("unknown voting pick %q (want %s or %s)", c.Pick, PickMajority, PickScore)
+	}
+	return nil
+}
+
+// SampleCount returns how many responses are sampled: Samples, else one per model, else DefaultSamples.
+func (c Config) SampleCount() int {
+	switch {
+	case c.Samples > 0:
+		return c.Samples
+	case len(c.Models) > 0:
+		return len(c.Models)
+	default:
+		return DefaultSamples
+	}
+}
+
+// model returns the model for sample i, or "" to use the caller's default.
+func (c Config) model(i int) string {
+	if len(c.Models) == 0 {
+		return ""
+	}
+	return c.Models[i%len(c.Models)]
+}
+
+// Sampler produces one response using model, or the default model when model is "".
+type Sampler func(ctx context.Context, model string) (string, error)
+
+// TicketSampler produces sample i like a Sampler, working on t, a branch of the
+// caller's ticket of its own, or nil when the caller has no ticket.
+type TicketSampler func(ctx context.Context, i int, model string, t *tickets.Ticket) (string, error)
+
+// Scorer rates how well answer responds to input; higher is better.
+type Scorer func(ctx context.Context, input, answer string) (float64, error)
+
+// Sample is one sampled response. Key is the normalized answer it votes for; a
+// sample that failed or had no answer to compare carries Error instead.
+type Sample struct {
+	Model  string `json:"model,omitempty"`
+	Output string `json:"output,omitempty"`
+	Key    string `json:"key,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Result is the outcome of a vote. Votes maps each normalized answer to the number
+// of samples that gave it; Scores holds the scorer's ratings with pick: score.
+// Answer is the first response that gave the Winner.
+type Result struct {
+	Answer  string             `json:"answer"`
+	Winner  string             `json:"winner"`
+	Votes   map[string]int     `json:"votes"`
+	Scores  map[string]float64 `json:"scores,omitempty"`
+	Samples []Sample           `json:"samples"`
+}
+
+// Run draws the configured number of samples in parallel and picks the winning
+// answer. input is only passed to score, which pick: score requires. The result is
+// returned with the samples even when no answer could be picked.
+func Run(ctx context.Context, cfg Config, input string, sample Sampler, score Scorer) (*Result, error) {
+	return run(ctx, cfg, input, func(ctx context.Context, _ int, model string) (string, error) {
+		return sample(ctx, model)
+	}, score)
+}
+
+// RunTicket is Run for samples that work on a ticket. Each sample gets its own
+// branch of t, so samples do not race on t. Every sample's hops are charged to t,
+// but only the branch of the sample that gave the answer is merged back into it.
+func RunTicket(ctx context.Context, cfg Config, input string, t *tickets.Ticket, sample TicketSampler, score Scorer) (*Result, error) {
+	branches := make([]*tickets.Ticket, cfg.SampleCount())
+	if t != nil {
+		for i := range branches {
+			branches[i] = t.Branch()
+		}
+	}
+	res, err := run(ctx, cfg, input, func(ctx context.Context, i int, model string) (string, error) {
+		return sample(ctx, i, model, branches[i])
+	}, score)
+	if t != nil {
+		winner := -1
+		if err == nil {
+			winner = res.AnswerIndex()
+		}
+		for i, b := range branches {
+			if i == winner {
+				t.Merge(b)
+			} else {
+				t.Charge(b)
+			}
+		}
+	}
+	return res, err
+}
+
+func run(ctx context.Context, cfg Config, input string, sample func(ctx context.Context, i int, model string) (string, error), score Scorer) (*Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Pick == PickScore && score == nil {
+		return nil, fmt.Errorf("voting pick: %s has no scorer", PickScore)
+	}
+
+	res := &Result{Votes: map[string]int{}, Samples: make([]Sample, cfg.SampleCount())}
+	var wg sync.WaitGroup
+	for i := range res.Samples {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := Sample{Model: cfg.model(i)}
+			out, err := sample(ctx, i, s.Model)
+			if err != nil {
+				s.Error = err.Error()
+			} else {
+				s.Output = out
+				if s.Key, err = cfg.key(out); err != nil {
+					s.Error = err.Error()
+				}
+			}
+			res.Samples[i] = s
+		}(i)
+	}
+	wg.Wait()
+
+	// Candidates in the order samples first gave them, so ties go to the earliest.
+	var candidates []string
+	answers := map[string]string{}
+	for _, s := range res.Samples {
+		if s.Error != "" {
+			continue
+		}
+		if res.Votes[s.Key] == 0 {
+			candidates = append(candidates, s.Key)
+			answers[s.Key] = s.Output
+		}
+		res.Votes[s.Key]++
+	}
+	if len(candidates) == 0 {
+		return res, fmt.Errorf("no sample produced an answer: %s", res.Samples[0].Error)
+	}
+
+	best := candidates[0]
+	if cfg.Pick == PickScore {
+		res.Scores = map[string]float64{}
+		for _, key := range candidates {
+			v, err := score(ctx, input, answers[key])
+			if err != nil {
+				logger.Warn(fmt.Sprintf("Voting scorer %s failed on an answer: %v", cfg.Scorer, err), false)
+				continue
+			}
+			res.Scores[key] = v
+		}
+		if len(res.Scores) == 0 {
+			return res, fmt.Errorf("scorer %s rated no answer", cfg.Scorer)
+		}
+		for _, key := range candidates {
+			v, ok := res.Scores[key]
+			if !ok {
+				continue
+			}
+			if top, scored := res.Scores[best]; !scored || v > top || (v == top && res.Votes[key] > res.Votes[best]) {
+				best = key
+			}
+		}
+	} else {
+		for _, key := range candidates {
+			if res.Votes[key] > res.Votes[best] {
+				best = key
+			}
+		}
+	}
+	res.Winner, res.Answer = best, answers[best]
+	return res, nil
+}
+
+// AnswerIndex returns the index in Samples of the sample that gave Answer, or -1
+// when no answer was picked.
+func (r *Result) AnswerIndex() int {
+	for i, s := range r.Samples {
+		if r.Winner != "" && s.Error == "" && s.Key == r.Winner {
+			return i
+		}
+	}
+	return -1
+}
+
+// key normalizes a response into the answer it votes for.
+func (c Config) key(out string) (string, error) {
+	if c.Match != MatchJSON {
+		key := strings.ToLower(strings.TrimSpace(out))
+		if key == "" {
+			return "", fmt.Errorf("empty response")
+		}
+		return key, nil
+	}
+	start, end := strings.Index(out, "{"), strings.LastIndex(out, "}")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("response has no JSON object")
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(out[start:end+1]), &v); err != nil {
+		return "", fmt.Errorf("response has no valid JSON object: %w", err)
+	}
+	for _, name := range strings.Split(c.Field, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("response has no field %s", c.Field)
+		}
+		if v, ok = obj[name]; !ok {
+			return "", fmt.Errorf("response has no field %s", c.Field)
+		}
+	}
+	if s, ok := v.(string); ok {
+		return strings.ToLower(strings.TrimSpace(s)), nil
+	}
+	// Maps marshal with sorted keys, so equal objects normalize alike.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Record appends the result to voting.votes in agentID's ticket namespace.
+func (r *Result) Record(t *tickets.Ticket, agentID string) {
+	var history []*Result
+	if prev, ok := t.GetNamespaced(agentID, "voting.votes"); ok {
+		_ = json.Unmarshal([]byte(prev), &history)
+	}
+	history = append(history, r)
+	if data, err := json.Marshal(history); err == nil {
+		t.SetNamespaced(agentID, "voting.votes", string(data))
+	}
+}
+
+var scoreRe = regexp.MustCompile(`-?\d+(?:\.\d+)?`)
+
+// ParseScore reads the first number in a scorer's reply.
+func ParseScore(reply string) (float64, error) {
+	m := scoreRe.FindString(reply)
+	if m == "" {
+		return 0, fmt.Errorf("scorer reply has no score: %q", strings.TrimSpace(reply))
+	}
+	return strconv.ParseFloat(m, 64)
+}
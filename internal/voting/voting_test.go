@@ -0,0 +1,143 @@
+package voting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"keystone/internal/tickets"
+)
+
+// byModel samples the reply scripted for each model.
+func byModel(replies map[string]string) Sampler {
+	return func(_ context.Context, model string) (string, error) {
+		reply, ok := replies[model]
+		if !ok {
+			return "", errors.New("no such model")
+		}
+		return reply, nil
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, c := range []Config{{}, {Samples: 5}, {Models: []string{"a", "b"}}, {Match: MatchJSON, Field: "label"}, {Pick: PickScore, Scorer: "judge"}} {
+		if err := c.Validate(); err != nil {
+			t.Errorf("%+v: unexpected error %v", c, err)
+		}
+	}
+	for want, c := range map[string]Config{
+		"at least two samples": {Samples: 1},
+		"requires a field":     {Match: MatchJSON},
+		"requires match: json": {Field: "label"},
+		"unknown voting match": {Match: "fuzzy"},
+		"requires a scorer":    {Pick: PickScore},
+		"requires pick: score": {Scorer: "judge"},
+	} {
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%+v: expected %q, got %v", c, want, err)
+		}
+	}
+}
+
+func TestRunMajorityAcrossModels(t *testing.T) {
+	cfg := Config{Samples: 5, Models: []string{"a", "b", "c"}}
+	res, err := Run(context.Background(), cfg, "input", byModel(map[string]string{"a": " Positive\n", "b": "negative", "c": "positive"}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Models cycle a, b, c, a, b: three positive votes against two negative.
+	if res.Winner != "positive" || res.Answer != " Positive\n" || res.Votes["positive"] != 3 || res.Votes["negative"] != 2 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if res.Samples[3].Model != "a" {
+		t.Errorf("samples should cycle through the models: %+v", res.Samples)
+	}
+}
+
+func TestRunJSONField(t *testing.T) {
+	cfg := Config{Models: []string{"a", "b", "c", "d"}, Match: MatchJSON, Field: "result.label"}
+	res, err := Run(context.Background(), cfg, "input", byModel(map[string]string{
+		"a": `Sure: {"result": {"label": "Spam", "confidence": 0.9}}`,
+		"b": `{"result": {"label": "ham"}}`,
+		"c": `{"result": {"label": "spam ", "confidence": 0.6}}`,
+		"d": "not json",
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Winner != "spam" || res.Votes["spam"] != 2 || res.Votes["ham"] != 1 || len(res.Votes) != 2 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if res.Samples[3].Error == "" {
+		t.Error("a response without JSON should not vote")
+	}
+}
+
+func TestRunPickByScore(t *testing.T) {
+	cfg := Config{Models: []string{"a", "b", "c"}, Pick: PickScore, Scorer: "judge"}
+	score := func(_ context.Context, input, answer string) (float64, error) {
+		if input != "question" {
+			t.Errorf("scorer got input %q", input)
+		}
+		if strings.Contains(answer, "detailed") {
+			return 9, nil
+		}
+		return 4, nil
+	}
+	res, err := Run(context.Background(), cfg, "question", byModel(map[string]string{"a": "short", "b": "short", "c": "detailed"}), score)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Answer != "detailed" || res.Scores["detailed"] != 9 || res.Votes["short"] != 2 {
+		t.Errorf("the highest-scored answer should win over the majority: %+v", res)
+	}
+}
+
+func TestRunFailuresAndRecord(t *testing.T) {
+	res, err := Run(context.Background(), Config{Models: []string{"x", "y"}}, "input", byModel(nil), nil)
+	if err == nil || !strings.Contains(err.Error(), "no such model") || len(res.Samples) != 2 {
+		t.Fatalf("expected every sample to fail, got %+v, %v", res, err)
+	}
+
+	tk := tickets.NewTicket("t1", "default", nil)
+	res.Record(tk, "bot")
+	res.Record(tk, "bot")
+	raw, ok := tk.GetNamespaced("bot", "voting.votes")
+	if !ok || strings.Count(raw, `"samples"`) != 2 {
+		t.Errorf("expected two recorded votes, got %q", raw)
+	}
+}
+
+func TestRunTicketChargesEverySample(t *testing.T) {
+	tk := tickets.NewTicket("t1", "default", nil)
+	tk.MaxHops = 3
+	res, err := RunTicket(context.Background(), Config{Samples: 3}, "input", tk, func(_ context.Context, i int, _ string, b *tickets.Ticket) (string, error) {
+		if err := b.Handoff("tool"); err != nil {
+			return "", err
+		}
+		b.SetNamespaced("tool", "sample", string(rune('a'+i)))
+		return "same", nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tk.Hops != 3 {
+		t.Errorf("expected every sample's hop to be charged, got %d hops", tk.Hops)
+	}
+	if got, _ := tk.GetNamespaced("tool", "sample"); got != string(rune('a'+res.AnswerIndex())) {
+		t.Errorf("expected only the winner's context, got %q", got)
+	}
+	if err := tk.Handoff("next"); err == nil {
+		t.Error("expected the samples to have exhausted max hops")
+	}
+}
+
+func TestParseScore(t *testing.T) {
+	if v, err := ParseScore("Score: 7.5/10"); err != nil || v != 7.5 {
+		t.Errorf("ParseScore = %v, %v", v, err)
+	}
+	if _, err := ParseScore("great"); err == nil {
+		t.Error("expected an error for a reply without a number")
+	}
+}
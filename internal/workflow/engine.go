@@ -2,12 +2,13 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 
 	"keystone/internal/agent"
 	"keystone/internal/logger"
 	"keystone/internal/tickets"
+	"keystone/internal/voting"
 )
 
 // Engine coordinates workflow execution
@@ -29,19 +30,17 @@ func (e *Engine) Run(ctx context.Context, wf Workflow, ticket *tickets.Ticket) (
 	logger.Info(fmt.Sprintf("Starting workflow '%s' with %d steps", wf.ID, len(wf.Steps)), false)
 
 	for i, step := range wf.Steps {
-		a, err := e.manager.Get(step.AgentID)
+		a, err := e.stepAgent(step)
 		if err != nil {
-			logger.Error(fmt.Sprintf("Failed to get agent '%s': %v", step.AgentID, err), false)
-			return results, fmt.Errorf("failed to get agent %s: %w", step.AgentID, err)
+			logger.Error(fmt.Sprintf("Failed to get agent for step %d: %v", i, err), false)
+			return results, err
 		}
 
-		// Merge step params with agent default params
-		params := make(map[string]string)
-		for k, v := range a.Parameters() {
-			params[k] = v
-		}
-		for k, v := range step.Params {
-			params[k] = v
+		// Merge step params with agent default params and check them against the agent's schema
+		if _, err := agent.MergeParams(a, step.Params); err != nil {
+			logger.Error(fmt.Sprintf("Invalid parameters for agent '%s': %v", a.ID(), err), false)
+			results = append(results, StepResult{AgentID: a.ID(), Output: "", Error: err})
+			return results, fmt.Errorf("step %d: %w", i, err)
 		}
 
 		// Determine final input
@@ -51,21 +50,25 @@ func (e *Engine) Run(ctx context.Context, wf Workflow, ticket *tickets.Ticket) (
 		}
 
 		// Apply agent prompt template
-		if a.PromptTemplate() != "" {
-			template := a.PromptTemplate()
-			for k, v := range params {
-				template = replacePlaceholder(template, k, v)
-			}
-			finalInput = fmt.Sprintf("%s\n%s", template, finalInput)
+		stepCtx, finalInput, err := agent.PrepareInput(ctx, a, finalInput, agent.PromptVars{Params: step.Params, Previous: prevOutput}, ticket)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to render prompt for agent '%s': %v", a.ID(), err), false)
+			results = append(results, StepResult{AgentID: a.ID(), Output: "", Error: err})
+			return results, fmt.Errorf("agent %s prompt: %w", a.ID(), err)
 		}
 
 		logger.Info(fmt.Sprintf("Running step %d - Agent '%s'", i, a.ID()), false)
 
 		// Run the agent
-		output, err := a.Handle(ctx, finalInput, ticket)
+		output, votes, err := e.runStep(stepCtx, step, a, finalInput, ticket)
 		if err != nil {
+			var timeout *agent.TimeoutError
 			logger.Error(fmt.Sprintf("Agent '%s' failed: %v", a.ID(), err), false)
-			results = append(results, StepResult{AgentID: a.ID(), Output: "", Error: err})
+			results = append(results, StepResult{AgentID: a.ID(), Output: "", Error: err, Votes: votes, TimedOut: errors.As(err, &timeout)})
+			if timeout != nil {
+				// The timeout error already names the agent.
+				return results, fmt.Errorf("step %d: %w", i, err)
+			}
 			return results, fmt.Errorf("agent %s failed: %w", a.ID(), err)
 		}
 
@@ -78,7 +81,7 @@ func (e *Engine) Run(ctx context.Context, wf Workflow, ticket *tickets.Ticket) (
 
 		// Increment step, pass verbose flag from Engine
 		ticket.IncrementStep(e.verbose)
-		results = append(results, StepResult{AgentID: a.ID(), Output: output, Error: nil})
+		results = append(results, StepResult{AgentID: a.ID(), Output: output, Error: nil, Votes: votes})
 
 		logger.Info(fmt.Sprintf("Step %d - Agent '%s' output:\n%s", i, a.ID(), output), false)
 
@@ -90,7 +93,45 @@ func (e *Engine) Run(ctx context.Context, wf Workflow, ticket *tickets.Ticket) (
 	return results, nil
 }
 
-// Helper: replace {{key}} in template with value
-func replacePlaceholder(template, key, val string) string {
-	return strings.ReplaceAll(template, fmt.Sprintf("{{%s}}", key), val)
+// runStep runs the step's agent on input: once, or for a vote step once per sample,
+// each on its own branch of the ticket, recording the vote in the agent's ticket namespace.
+func (e *Engine) runStep(ctx context.Context, step Step, a agent.Agent, input string, ticket *tickets.Ticket) (string, *voting.Result, error) {
+	switch step.Type {
+	case "":
+		output, err := a.Handle(ctx, input, ticket)
+		return output, nil, err
+	case StepVote:
+		var score voting.Scorer
+		if step.Voting.Scorer != "" {
+			score = agent.VoteScorer(e.manager, step.Voting.Scorer)
+		}
+		res, err := voting.RunTicket(ctx, step.Voting, input, ticket, func(ctx context.Context, _ int, model string, t *tickets.Ticket) (string, error) {
+			return a.Handle(agent.WithModelOverride(ctx, model), input, t)
+		}, score)
+		if res != nil {
+			res.Record(ticket, a.ID())
+		}
+		if err != nil {
+			return "", res, fmt.Errorf("vote: %w", err)
+		}
+		return res.Answer, res, nil
+	default:
+		return "", nil, fmt.Errorf("unknown step type %q", step.Type)
+	}
+}
+
+// stepAgent returns the agent named by the step, or the first agent with its capability.
+func (e *Engine) stepAgent(step Step) (agent.Agent, error) {
+	if step.AgentID == "" && step.Capability != "" {
+		a, err := e.manager.FirstWithCapability(step.Capability)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find agent: %w", err)
+		}
+		return a, nil
+	}
+	a, err := e.manager.Get(step.AgentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent %s: %w", step.AgentID, err)
+	}
+	return a, nil
 }
@@ -3,10 +3,12 @@ package workflow
 import (
 	"context"
 	"testing"
+	"time"
 
 	"keystone/internal/agent"
 	"keystone/internal/providers"
 	"keystone/internal/tickets"
+	"keystone/internal/voting"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -131,3 +133,107 @@ func TestWorkflow_ParamMerging(t *testing.T) {
 	assert.Contains(t, results[0].Output, "input")
 	assert.Contains(t, results[0].Output, "[mocked]")
 }
+
+func TestWorkflow_ParamSchemaRejectsInvalidStep(t *testing.T) {
+	manager := agent.NewManager()
+	schema := agent.ParamSchema{"limit": {Type: agent.ParamInt, Default: "3"}}
+	typed := agent.NewAgent("typed", "Typed", "", &agent.MockProvider{}, "m", "none",
+		agent.WithPromptTemplate("limit={{limit}}"), agent.WithParameterSchema(schema))
+	_ = manager.Register(typed)
+	engine := NewEngine(manager, false)
+
+	results, err := engine.Run(context.Background(), Workflow{ID: "ok", Steps: []Step{{AgentID: "typed", Input: "go"}}}, tickets.NewTicket("t4", "default", nil))
+	assert.NoError(t, err)
+	assert.Contains(t, results[0].Output, "limit=3")
+
+	wf := Workflow{ID: "bad", Steps: []Step{{AgentID: "typed", Input: "go", Params: map[string]string{"limit": "many"}}}}
+	_, err = engine.Run(context.Background(), wf, tickets.NewTicket("t5", "default", nil))
+	assert.ErrorContains(t, err, `"many" is not an int`)
+}
+
+func TestWorkflow_StepByCapability(t *testing.T) {
+	manager := agent.NewManager()
+	_ = manager.Register(agent.NewAgent("writer", "Writer", "", &agent.MockProvider{}, "m", "none", agent.WithCapabilities("summarization")))
+	engine := NewEngine(manager, false)
+
+	wf := Workflow{ID: "cap", Steps: []Step{{Capability: "summarization", Input: "long text"}}}
+	results, err := engine.Run(context.Background(), wf, tickets.NewTicket("t6", "default", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "writer", results[0].AgentID)
+
+	wf.Steps[0].Capability = "translation"
+	_, err = engine.Run(context.Background(), wf, tickets.NewTicket("t7", "default", nil))
+	assert.ErrorContains(t, err, `no agent with capability "translation"`)
+}
+
+// labelAgent answers with the label scripted for the model it is asked to use,
+// handing the ticket to a tool and noting the model on it first.
+type labelAgent struct {
+	MockAgent
+	labels map[string]string
+}
+
+func (a *labelAgent) Handle(ctx context.Context, _ string, t *tickets.Ticket) (string, error) {
+	model := agent.ModelFromContext(ctx, a.DefaultModel())
+	if t != nil {
+		if err := t.Handoff("tool"); err != nil {
+			return "", err
+		}
+		t.SetNamespaced(a.id, "model", model)
+	}
+	return a.labels[model], nil
+}
+
+func TestWorkflow_VoteStep(t *testing.T) {
+	manager := agent.NewManager()
+	_ = manager.Register(&labelAgent{MockAgent: MockAgent{id: "classify"}, labels: map[string]string{"a": `{"label": "spam"}`, "b": `{"label": "ham"}`, "mock-model": `{"label": "Spam"}`}})
+	_ = manager.Register(&MockAgent{id: "echo"})
+
+	wf := Workflow{
+		ID: "wf-vote",
+		Steps: []Step{
+			{AgentID: "classify", Type: StepVote, Input: "buy now", Voting: voting.Config{Models: []string{"a", "b", ""}, Match: voting.MatchJSON, Field: "label"}},
+			{AgentID: "echo"},
+		},
+	}
+	ticket := tickets.NewTicket("t-vote", "default", nil)
+	results, err := NewEngine(manager, false).Run(context.Background(), wf, ticket)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	assert.Equal(t, `{"label": "spam"}`, results[0].Output)
+	assert.Equal(t, map[string]int{"spam": 2, "ham": 1}, results[0].Votes.Votes)
+	assert.Contains(t, results[1].Output, `{"label": "spam"}`, "the winning answer feeds the next step")
+
+	raw, ok := ticket.GetNamespaced("classify", "voting.votes")
+	assert.True(t, ok)
+	assert.Contains(t, raw, `"votes":{"ham":1,"spam":2}`)
+	model, _ := ticket.GetNamespaced("classify", "model")
+	assert.Equal(t, "a", model, "only the winning sample's ticket changes are kept")
+	assert.Equal(t, 5, ticket.Hops, "one hop for every sample and one per step")
+
+	wf.Steps = []Step{{AgentID: "echo", Type: "poll", Input: "x"}}
+	_, err = NewEngine(manager, false).Run(context.Background(), wf, ticket)
+	assert.ErrorContains(t, err, `unknown step type "poll"`)
+}
+
+// blockingProvider waits until the call's context ends.
+type blockingProvider struct{ MockProvider }
+
+func (p *blockingProvider) GenerateResponse(ctx context.Context, _, _ string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestWorkflow_StepTimeout(t *testing.T) {
+	manager := agent.NewManager()
+	limiter := manager.Limiter("slow", agent.Limits{Timeout: 10 * time.Millisecond})
+	_ = manager.Register(agent.NewAgent("slow", "Slow", "", &blockingProvider{}, "m", "none", agent.WithLimiter(limiter)))
+
+	wf := Workflow{ID: "wf-timeout", Steps: []Step{{AgentID: "slow", Input: "hi"}}}
+	results, err := NewEngine(manager, false).Run(context.Background(), wf, tickets.NewTicket("t-timeout", "default", nil))
+	assert.EqualError(t, err, "step 0: agent slow timed out after 10ms")
+	assert.Len(t, results, 1)
+	assert.True(t, results[0].TimedOut)
+	assert.ErrorIs(t, results[0].Error, context.DeadlineExceeded)
+}
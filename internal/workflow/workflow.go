@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"keystone/internal/voting"
 )
 
 // -------------------------
@@ -113,10 +115,21 @@ func (s *Store) Delete(id string) error {
 // Workflow structs (existing)
 // -------------------------
 
+// Step types. A step without a type runs its agent once.
+const (
+	StepVote = "vote" // sample the agent several times and keep the winner of a vote
+)
+
+// Step runs one agent. Capability may be given instead of AgentID to use the
+// first registered agent (by ID) that advertises it. A vote step samples the
+// agent as its Voting settings say.
 type Step struct {
-	AgentID string            `yaml:"agent_id"`
-	Input   string            `yaml:"input"`
-	Params  map[string]string `yaml:"params,omitempty"`
+	AgentID    string            `yaml:"agent_id,omitempty"`
+	Capability string            `yaml:"capability,omitempty"`
+	Type       string            `yaml:"type,omitempty"`
+	Input      string            `yaml:"input"`
+	Params     map[string]string `yaml:"params,omitempty"`
+	Voting     voting.Config     `yaml:"voting,omitempty"`
 }
 
 type Workflow struct {
@@ -124,8 +137,13 @@ type Workflow struct {
 	Steps []Step `yaml:"steps"`
 }
 
+// StepResult is the outcome of a step. Votes is set for vote steps; TimedOut is
+// set when the step's agent ran past its timeout, Error then being an
+// *agent.TimeoutError.
 type StepResult struct {
-	AgentID string
-	Output  string
-	Error   error
+	AgentID  string
+	Output   string
+	Error    error
+	Votes    *voting.Result
+	TimedOut bool
 }